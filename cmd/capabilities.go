@@ -0,0 +1,22 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/spf13/cobra"
+)
+
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Print the version and supported features of the plugin as JSON",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return licenseplugin.WriteCapabilities(licenseplugin.NewCapabilities(Version), cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(capabilitiesCmd)
+}
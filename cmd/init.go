@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin/config"
+	"github.com/palantir/godel-license-plugin/licenseplugin/spdx"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Write a starter configuration file for a license",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configFlagVal == "" {
+				return errors.Errorf("configuration file must be specified using the --config flag")
+			}
+			if _, err := os.Stat(configFlagVal); err == nil {
+				return errors.Errorf("configuration file %s already exists", configFlagVal)
+			} else if !os.IsNotExist(err) {
+				return errors.Wrapf(err, "failed to stat %s", configFlagVal)
+			}
+
+			license, err := spdx.Lookup(initSPDXFlagVal)
+			if err != nil {
+				return err
+			}
+			if initHolderFlagVal == "" && strings.Contains(license.Header, spdx.HolderPlaceholder) {
+				return errors.Errorf("--holder must be specified for license %s", license.ID)
+			}
+			cfgBytes, err := config.StarterConfig(license, initHolderFlagVal)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(configFlagVal), 0755); err != nil {
+				return errors.Wrapf(err, "failed to create directory for %s", configFlagVal)
+			}
+			if err := os.WriteFile(configFlagVal, cfgBytes, 0644); err != nil {
+				return errors.Wrapf(err, "failed to write %s", configFlagVal)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote configuration for %s to %s\n", license.ID, configFlagVal)
+			return nil
+		},
+	}
+
+	initSPDXFlagVal   string
+	initHolderFlagVal string
+)
+
+func init() {
+	initCmd.Flags().StringVar(&initSPDXFlagVal, "spdx", "", "SPDX identifier of the license")
+	initCmd.Flags().StringVar(&initHolderFlagVal, "holder", "", "copyright holder (required unless the license has no copyright holder)")
+	if err := initCmd.MarkFlagRequired("spdx"); err != nil {
+		panic(err)
+	}
+	rootCmd.AddCommand(initCmd)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	printHeaderCmd = &cobra.Command{
+		Use:   "print-header",
+		Short: "Print the license header that would be applied to a file without modifying the file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectParam, err := loadProjectParam(projectDirFlagVal, configFlagVal, godelConfigFileFlagVal, nil)
+			if err != nil {
+				return err
+			}
+			header, ok, err := licenseplugin.RenderHeader(printHeaderFilenameFlagVal, projectParam)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.Errorf("no license header applies to %s", printHeaderFilenameFlagVal)
+			}
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), header)
+			return nil
+		},
+	}
+
+	printHeaderFilenameFlagVal string
+)
+
+func init() {
+	printHeaderCmd.Flags().StringVar(&printHeaderFilenameFlagVal, "filename", "", "path of the file whose license header is printed (the file does not need to exist)")
+	if err := printHeaderCmd.MarkFlagRequired("filename"); err != nil {
+		panic(err)
+	}
+	rootCmd.AddCommand(printHeaderCmd)
+}
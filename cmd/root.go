@@ -5,8 +5,13 @@
 package cmd
 
 import (
+	"fmt"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/palantir/godel-license-plugin/licenseplugin/config"
 	"github.com/palantir/godel/v2/framework/pluginapi"
 	"github.com/palantir/pkg/cobracli"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -14,22 +19,43 @@ var (
 	rootCmd = &cobra.Command{
 		Use:   "license",
 		Short: "Apply, verify and remove license headers from project files",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// the project directory is required for all operations other than those that operate on stdin and those
+			// that do not operate on the project
+			if projectDirFlagVal == "" && !(cmd == runCmd && stdinFlagVal) && cmd != capabilitiesCmd && cmd != initCmd {
+				return fmt.Errorf(`required flag(s) "%s" not set`, pluginapi.ProjectDirFlagName)
+			}
+			return nil
+		},
 	}
 
 	projectDirFlagVal      string
 	godelConfigFileFlagVal string
 	configFlagVal          string
+	configSearchFlagVal    []string
 )
 
 func Execute() int {
-	return cobracli.ExecuteWithDefaultParams(rootCmd)
+	return cobracli.ExecuteWithDefaultParams(rootCmd, cobracli.ExitCodeExtractorParam(exitCode))
+}
+
+// exitCode returns the exit code for the provided error returned by a command: the exit code specified by
+// --verify-exit-code if files failed verification, the exit code specified by --changed-exit-code (or implied by
+// --exit-nonzero-on-change) if files were changed and 1 otherwise.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, licenseplugin.ErrVerifyFailed):
+		return verifyExitCodeFlagVal
+	case errors.Is(err, licenseplugin.ErrFilesChanged):
+		return changedExitCodeFlagVal
+	default:
+		return 1
+	}
 }
 
 func init() {
 	pluginapi.AddProjectDirPFlagPtr(rootCmd.PersistentFlags(), &projectDirFlagVal)
 	pluginapi.AddGodelConfigPFlagPtr(rootCmd.PersistentFlags(), &godelConfigFileFlagVal)
 	pluginapi.AddConfigPFlagPtr(rootCmd.PersistentFlags(), &configFlagVal)
-	if err := rootCmd.MarkPersistentFlagRequired(pluginapi.ProjectDirFlagName); err != nil {
-		panic(err)
-	}
+	rootCmd.PersistentFlags().StringSliceVar(&configSearchFlagVal, "config-search", config.DefaultSearchPaths, "candidate configuration files (relative to the project directory) that are searched in order if --config is not specified; the first one that exists is used")
 }
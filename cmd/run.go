@@ -5,49 +5,424 @@
 package cmd
 
 import (
-	"github.com/palantir/go-license/commoncmd"
-	"github.com/palantir/go-license/golicense"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/palantir/godel-license-plugin/licenseplugin/config"
 	godelconfig "github.com/palantir/godel/v2/framework/godel/config"
 	"github.com/palantir/godel/v2/framework/godellauncher"
 	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+// exitNonzeroOnChangeExitCode is the exit code returned when --exit-nonzero-on-change is specified and apply or remove
+// modifies any files. It differs from the exit code of other failures so that callers can distinguish the two.
+const exitNonzeroOnChangeExitCode = 3
+
 var (
 	runCmd = &cobra.Command{
 		Use: "run",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			projectCfg, err := commoncmd.LoadConfig(configFlagVal)
+			if countOnlyFlagVal && !verifyFlagVal {
+				return errors.Errorf("--count-only can only be specified when --verify is used")
+			}
+			if verifyExitCodeFlagVal < 1 || verifyExitCodeFlagVal > 255 {
+				return errors.Errorf("--verify-exit-code must be between 1 and 255: %d", verifyExitCodeFlagVal)
+			}
+			if changedExitCodeFlagVal < 0 || changedExitCodeFlagVal > 255 {
+				return errors.Errorf("--changed-exit-code must be between 0 and 255: %d", changedExitCodeFlagVal)
+			}
+			if exitNonzeroOnChangeFlagVal {
+				if cmd.Flags().Changed("changed-exit-code") {
+					return errors.Errorf("--exit-nonzero-on-change cannot be specified with --changed-exit-code")
+				}
+				changedExitCodeFlagVal = exitNonzeroOnChangeExitCode
+			}
+			if addOnlyFlagVal && (verifyFlagVal || removeFlagVal) {
+				return errors.Errorf("--add-only cannot be specified with --verify or --remove")
+			}
+			if skipInvalidGoFlagVal && (verifyFlagVal || removeFlagVal) {
+				return errors.Errorf("--skip-invalid-go cannot be specified with --verify or --remove")
+			}
+			if checkCommitYearFlagVal && !verifyFlagVal {
+				return errors.Errorf("--check-commit-year can only be specified when --verify is used")
+			}
+			colorMode, err := licenseplugin.ParseColorMode(colorFlagVal)
 			if err != nil {
 				return err
 			}
-			if godelConfigFileFlagVal != "" {
-				excludes, err := godelconfig.ReadGodelConfigExcludesFromFile(godelConfigFileFlagVal)
-				if err != nil {
+			pathBase, err := licenseplugin.ParsePathBase(pathBaseFlagVal)
+			if err != nil {
+				return err
+			}
+			outputFormat, err := licenseplugin.ParseOutputFormat(outputFlagVal)
+			if err != nil {
+				return err
+			}
+			if countOnlyFlagVal && outputFormat != licenseplugin.OutputText {
+				return errors.Errorf("--count-only cannot be specified with --output %s", outputFormat)
+			}
+			textLayout, err := licenseplugin.ParseTextLayout(formatOutputFlagVal)
+			if err != nil {
+				return err
+			}
+			if textLayout != licenseplugin.TextLayoutList {
+				switch {
+				case !verifyFlagVal:
+					return errors.Errorf("--format-output can only be specified when --verify is used")
+				case outputFormat != licenseplugin.OutputText:
+					return errors.Errorf("--format-output cannot be specified with --output %s", outputFormat)
+				case countOnlyFlagVal:
+					return errors.Errorf("--format-output cannot be specified with --count-only")
+				}
+			}
+			if dryRunFlagVal {
+				switch {
+				case verifyFlagVal:
+					return errors.Errorf("--dry-run cannot be specified with --verify")
+				case stdinFlagVal:
+					return errors.Errorf("--dry-run cannot be specified with --stdin")
+				case outputFormat == licenseplugin.OutputSARIF || outputFormat == licenseplugin.OutputGitHub || outputFormat == licenseplugin.OutputCheckstyle:
+					return errors.Errorf("--dry-run cannot be specified with --output %s", outputFormat)
+				}
+			}
+			if fixAfterFlagVal {
+				switch {
+				case !verifyFlagVal:
+					return errors.Errorf("--fix-after can only be specified when --verify is used")
+				case archiveFlagVal != "":
+					return errors.Errorf("--fix-after cannot be specified with --archive")
+				case stdinFlagVal:
+					return errors.Errorf("--fix-after cannot be specified with --stdin")
+				case outputFormat != licenseplugin.OutputText:
+					return errors.Errorf("--fix-after cannot be specified with --output %s", outputFormat)
+				}
+			}
+			if manifestFlagVal != "" {
+				switch {
+				case !verifyFlagVal:
+					return errors.Errorf("--manifest can only be specified when --verify is used")
+				case archiveFlagVal != "":
+					return errors.Errorf("--manifest cannot be specified with --archive")
+				case stdinFlagVal:
+					return errors.Errorf("--manifest cannot be specified with --stdin")
+				case fileFlagVal != "":
+					return errors.Errorf("--manifest cannot be specified with --file")
+				case streamFilesFlagVal:
+					return errors.Errorf("--manifest cannot be specified with --stream-files")
+				case len(subProjectFlagVal) > 0:
+					return errors.Errorf("--manifest cannot be specified with --sub-project")
+				case fixAfterFlagVal:
+					return errors.Errorf("--manifest cannot be specified with --fix-after")
+				}
+			}
+			var modifiedSince time.Time
+			if modifiedSinceFlagVal != "" {
+				switch {
+				case archiveFlagVal != "":
+					return errors.Errorf("--modified-since cannot be specified with --archive")
+				case stdinFlagVal:
+					return errors.Errorf("--modified-since cannot be specified with --stdin")
+				}
+				if modifiedSince, err = licenseplugin.ParseModifiedSince(modifiedSinceFlagVal, time.Now()); err != nil {
 					return err
 				}
-				projectCfg.Exclude.Add(excludes)
 			}
-			projectParam, err := projectCfg.ToParam()
+			if maxDepthFlagVal != 0 {
+				switch {
+				case maxDepthFlagVal < 0:
+					return errors.Errorf("--max-depth must not be negative: %d", maxDepthFlagVal)
+				case archiveFlagVal != "":
+					return errors.Errorf("--max-depth cannot be specified with --archive")
+				case stdinFlagVal:
+					return errors.Errorf("--max-depth cannot be specified with --stdin")
+				}
+			}
+			if manifestClosedFlagVal && manifestFlagVal == "" {
+				return errors.Errorf("--manifest-closed can only be specified when --manifest is used")
+			}
+			if backupFlagVal {
+				switch {
+				case verifyFlagVal && !fixAfterFlagVal:
+					return errors.Errorf("--backup cannot be specified with --verify unless --fix-after is used")
+				case dryRunFlagVal:
+					return errors.Errorf("--backup cannot be specified with --dry-run")
+				case stdinFlagVal:
+					return errors.Errorf("--backup cannot be specified with --stdin")
+				}
+			}
+			if failFastFlagVal {
+				switch {
+				case !verifyFlagVal:
+					return errors.Errorf("--fail-fast can only be specified when --verify is used")
+				case continueOnFileErrorsFlagVal:
+					return errors.Errorf("--fail-fast cannot be specified with --continue-on-file-errors")
+				}
+			}
+			if dryRunHeadersFlagVal && !dryRunFlagVal {
+				return errors.Errorf("--dry-run-headers can only be specified when --dry-run is used")
+			}
+			if patchFileFlagVal != "" && !dryRunFlagVal {
+				return errors.Errorf("--patch-file can only be specified when --dry-run is used")
+			}
+			projectParam, err := loadProjectParam(projectDirFlagVal, configFlagVal, godelConfigFileFlagVal, subProjectFlagVal)
 			if err != nil {
 				return err
 			}
+			var warnings io.Writer
+			if warnSkippedFilesFlagVal {
+				warnings = cmd.ErrOrStderr()
+			}
+			var timings io.Writer
+			if timingsFlagVal {
+				timings = cmd.ErrOrStderr()
+			}
+			runParam := licenseplugin.RunParam{
+				Verify:               verifyFlagVal,
+				Remove:               removeFlagVal,
+				Strict:               strictFlagVal,
+				AddOnly:              addOnlyFlagVal,
+				FixAfter:             fixAfterFlagVal,
+				DryRun:               dryRunFlagVal,
+				DryRunHeaders:        dryRunHeadersFlagVal,
+				PatchFile:            patchFileFlagVal,
+				ErrOnChange:          changedExitCodeFlagVal != 0,
+				Backup:               backupFlagVal,
+				ContinueOnFileErrors: continueOnFileErrorsFlagVal,
+				FailFast:             failFastFlagVal,
+				FailOnSkipped:        failOnSkippedFlagVal,
+				SkipInvalidGo:        skipInvalidGoFlagVal,
+				CountOnly:            countOnlyFlagVal,
+				GroupByDir:           groupByDirFlagVal,
+				Output:               outputFormat,
+				TextLayout:           textLayout,
+				Types:                typeFlagVal,
+				MaxChanges:           maxChangesFlagVal,
+				Color:                colorMode,
+				Archive:              archiveFlagVal,
+				FailuresFile:         failuresFileFlagVal,
+				NoticeFile:           noticeFileFlagVal,
+				NewFilesSince:        newFilesSinceFlagVal,
+				ModifiedSince:        modifiedSince,
+				MaxDepth:             maxDepthFlagVal,
+				ManifestClosed:       manifestClosedFlagVal,
+				CheckCommitYear:      checkCommitYearFlagVal,
+				GitParallelism:       gitParallelismFlagVal,
+				ProjectDir:           projectDirFlagVal,
+				Warnings:             warnings,
+				Timings:              timings,
+				PathBase:             pathBase,
+			}
+			if manifestFlagVal != "" {
+				if runParam.Manifest, err = licenseplugin.ReadManifest(manifestFlagVal, projectDirFlagVal); err != nil {
+					return err
+				}
+			}
 
-			// plugin matches all Go files in project except for those excluded by configuration
-			goFiles, err := godellauncher.ListProjectPaths(projectDirFlagVal, matcher.Name(`.*\.go`), projectParam.Exclude)
+			if fileFlagVal != "" {
+				switch {
+				case stdinFlagVal:
+					return errors.Errorf("--file cannot be specified with --stdin")
+				case streamFilesFlagVal:
+					return errors.Errorf("--file cannot be specified with --stream-files")
+				case len(subProjectFlagVal) > 0:
+					return errors.Errorf("--file cannot be specified with --sub-project")
+				}
+				return licenseplugin.RunLicenseFile(fileFlagVal, projectParam, runParam, cmd.OutOrStdout())
+			}
+
+			if stdinFlagVal {
+				if filenameFlagVal == "" {
+					return errors.Errorf("--filename must be specified when --stdin is used")
+				}
+				return licenseplugin.RunLicenseContent(filenameFlagVal, cmd.InOrStdin(), projectParam, runParam, cmd.OutOrStdout())
+			}
+
+			if streamFilesFlagVal {
+				if len(subProjectFlagVal) > 0 {
+					return errors.Errorf("--stream-files cannot be specified with --sub-project")
+				}
+				return licenseplugin.RunLicenseDir(projectDirFlagVal, projectParam, runParam, cmd.OutOrStdout())
+			}
+
+			// plugin matches all Go files and files of configured file types in project except for those excluded by
+			// configuration
+			files, err := godellauncher.ListProjectPaths(projectDirFlagVal, projectParam.FileMatcher(), projectParam.Exclude)
 			if err != nil {
 				return err
 			}
-			return golicense.RunLicense(goFiles, projectParam, verifyFlagVal, removeFlagVal, cmd.OutOrStdout())
+			if len(subProjectFlagVal) == 0 {
+				return licenseplugin.RunLicense(files, projectParam, runParam, cmd.OutOrStdout())
+			}
+
+			projects := []licenseplugin.Project{{Files: files, Param: projectParam}}
+			for _, subProject := range subProjectFlagVal {
+				subProjectDir := filepath.Join(projectDirFlagVal, subProject)
+				subProjectParam, err := loadProjectParam(
+					subProjectDir,
+					"",
+					filepath.Join(subProjectDir, "godel", "config", "godel.yml"),
+					nil,
+				)
+				if err != nil {
+					return errors.Wrapf(err, "failed to load configuration for sub-project %s", subProject)
+				}
+				subProjectFiles, err := godellauncher.ListProjectPaths(subProjectDir, subProjectParam.FileMatcher(), subProjectParam.Exclude)
+				if err != nil {
+					return err
+				}
+				// the files are relative to the working directory, so the parameters must match paths relative to it
+				if subProjectParam, err = subProjectParam.InDir(subProjectDir); err != nil {
+					return errors.Wrapf(err, "failed to load configuration for sub-project %s", subProject)
+				}
+				projects = append(projects, licenseplugin.Project{Files: subProjectFiles, Param: subProjectParam})
+			}
+			return licenseplugin.RunLicenseProjects(projects, runParam, cmd.OutOrStdout())
 		},
 	}
 
-	verifyFlagVal bool
-	removeFlagVal bool
+	verifyFlagVal               bool
+	verifyExitCodeFlagVal       int
+	changedExitCodeFlagVal      int
+	exitNonzeroOnChangeFlagVal  bool
+	removeFlagVal               bool
+	addOnlyFlagVal              bool
+	fixAfterFlagVal             bool
+	backupFlagVal               bool
+	continueOnFileErrorsFlagVal bool
+	failFastFlagVal             bool
+	dryRunFlagVal               bool
+	dryRunHeadersFlagVal        bool
+	patchFileFlagVal            string
+	maxChangesFlagVal           int
+	colorFlagVal                string
+	formatOutputFlagVal         string
+	archiveFlagVal              string
+	pathBaseFlagVal             string
+	stdinFlagVal                bool
+	filenameFlagVal             string
+	fileFlagVal                 string
+	newFilesSinceFlagVal        string
+	modifiedSinceFlagVal        string
+	maxDepthFlagVal             int
+	manifestFlagVal             string
+	manifestClosedFlagVal       bool
+	checkCommitYearFlagVal      bool
+	gitParallelismFlagVal       int
+	failuresFileFlagVal         string
+	noticeFileFlagVal           string
+	holderFlagVal               string
+	subProjectFlagVal           []string
+	strictFlagVal               bool
+	typeFlagVal                 []string
+	countOnlyFlagVal            bool
+	groupByDirFlagVal           bool
+	outputFlagVal               string
+	includeHiddenFlagVal        bool
+	includeThirdPartyFlagVal    bool
+	streamFilesFlagVal          bool
+	warnSkippedFilesFlagVal     bool
+	timingsFlagVal              bool
+	failOnSkippedFlagVal        bool
+	skipInvalidGoFlagVal        bool
 )
 
 func init() {
 	runCmd.Flags().BoolVar(&verifyFlagVal, "verify", false, "verify that files have proper license headers applied")
+	runCmd.Flags().IntVar(&verifyExitCodeFlagVal, "verify-exit-code", 1, "exit code returned when files fail verification (other failures return 1)")
+	runCmd.Flags().IntVar(&changedExitCodeFlagVal, "changed-exit-code", 0, "exit code returned when apply or remove modifies any files (0 means that modifying files is not reported)")
+	runCmd.Flags().BoolVar(&exitNonzeroOnChangeFlagVal, "exit-nonzero-on-change", false, fmt.Sprintf("exit with code %d when apply or remove modifies any files (equivalent to --changed-exit-code %d)", exitNonzeroOnChangeExitCode, exitNonzeroOnChangeExitCode))
 	runCmd.Flags().BoolVar(&removeFlagVal, "remove", false, "remove the license header from files (no-op if verify is true)")
+	runCmd.Flags().BoolVar(&addOnlyFlagVal, "add-only", false, "only add license headers to files that do not have one (files with a leading comment that resembles a license header are never modified)")
+	runCmd.Flags().BoolVar(&fixAfterFlagVal, "fix-after", false, "after verify reports the files that fail verification, apply the license header and list the files that were fixed (requires --verify)")
+	runCmd.Flags().BoolVar(&backupFlagVal, "backup", false, "before apply or remove modifies a file, write a copy of it with the same permissions to the path of the file with \""+licenseplugin.BackupSuffix+"\" appended")
+	runCmd.Flags().BoolVar(&continueOnFileErrorsFlagVal, "continue-on-file-errors", false, "skip files for which the operating system returns an error (for example, for paths that are too long) and continue with the other files, then fail with a summary of the errors")
+	runCmd.Flags().BoolVar(&failFastFlagVal, "fail-fast", false, "stop verify at the first file that fails verification and report only that file (requires --verify)")
+	runCmd.Flags().BoolVar(&dryRunFlagVal, "dry-run", false, "print the action that apply or remove would take for each file (add, update, remove or none) without modifying any files (use --output json or ndjson for machine-readable output)")
+	runCmd.Flags().BoolVar(&dryRunHeadersFlagVal, "dry-run-headers", false, "include the license header that each file would have in the JSON output of --dry-run")
+	runCmd.Flags().StringVar(&patchFileFlagVal, "patch-file", "", "write a unified diff of the changes that --dry-run would make to the specified file (can be applied using git apply)")
+	runCmd.Flags().IntVar(&maxChangesFlagVal, "max-changes", 0, "fail without modifying any files if more than this many files would be modified (0 means no limit)")
+	runCmd.Flags().StringVar(&formatOutputFlagVal, "format-output", string(licenseplugin.TextLayoutList), "layout of the text output of verify: list (files grouped by failure) or table (aligned path and status columns)")
+	runCmd.Flags().StringVar(&colorFlagVal, "color", string(licenseplugin.ColorAuto), "when to color verify output (auto, always or never)")
+	runCmd.Flags().StringVar(&archiveFlagVal, "archive", "", "verify the entries of the specified tar, tar.gz or zip archive instead of the project files (requires --verify)")
+	runCmd.Flags().StringVar(&pathBaseFlagVal, "path-base", string(licenseplugin.PathBaseCWD), "directory that reported paths are relative to (cwd or project)")
+	runCmd.Flags().BoolVar(&stdinFlagVal, "stdin", false, "read the content of a single file from stdin and write the result to stdout (does not require --project-dir)")
+	runCmd.Flags().StringVar(&filenameFlagVal, "filename", "", "path of the file whose content is provided on stdin (used to determine the license header)")
+	runCmd.Flags().StringVar(&fileFlagVal, "file", "", "process only the file at the specified path rather than the files of the project (the project configuration still determines its header)")
+	runCmd.Flags().StringVar(&manifestFlagVal, "manifest", "", "verify the files listed in the specified manifest (one path relative to the project directory per line) rather than the project files (requires --verify)")
+	runCmd.Flags().BoolVar(&manifestClosedFlagVal, "manifest-closed", false, "also fail verification for files of the project that have the license header but are not listed in the manifest (requires --manifest)")
+	runCmd.Flags().IntVar(&maxDepthFlagVal, "max-depth", 0, "only process files at most the specified number of levels below the project directory, where 1 is the files in the project directory itself (0 means no limit)")
+	runCmd.Flags().StringVar(&modifiedSinceFlagVal, "modified-since", "", "only process files that were modified after the specified time, which is a duration before now (such as 24h), an RFC 3339 timestamp or a date (such as 2024-01-02)")
+	runCmd.Flags().StringVar(&newFilesSinceFlagVal, "new-files-since", "", "only verify files that were added after the specified git ref (has no effect on apply and remove)")
+	runCmd.Flags().BoolVar(&checkCommitYearFlagVal, "check-commit-year", false, "fail verification for files whose header year does not end with the year of their most recent git commit (files that are not tracked are skipped)")
+	runCmd.Flags().IntVar(&gitParallelismFlagVal, "git-parallelism", 0, "maximum number of git processes to run at once (0 means the number of CPUs)")
+	runCmd.Flags().StringVar(&failuresFileFlagVal, "failures-file", "", "write the paths of the files that fail verification to the specified file, one per line")
+	runCmd.Flags().StringVar(&noticeFileFlagVal, "notice-file", "", "write the distinct copyright lines of the license headers of the processed files to the specified file, sorted and one per line")
+	runCmd.Flags().StringVar(&holderFlagVal, "holder", "", "copyright holder that replaces the copyright holders in configuration for this run")
+	runCmd.Flags().StringSliceVar(&subProjectFlagVal, "sub-project", nil, "directory of a sub-project relative to the project directory whose files are processed using the sub-project's own configuration (can be specified multiple times)")
+	runCmd.Flags().BoolVar(&strictFlagVal, "strict", false, "require the license header to be followed directly by the content of the file (apply removes blank lines that follow the header)")
+	runCmd.Flags().StringSliceVar(&typeFlagVal, "type", nil, "only process files of the specified file type (\"go\" or the name of a configured file type; can be specified multiple times)")
+	runCmd.Flags().BoolVar(&countOnlyFlagVal, "count-only", false, "print only the number of files that fail verification (requires --verify)")
+	runCmd.Flags().BoolVar(&groupByDirFlagVal, "group-by-dir", false, "group the files listed by verify by directory")
+	runCmd.Flags().StringVar(&outputFlagVal, "output", string(licenseplugin.OutputText), "format of verify and --dry-run output (text, sarif, ndjson, github, json or checkstyle; --dry-run supports text, ndjson and json)")
+	runCmd.Flags().BoolVar(&includeHiddenFlagVal, "include-hidden", false, "process hidden files and directories (those whose names start with \".\") even if configuration excludes them")
+	runCmd.Flags().BoolVar(&streamFilesFlagVal, "stream-files", false, "process files as they are found rather than after the project directory has been walked (reduces memory use for large projects)")
+	runCmd.Flags().BoolVar(&includeThirdPartyFlagVal, "include-third-party", false, "process files that have the third-party marker specified by configuration")
+	runCmd.Flags().BoolVar(&warnSkippedFilesFlagVal, "warn-skipped-files", false, "write a warning to stderr for each file that is skipped because it is binary or (for verify) larger than the skip-files-over size of configuration")
+	runCmd.Flags().BoolVar(&failOnSkippedFlagVal, "fail-on-skipped", false, "fail without modifying any files if any file is skipped because it is binary, (for verify) larger than the skip-files-over size of configuration or (with --skip-invalid-go) not valid Go")
+	runCmd.Flags().BoolVar(&timingsFlagVal, "timings", false, "write the time taken to process each file to stderr, sorted with the slowest file first (useful to find large files to skip using the skip-files-over size of configuration)")
+	runCmd.Flags().BoolVar(&skipInvalidGoFlagVal, "skip-invalid-go", false, "skip Go files that do not parse rather than applying the license header to them (skipped files are reported by --warn-skipped-files)")
 	rootCmd.AddCommand(runCmd)
 }
+
+// loadProjectParam returns the project parameters for the provided plugin configuration file and godel configuration
+// file (which may be empty). If the plugin configuration file is empty, the first of the candidates specified by
+// --config-search that exists in the provided project directory is used. The provided paths, which are relative to the
+// project directory, are excluded in addition to the excludes specified by configuration. If --include-hidden is
+// specified, the excludes of hidden files and directories are removed from the excludes specified by configuration. If
+// --include-third-party is specified, the third-party marker specified by configuration is ignored.
+func loadProjectParam(projectDir, cfgFile, godelCfgFile string, excludePaths []string) (licenseplugin.ProjectParam, error) {
+	if cfgFile == "" {
+		var err error
+		if cfgFile, err = config.FindConfig(projectDir, configSearchFlagVal); err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+	}
+	projectCfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return licenseplugin.ProjectParam{}, err
+	}
+	if err := projectCfg.ResolveExcludePaths(projectDir, cfgFile); err != nil {
+		return licenseplugin.ProjectParam{}, err
+	}
+	if godelCfgFile != "" {
+		excludes, err := godelconfig.ReadGodelConfigExcludesFromFile(godelCfgFile)
+		if err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+		projectCfg.Exclude.Add(excludes)
+	}
+	if projectCfg.CaseInsensitiveExclude == nil {
+		caseInsensitive := config.IsCaseInsensitiveFS(projectDir)
+		projectCfg.CaseInsensitiveExclude = &caseInsensitive
+	}
+	if includeHiddenFlagVal {
+		projectCfg.Exclude = config.RemoveHiddenExcludes(projectCfg.Exclude)
+	}
+	if includeThirdPartyFlagVal {
+		projectCfg.ThirdPartyMarker = ""
+	}
+	for _, excludePath := range excludePaths {
+		projectCfg.Exclude.Add(matcher.NamesPathsCfg{
+			Paths: []string{filepath.Clean(excludePath)},
+		})
+	}
+	if holderFlagVal != "" {
+		projectCfg.CopyrightHolders = []string{holderFlagVal}
+	}
+	return projectCfg.ToParamInDir(projectDir)
+}
@@ -5,7 +5,7 @@
 package cmd
 
 import (
-	"github.com/palantir/go-license/golicense/config"
+	"github.com/palantir/godel-license-plugin/licenseplugin/config"
 	"github.com/palantir/godel/v2/framework/pluginapi"
 )
 
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/palantir/go-license/golicense"
+)
+
+// headerLikeRegexp matches the text of a comment that resembles a license header.
+var headerLikeRegexp = regexp.MustCompile(`(?i)copyright|licen[cs]e|spdx`)
+
+// hasHeader returns true if the provided content has a header that the provided licenser accepts or starts with a
+// leading comment that resembles a license header: the leading block of non-blank lines of the content (after the
+// leading lines that the licenser keeps) starts with a line that does not start with a letter or digit (such as a
+// comment marker) and contains text that matches headerLikeRegexp.
+func hasHeader(content string, licenser golicense.Licenser) bool {
+	if accepts(content, licenser) {
+		return true
+	}
+	if l, ok := licenser.(*leadingLinesLicenser); ok {
+		_, content = l.split(content)
+	}
+	block := strings.ReplaceAll(trimLeadingBlankLines(content), "\r\n", "\n")
+	if blockEnd := strings.Index(block, "\n\n"); blockEnd != -1 {
+		block = block[:blockEnd]
+	}
+	first, _ := utf8.DecodeRuneInString(strings.TrimLeftFunc(block, unicode.IsSpace))
+	if first == utf8.RuneError || unicode.IsLetter(first) || unicode.IsDigit(first) {
+		return false
+	}
+	return headerLikeRegexp.MatchString(block)
+}
+
+// addOnlyChanges returns the provided changes without the changes of files that had a header before the change, so
+// only files that did not have a header are modified.
+func addOnlyChanges(changes []Change) []Change {
+	var out []Change
+	for _, change := range changes {
+		if !change.HadHeader {
+			out = append(out, change)
+		}
+	}
+	return out
+}
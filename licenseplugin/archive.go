@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
+)
+
+// Archive formats supported by VerifyArchive.
+const (
+	ArchiveFormatTar   = "tar"
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatZip   = "zip"
+)
+
+// ArchiveFormat returns the archive format for the provided file name based on its extension. Returns an error if
+// the extension does not correspond to a supported archive format.
+func ArchiveFormat(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveFormatTarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return ArchiveFormatTar, nil
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveFormatZip, nil
+	default:
+		return "", errors.Errorf("unable to determine archive format of %s: supported extensions are .tar, .tar.gz, .tgz and .zip", name)
+	}
+}
+
+// VerifyArchive verifies the license headers of the regular file entries in the archive read from the provided
+// reader without extracting it. The format must be one of ArchiveFormatTar, ArchiveFormatTarGz or ArchiveFormatZip.
+// Entries are matched using their path within the archive. Returns the sorted paths of the entries that do not have
+// the correct license header.
+func VerifyArchive(r io.Reader, format string, projectParam ProjectParam) ([]string, error) {
+	// if header and matchers do not exist, return (nothing to check)
+	if projectParam.empty() {
+		return nil, nil
+	}
+
+	var failed []string
+	visitor := func(path string, content []byte) {
+		if !VerifyContent(path, content, projectParam) {
+			failed = append(failed, path)
+		}
+	}
+
+	fileMatcher := projectParam.FileMatcher()
+	var err error
+	switch format {
+	case ArchiveFormatTar:
+		err = visitTarEntries(r, fileMatcher, visitor)
+	case ArchiveFormatTarGz:
+		var gzr *gzip.Reader
+		gzr, err = gzip.NewReader(r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create gzip reader")
+		}
+		defer func() {
+			_ = gzr.Close()
+		}()
+		err = visitTarEntries(gzr, fileMatcher, visitor)
+	case ArchiveFormatZip:
+		err = visitZipEntries(r, fileMatcher, visitor)
+	default:
+		return nil, errors.Errorf("unsupported archive format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(failed)
+	return failed, nil
+}
+
+// VerifyFS verifies the license headers of the regular files of the provided file system (for example, an in-memory
+// file system or the file system returned by os.DirFS for a directory). Files are matched using their path within the
+// file system. If projectParam.ForModule is non-nil, the module of each file is determined using the go.mod files of
+// the file system. Returns the sorted paths of the files that do not have the correct license header.
+//
+// VerifyFS is the only operation that reads files from an fs.FS: fs.FS is read-only, so applying and removing
+// licenses, and the operations that depend on the files and directories surrounding a file (such as sidecar files,
+// package doc files and commit years), are only supported for the OS file system by RunLicense and RunLicenseDir. Only
+// the headers of the files are verified in the same manner as VerifyContent: ProjectParam.Required,
+// ProjectParam.RequirePackageClause and ProjectParam.RejectPlaceholders are not applied.
+func VerifyFS(fsys fs.FS, projectParam ProjectParam) ([]string, error) {
+	// if header and matchers do not exist, return (nothing to check)
+	if projectParam.empty() {
+		return nil, nil
+	}
+
+	modules := newFSModuleResolver(fsys, projectParam)
+	var failed []string
+	if err := visitFSFiles(fsys, projectParam.FileMatcher(), func(path string, content []byte) error {
+		param := projectParam
+		if _, ok := fileLicenser(path, projectParam); ok {
+			var err error
+			if param, err = modules.param(path); err != nil {
+				return err
+			}
+		}
+		if !VerifyContent(path, content, param) {
+			failed = append(failed, path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(failed)
+	return failed, nil
+}
+
+func visitFSFiles(fsys fs.FS, fileMatcher matcher.Matcher, visitor func(path string, content []byte) error) error {
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed to walk %s", path)
+		}
+		if !d.Type().IsRegular() || !fileMatcher.Match(path) {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", path)
+		}
+		return visitor(path, content)
+	})
+}
+
+func visitTarEntries(r io.Reader, fileMatcher matcher.Matcher, visitor func(path string, content []byte)) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to read tar entry")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !fileMatcher.Match(hdr.Name) {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read tar entry %s", hdr.Name)
+		}
+		visitor(hdr.Name, content)
+	}
+}
+
+func visitZipEntries(r io.Reader, fileMatcher matcher.Matcher, visitor func(path string, content []byte)) error {
+	// zip archives must be read using random access, so buffer the entire archive in memory
+	zipBytes, err := io.ReadAll(r)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read zip archive")
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create zip reader")
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !fileMatcher.Match(f.Name) {
+			continue
+		}
+		content, err := readZipEntry(f)
+		if err != nil {
+			return err
+		}
+		visitor(f.Name, content)
+	}
+	return nil
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open zip entry %s", f.Name)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read zip entry %s", f.Name)
+	}
+	return content, nil
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/palantir/pkg/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var archiveEntries = []struct {
+	name    string
+	content string
+}{
+	{name: "project/foo.go", content: "package foo\n"},
+	{name: "project/bar/bar.go", content: testHeader + "\npackage bar\n"},
+	{name: "project/vendor/baz.go", content: "package baz\n"},
+	{name: "project/README.md", content: "readme\n"},
+}
+
+func TestVerifyArchive(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		Exclude:  matcher.Name("vendor"),
+	}
+
+	for i, tc := range []struct {
+		format  string
+		archive []byte
+	}{
+		{format: licenseplugin.ArchiveFormatTar, archive: tarArchive(t, false)},
+		{format: licenseplugin.ArchiveFormatTarGz, archive: tarArchive(t, true)},
+		{format: licenseplugin.ArchiveFormatZip, archive: zipArchive(t)},
+	} {
+		failed, err := licenseplugin.VerifyArchive(bytes.NewReader(tc.archive), tc.format, projectParam)
+		require.NoError(t, err, "Case %d: %s", i, tc.format)
+		assert.Equal(t, []string{"project/foo.go"}, failed, "Case %d: %s", i, tc.format)
+	}
+}
+
+func TestVerifyFS(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		Exclude:  matcher.Name("vendor"),
+	}
+
+	mapFS := fstest.MapFS{}
+	dir := t.TempDir()
+	for _, entry := range archiveEntries {
+		mapFS[entry.name] = &fstest.MapFile{Data: []byte(entry.content)}
+
+		path := filepath.Join(dir, filepath.FromSlash(entry.name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(entry.content), 0644))
+	}
+
+	for i, tc := range []struct {
+		name string
+		fsys fs.FS
+	}{
+		{name: "in-memory", fsys: mapFS},
+		{name: "directory", fsys: os.DirFS(dir)},
+	} {
+		failed, err := licenseplugin.VerifyFS(tc.fsys, projectParam)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, []string{"project/foo.go"}, failed, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestVerifyFSForModule(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser("// Copyright " + licenseplugin.ModulePlaceholder),
+		ForModule: func(module string) (licenseplugin.ProjectParam, error) {
+			return licenseplugin.ProjectParam{
+				Licenser: golicense.NewLicenser("// Copyright " + module),
+			}, nil
+		},
+	}
+
+	failed, err := licenseplugin.VerifyFS(fstest.MapFS{
+		"go.mod":         {Data: []byte("module example.com/root\n")},
+		"root.go":        {Data: []byte("// Copyright example.com/root\npackage root\n")},
+		"sub/go.mod":     {Data: []byte("module example.com/sub\n")},
+		"sub/sub.go":     {Data: []byte("// Copyright example.com/sub\npackage sub\n")},
+		"sub/pkg/pkg.go": {Data: []byte("// Copyright example.com/root\npackage pkg\n")},
+	}, projectParam)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sub/pkg/pkg.go"}, failed)
+
+	_, err = licenseplugin.VerifyFS(fstest.MapFS{
+		"root.go": {Data: []byte("// Copyright example.com/root\npackage root\n")},
+	}, projectParam)
+	assert.EqualError(t, err, "failed to determine module of root.go: no go.mod file in its directory or any parent directory")
+}
+
+func TestVerifyArchiveAcceptedHeader(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: licenseplugin.NewAcceptingLicenser(golicense.NewLicenser("// Copyright 2024 Other Inc."), golicense.NewLicenser(testHeader)),
+		Exclude:  matcher.Name("vendor"),
+	}
+
+	failed, err := licenseplugin.VerifyArchive(bytes.NewReader(tarArchive(t, false)), licenseplugin.ArchiveFormatTar, projectParam)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"project/foo.go"}, failed)
+}
+
+func TestVerifyArchiveUnsupportedFormat(t *testing.T) {
+	_, err := licenseplugin.VerifyArchive(&bytes.Buffer{}, "rar", licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	})
+	assert.EqualError(t, err, `unsupported archive format "rar"`)
+}
+
+func tarArchive(t *testing.T, gzipped bool) []byte {
+	buf := &bytes.Buffer{}
+	var w io.Writer = buf
+	var gzw *gzip.Writer
+	if gzipped {
+		gzw = gzip.NewWriter(buf)
+		w = gzw
+	}
+	tw := tar.NewWriter(w)
+	for _, entry := range archiveEntries {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+			Typeflag: tar.TypeReg,
+		})
+		require.NoError(t, err)
+		_, err = tw.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	if gzw != nil {
+		require.NoError(t, gzw.Close())
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, entry := range archiveEntries {
+		w, err := zw.Create(entry.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// stagedFile is the new content of a file that has been written to a temporary file in the directory of the file. The
+// file is not modified until the staged file is committed, which renames the temporary file to the path of the file so
+// that readers (and an interrupted operation) never observe a partially written file.
+type stagedFile struct {
+	path    string
+	tmpPath string
+}
+
+// stageFile writes the provided content to a temporary file with the provided permissions in the directory of the file
+// at the provided path. If the path is a symbolic link, the file that it refers to is staged so that committing the
+// staged file does not replace the link.
+func stageFile(path string, content []byte, mode os.FileMode) (rStaged stagedFile, rErr error) {
+	if target, err := filepath.EvalSymlinks(longPath(path)); err == nil {
+		path = target
+	}
+	tmpFile, err := os.CreateTemp(longPath(filepath.Dir(path)), stagedFilePrefix(path))
+	if err != nil {
+		return stagedFile{}, errors.Wrapf(err, "failed to create temporary file")
+	}
+	defer func() {
+		if rErr != nil {
+			_ = os.Remove(tmpFile.Name())
+		}
+	}()
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return stagedFile{}, errors.WithStack(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return stagedFile{}, errors.WithStack(err)
+	}
+	// temporary files are created with permissions of 0600
+	if err := os.Chmod(tmpFile.Name(), mode.Perm()); err != nil {
+		return stagedFile{}, errors.Wrapf(err, "failed to set permissions")
+	}
+	return stagedFile{
+		path:    path,
+		tmpPath: tmpFile.Name(),
+	}, nil
+}
+
+// stagedFilePrefix returns the prefix of the names of the temporary files of the file at the provided path.
+func stagedFilePrefix(path string) string {
+	return "." + filepath.Base(path) + ".tmp-"
+}
+
+// removeStaleStagedFiles removes the temporary files of the files at the provided paths (see stagedFilePrefix) that
+// were left behind by an operation that was interrupted before it committed them. The directory of the files is read
+// once for all of the files in it. Errors are ignored because the temporary files do not affect the files.
+func removeStaleStagedFiles(paths []string) {
+	prefixesByDir := make(map[string]map[string]struct{})
+	for _, path := range paths {
+		if target, err := filepath.EvalSymlinks(longPath(path)); err == nil {
+			path = target
+		}
+		dir := filepath.Dir(path)
+		if prefixesByDir[dir] == nil {
+			prefixesByDir[dir] = make(map[string]struct{})
+		}
+		prefixesByDir[dir][stagedFilePrefix(path)] = struct{}{}
+	}
+	for dir, prefixes := range prefixesByDir {
+		entries, err := os.ReadDir(longPath(dir))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			idx := strings.LastIndex(entry.Name(), ".tmp-")
+			if idx == -1 {
+				continue
+			}
+			if _, ok := prefixes[entry.Name()[:idx+len(".tmp-")]]; ok {
+				_ = os.Remove(filepath.Join(longPath(dir), entry.Name()))
+			}
+		}
+	}
+}
+
+// commit replaces the file with the staged content.
+func (f stagedFile) commit() error {
+	if err := os.Rename(f.tmpPath, longPath(f.path)); err != nil {
+		f.discard()
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// discard removes the staged content without modifying the file.
+func (f stagedFile) discard() {
+	_ = os.Remove(f.tmpPath)
+}
+
+// writeFileAtomic writes the provided content to the file at the provided path with the provided permissions by
+// staging and committing it, so the file is either left unmodified or has the complete content. Temporary files of
+// the file that were left behind by an interrupted operation are removed first.
+func writeFileAtomic(path string, content []byte, mode os.FileMode) error {
+	removeStaleStagedFiles([]string{path})
+	staged, err := stageFile(path, content, mode)
+	if err != nil {
+		return err
+	}
+	return staged.commit()
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin/commentstyle"
+	"github.com/pkg/errors"
+)
+
+// Capabilities describes the features supported by a version of the plugin so that tools that wrap the plugin can
+// determine which features are available.
+type Capabilities struct {
+	// Version is the version of the plugin.
+	Version string `json:"version"`
+	// FileTypes are the names of the file types that have license headers without being configured.
+	FileTypes []string `json:"fileTypes"`
+	// CommentStyles are the names of the comment styles in which headers can be rendered for configured file types.
+	CommentStyles []string `json:"commentStyles"`
+	// TemplateTokens are the placeholders that are replaced in configured headers.
+	TemplateTokens []string `json:"templateTokens"`
+	// OutputFormats are the formats in which the results of verify can be written.
+	OutputFormats []OutputFormat `json:"outputFormats"`
+}
+
+// NewCapabilities returns the Capabilities of this plugin, which has the provided version.
+func NewCapabilities(version string) Capabilities {
+	return Capabilities{
+		Version:        version,
+		FileTypes:      []string{GoFileType},
+		CommentStyles:  commentstyle.Names(),
+		TemplateTokens: []string{"{{YEAR}}", HolderPlaceholder, LicenseTextPlaceholder, ModulePlaceholder, CommitPlaceholder, "${VAR}"},
+		OutputFormats:  []OutputFormat{OutputText, OutputSARIF, OutputNDJSON, OutputGitHub, OutputJSON, OutputCheckstyle},
+	}
+}
+
+// WriteCapabilities writes the provided Capabilities to the provided writer as an indented JSON object.
+func WriteCapabilities(capabilities Capabilities, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(capabilities); err != nil {
+		return errors.Wrapf(err, "failed to write capabilities")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/pkg/errors"
+)
+
+// ColorMode specifies whether or not output is colored using ANSI escape sequences.
+type ColorMode string
+
+const (
+	// ColorAuto colors output only if it is written to a terminal.
+	ColorAuto ColorMode = "auto"
+	// ColorAlways always colors output.
+	ColorAlways ColorMode = "always"
+	// ColorNever never colors output.
+	ColorNever ColorMode = "never"
+)
+
+const (
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiReset = "\x1b[0m"
+)
+
+// ParseColorMode returns the ColorMode for the provided string. Returns an error if the string is not a valid mode.
+func ParseColorMode(mode string) (ColorMode, error) {
+	switch colorMode := ColorMode(mode); colorMode {
+	case ColorAuto, ColorAlways, ColorNever:
+		return colorMode, nil
+	default:
+		return "", errors.Errorf("invalid color mode %q: must be one of %q, %q or %q", mode, ColorAuto, ColorAlways, ColorNever)
+	}
+}
+
+// Enabled returns true if output written to the provided writer should be colored. The empty ColorMode is treated
+// as ColorAuto.
+func (m ColorMode) Enabled(w io.Writer) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	default:
+		f, ok := w.(*os.File)
+		return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+	}
+}
+
+// colorizer wraps text in ANSI escape sequences if it is enabled.
+type colorizer bool
+
+func (c colorizer) bold(s string) string {
+	return c.wrap(ansiBold, s)
+}
+
+func (c colorizer) red(s string) string {
+	return c.wrap(ansiRed, s)
+}
+
+func (c colorizer) wrap(code, s string) string {
+	if !c {
+		return s
+	}
+	return code + s + ansiReset
+}
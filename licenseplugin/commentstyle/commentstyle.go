@@ -0,0 +1,409 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package commentstyle contains a registry of the comment styles that license headers can be rendered in.
+package commentstyle
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CommentStyle renders text as a comment in the syntax of a particular language.
+type CommentStyle interface {
+	// Comment returns the provided text rendered as a comment. Neither the text nor the returned comment has a
+	// trailing newline.
+	Comment(text string) string
+
+	// Uncomment returns the text of the provided comment, which does not have a trailing newline. Returns false if the
+	// comment is not a comment in this style.
+	Uncomment(comment string) (string, bool)
+}
+
+var styles = map[string]CommentStyle{}
+
+// fileStyles maps the file names and extensions for which comment styles are registered to the names of the styles.
+var fileStyles = map[string]string{}
+
+// Register registers the provided comment style under the provided name. Panics if a comment style is already
+// registered under the name.
+func Register(name string, style CommentStyle) {
+	if _, ok := styles[name]; ok {
+		panic(errors.Errorf("comment style %q is already registered", name))
+	}
+	styles[name] = style
+}
+
+// Lookup returns the comment style registered under the provided name. Returns an error if no comment style is
+// registered under the name.
+func Lookup(name string) (CommentStyle, error) {
+	style, ok := styles[name]
+	if !ok {
+		return nil, errors.Errorf("unknown comment style %q: must be one of %v", name, Names())
+	}
+	return style, nil
+}
+
+// RegisterFile registers the comment style with the provided name as the comment style of files whose name is the
+// provided pattern or, if the pattern starts with ".", whose name ends with the pattern (for example, "Dockerfile" or
+// ".yaml"). Panics if no comment style is registered under the name or if a comment style is already registered for
+// the pattern.
+func RegisterFile(pattern, name string) {
+	if _, ok := styles[name]; !ok {
+		panic(errors.Errorf("comment style %q is not registered", name))
+	}
+	if _, ok := fileStyles[pattern]; ok {
+		panic(errors.Errorf("comment style is already registered for files %q", pattern))
+	}
+	fileStyles[pattern] = name
+}
+
+// ForFile returns the name of the comment style registered for files with the provided name (see RegisterFile). A
+// comment style registered for the exact name takes precedence over those registered for extensions, and a comment
+// style registered for a longer extension takes precedence over one registered for a shorter extension (so ".go.tmpl"
+// takes precedence over ".tmpl"). Returns false if no comment style is registered for files with the name.
+func ForFile(name string) (string, bool) {
+	if style, ok := fileStyles[name]; ok {
+		return style, true
+	}
+	style, longest := "", 0
+	for pattern, styleName := range fileStyles {
+		if strings.HasPrefix(pattern, ".") && strings.HasSuffix(name, pattern) && len(pattern) > longest {
+			style, longest = styleName, len(pattern)
+		}
+	}
+	return style, longest > 0
+}
+
+// Names returns the sorted names of the registered comment styles.
+func Names() []string {
+	var names []string
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Uncomment returns the text of the provided comment using the first registered comment style (in the order of
+// Names) that the comment is in. Returns false if the comment is not in any registered style.
+func Uncomment(comment string) (string, bool) {
+	for _, name := range Names() {
+		if text, ok := styles[name].Uncomment(comment); ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// Reflow returns the provided comment with its text reflowed so that no line of the text is longer than the provided
+// width and rendered in the first registered comment style (in the order of Names) that the comment is in. Every
+// paragraph of the text (lines separated by empty lines) is reflowed separately, so empty lines are preserved and
+// paragraphs are never merged. Lines that start with whitespace and copyright lines (lines that start with "Copyright")
+// are preserved as they are and are not merged with other lines. Words longer than the width are placed on their own
+// line. Returns false if the comment is not in any registered style.
+func Reflow(comment string, width int) (string, bool) {
+	for _, name := range Names() {
+		if text, ok := styles[name].Uncomment(comment); ok {
+			return styles[name].Comment(reflow(text, width)), true
+		}
+	}
+	return "", false
+}
+
+// reflow returns the provided text with the lines of each paragraph joined and split into lines that are no longer
+// than the provided width.
+func reflow(text string, width int) string {
+	var out, words []string
+	flush := func() {
+		line := ""
+		for _, word := range words {
+			switch {
+			case line == "":
+				line = word
+			case len(line)+1+len(word) <= width:
+				line += " " + word
+			default:
+				out = append(out, line)
+				line = word
+			}
+		}
+		if line != "" {
+			out = append(out, line)
+		}
+		words = nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" || line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(line, "Copyright") {
+			flush()
+			out = append(out, line)
+			continue
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// Redelimit returns the variants of the provided content in which its leading comment is rendered with the delimiters
+// of the provided comment: for every registered Delimited style that has the same line prefix as the style of the
+// comment but different delimiters (for example, "/**" rather than "/*") and that the content starts with a comment
+// in, the content with that comment rendered in the style of the provided comment. Returns nil if the provided comment
+// is not in a registered Delimited style.
+func Redelimit(content, comment string) []string {
+	var style Delimited
+	found := false
+	for _, name := range Names() {
+		if s, ok := styles[name].(Delimited); ok {
+			if _, ok := s.Uncomment(comment); ok {
+				style, found = s, true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+	var variants []string
+	for _, name := range Names() {
+		s, ok := styles[name].(Delimited)
+		if !ok || s.LinePrefix != style.LinePrefix || s == style {
+			continue
+		}
+		leading, rest, ok := s.leading(content)
+		if !ok {
+			continue
+		}
+		if text, ok := s.Uncomment(leading); ok {
+			variants = append(variants, style.Comment(text)+rest)
+		}
+	}
+	return variants
+}
+
+// Delimited is a CommentStyle that prefixes every line of the text and optionally surrounds the text with an opening
+// and a closing line. Trailing whitespace is removed from the lines of the comment, so empty lines of the text are
+// rendered as the prefix without trailing whitespace.
+type Delimited struct {
+	// Start is the line that precedes the text. If empty, no line precedes the text.
+	Start string
+
+	// LinePrefix is the prefix of every line of the text.
+	LinePrefix string
+
+	// End is the line that follows the text. If empty, no line follows the text.
+	End string
+}
+
+func (s Delimited) Comment(text string) string {
+	var lines []string
+	if s.Start != "" {
+		lines = append(lines, s.Start)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, strings.TrimRight(s.LinePrefix+line, " \t"))
+	}
+	if s.End != "" {
+		lines = append(lines, s.End)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s Delimited) Uncomment(comment string) (string, bool) {
+	lines := strings.Split(comment, "\n")
+	if s.Start != "" {
+		if len(lines) == 0 || lines[0] != s.Start {
+			return "", false
+		}
+		lines = lines[1:]
+	}
+	if s.End != "" {
+		if len(lines) == 0 || lines[len(lines)-1] != s.End {
+			return "", false
+		}
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+	trimmedPrefix := strings.TrimRight(s.LinePrefix, " \t")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, s.LinePrefix):
+			lines[i] = line[len(s.LinePrefix):]
+		case line == trimmedPrefix:
+			lines[i] = ""
+		default:
+			return "", false
+		}
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// leading splits the provided content into the comment in this style that it starts with (without its trailing newline)
+// and the content that follows the comment. Returns false if the content does not start with a comment in this style.
+func (s Delimited) leading(content string) (string, string, bool) {
+	trimmedPrefix := strings.TrimRight(s.LinePrefix, " \t")
+	end := 0
+	for i := 0; end < len(content); i++ {
+		lineEnd := strings.IndexByte(content[end:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(content) - end
+		}
+		line := content[end : end+lineEnd]
+		switch {
+		case i == 0 && s.Start != "":
+			if line != s.Start {
+				return "", "", false
+			}
+		case s.End != "" && line == s.End:
+			return content[:end+lineEnd], content[end+lineEnd:], true
+		case strings.HasPrefix(line, s.LinePrefix) || line == trimmedPrefix:
+		default:
+			if s.End != "" || end == 0 {
+				return "", "", false
+			}
+			return content[:end-1], content[end-1:], true
+		}
+		end += lineEnd + 1
+	}
+	if s.End != "" || end == 0 {
+		return "", "", false
+	}
+	return strings.TrimSuffix(content, "\n"), content[len(strings.TrimSuffix(content, "\n")):], true
+}
+
+// Lines is a CommentStyle that renders the first line, the middle lines and the last line of the text with their own
+// prefixes and suffixes (for example, "//! " for the first line and "// " for the other lines) and optionally surrounds
+// the text with an opening and a closing line. Text that has a single line is rendered with the prefix of the first
+// line and the suffix of the last line. Trailing whitespace is removed from the lines of the comment.
+type Lines struct {
+	// Start is the line that precedes the text. If empty, no line precedes the text.
+	Start string
+
+	// First is the prefix and suffix of the first line of the text.
+	First Affixes
+
+	// Middle is the prefix and suffix of the lines of the text other than the first and the last line.
+	Middle Affixes
+
+	// Last is the prefix and suffix of the last line of the text.
+	Last Affixes
+
+	// End is the line that follows the text. If empty, no line follows the text.
+	End string
+}
+
+// Affixes are the prefix and the suffix of a line of a comment.
+type Affixes struct {
+	Prefix string
+	Suffix string
+}
+
+// affixes returns the affixes of the line with the provided index of text with the provided number of lines.
+func (s Lines) affixes(i, n int) Affixes {
+	affixes := s.Middle
+	if i == 0 {
+		affixes.Prefix, affixes.Suffix = s.First.Prefix, s.First.Suffix
+	}
+	if i == n-1 {
+		affixes.Suffix = s.Last.Suffix
+		if i != 0 {
+			affixes.Prefix = s.Last.Prefix
+		}
+	}
+	return affixes
+}
+
+func (s Lines) Comment(text string) string {
+	textLines := strings.Split(text, "\n")
+	var lines []string
+	if s.Start != "" {
+		lines = append(lines, s.Start)
+	}
+	for i, line := range textLines {
+		affixes := s.affixes(i, len(textLines))
+		lines = append(lines, strings.TrimRight(affixes.Prefix+line+affixes.Suffix, " \t"))
+	}
+	if s.End != "" {
+		lines = append(lines, s.End)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s Lines) Uncomment(comment string) (string, bool) {
+	lines := strings.Split(comment, "\n")
+	if s.Start != "" {
+		if len(lines) == 0 || lines[0] != s.Start {
+			return "", false
+		}
+		lines = lines[1:]
+	}
+	if s.End != "" {
+		if len(lines) == 0 || lines[len(lines)-1] != s.End {
+			return "", false
+		}
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+	for i, line := range lines {
+		text, ok := s.affixes(i, len(lines)).strip(line)
+		if !ok {
+			return "", false
+		}
+		lines[i] = text
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// strip returns the text of the provided line of a comment, which is the line without the affixes. Returns false if the
+// line does not have the affixes.
+func (a Affixes) strip(line string) (string, bool) {
+	if suffix := strings.TrimRight(a.Suffix, " \t"); suffix != "" {
+		if !strings.HasSuffix(line, suffix) {
+			return "", false
+		}
+		line = line[:len(line)-len(suffix)]
+	}
+	switch {
+	case strings.HasPrefix(line, a.Prefix):
+		return line[len(a.Prefix):], true
+	case line == strings.TrimRight(a.Prefix, " \t"):
+		return "", true
+	default:
+		return "", false
+	}
+}
+
+func init() {
+	for name, style := range map[string]CommentStyle{
+		"slash":     Delimited{LinePrefix: "// "},
+		"hash":      Delimited{LinePrefix: "# "},
+		"dash":      Delimited{LinePrefix: "-- "},
+		"semicolon": Delimited{LinePrefix: "; "},
+		"block":     Delimited{Start: "/*", LinePrefix: " * ", End: " */"},
+		"javadoc":   Delimited{Start: "/**", LinePrefix: " * ", End: " */"},
+		"xml":       Delimited{Start: "<!--", End: "-->"},
+		"gotmpl":    Delimited{Start: "{{/*", End: "*/}}"},
+	} {
+		Register(name, style)
+	}
+	for name, patterns := range map[string][]string{
+		"slash":     {".c", ".cc", ".cpp", ".h", ".java", ".js", ".kt", ".proto", ".rs", ".scala", ".swift", ".ts"},
+		"hash":      {"Dockerfile", "Makefile", ".bash", ".properties", ".py", ".rb", ".sh", ".toml", ".yaml", ".yml"},
+		"dash":      {".lua", ".sql"},
+		"semicolon": {".clj", ".el", ".lisp"},
+		"block":     {".css"},
+		"xml":       {".html", ".xml"},
+		"gotmpl":    {".go.tmpl", ".gotmpl"},
+	} {
+		for _, pattern := range patterns {
+			RegisterFile(pattern, name)
+		}
+	}
+}
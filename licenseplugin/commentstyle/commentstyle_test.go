@@ -0,0 +1,246 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package commentstyle_test
+
+import (
+	"testing"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin/commentstyle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentStyles(t *testing.T) {
+	const text = "Copyright {{YEAR}} Acme Inc\n\nAll rights reserved."
+
+	for i, tc := range []struct {
+		name string
+		want string
+	}{
+		{name: "slash", want: "// Copyright {{YEAR}} Acme Inc\n//\n// All rights reserved."},
+		{name: "hash", want: "# Copyright {{YEAR}} Acme Inc\n#\n# All rights reserved."},
+		{name: "dash", want: "-- Copyright {{YEAR}} Acme Inc\n--\n-- All rights reserved."},
+		{name: "semicolon", want: "; Copyright {{YEAR}} Acme Inc\n;\n; All rights reserved."},
+		{name: "block", want: "/*\n * Copyright {{YEAR}} Acme Inc\n *\n * All rights reserved.\n */"},
+		{name: "javadoc", want: "/**\n * Copyright {{YEAR}} Acme Inc\n *\n * All rights reserved.\n */"},
+		{name: "xml", want: "<!--\nCopyright {{YEAR}} Acme Inc\n\nAll rights reserved.\n-->"},
+		{name: "gotmpl", want: "{{/*\nCopyright {{YEAR}} Acme Inc\n\nAll rights reserved.\n*/}}"},
+	} {
+		style, err := commentstyle.Lookup(tc.name)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		comment := style.Comment(text)
+		assert.Equal(t, tc.want, comment, "Case %d: %s", i, tc.name)
+
+		got, ok := style.Uncomment(comment)
+		assert.True(t, ok, "Case %d: %s", i, tc.name)
+		assert.Equal(t, text, got, "Case %d: %s", i, tc.name)
+
+		got, ok = commentstyle.Uncomment(comment)
+		assert.True(t, ok, "Case %d: %s", i, tc.name)
+		assert.Equal(t, text, got, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestLines(t *testing.T) {
+	for i, tc := range []struct {
+		name  string
+		style commentstyle.Lines
+		text  string
+		want  string
+	}{
+		{
+			name:  "first line with a different prefix",
+			style: commentstyle.Lines{First: commentstyle.Affixes{Prefix: "//! "}, Middle: commentstyle.Affixes{Prefix: "// "}, Last: commentstyle.Affixes{Prefix: "// "}},
+			text:  "Copyright Acme Inc\n\nAll rights reserved.",
+			want:  "//! Copyright Acme Inc\n//\n// All rights reserved.",
+		},
+		{
+			name: "block comment that opens on the first line and closes on the last line",
+			style: commentstyle.Lines{
+				First:  commentstyle.Affixes{Prefix: "/* "},
+				Middle: commentstyle.Affixes{Prefix: " * "},
+				Last:   commentstyle.Affixes{Prefix: " * ", Suffix: " */"},
+			},
+			text: "Copyright Acme Inc\n\nAll rights reserved.",
+			want: "/* Copyright Acme Inc\n *\n * All rights reserved. */",
+		},
+		{
+			name: "single line uses the prefix of the first line and the suffix of the last line",
+			style: commentstyle.Lines{
+				First:  commentstyle.Affixes{Prefix: "/* "},
+				Middle: commentstyle.Affixes{Prefix: " * "},
+				Last:   commentstyle.Affixes{Prefix: " * ", Suffix: " */"},
+			},
+			text: "Copyright Acme Inc",
+			want: "/* Copyright Acme Inc */",
+		},
+		{
+			name: "opening and closing lines",
+			style: commentstyle.Lines{
+				Start:  "/*",
+				First:  commentstyle.Affixes{Prefix: " * "},
+				Middle: commentstyle.Affixes{Prefix: " * "},
+				Last:   commentstyle.Affixes{Prefix: " * "},
+				End:    " */",
+			},
+			text: "Copyright Acme Inc\nAll rights reserved.",
+			want: "/*\n * Copyright Acme Inc\n * All rights reserved.\n */",
+		},
+		{
+			name:  "suffix of every line",
+			style: commentstyle.Lines{First: commentstyle.Affixes{Prefix: "(* ", Suffix: " *)"}, Middle: commentstyle.Affixes{Prefix: "(* ", Suffix: " *)"}, Last: commentstyle.Affixes{Prefix: "(* ", Suffix: " *)"}},
+			text:  "Copyright Acme Inc\n\nAll rights reserved.",
+			want:  "(* Copyright Acme Inc *)\n(*  *)\n(* All rights reserved. *)",
+		},
+	} {
+		comment := tc.style.Comment(tc.text)
+		assert.Equal(t, tc.want, comment, "Case %d: %s", i, tc.name)
+
+		got, ok := tc.style.Uncomment(comment)
+		assert.True(t, ok, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.text, got, "Case %d: %s", i, tc.name)
+	}
+
+	// the first line must have the prefix of the first line
+	style := commentstyle.Lines{First: commentstyle.Affixes{Prefix: "//! "}, Middle: commentstyle.Affixes{Prefix: "// "}, Last: commentstyle.Affixes{Prefix: "// "}}
+	_, ok := style.Uncomment("// Copyright Acme Inc\n// All rights reserved.")
+	assert.False(t, ok)
+}
+
+func TestUncommentNotComment(t *testing.T) {
+	style, err := commentstyle.Lookup("slash")
+	require.NoError(t, err)
+	_, ok := style.Uncomment("// Copyright\n# Acme Inc")
+	assert.False(t, ok)
+
+	_, ok = commentstyle.Uncomment("Copyright Acme Inc")
+	assert.False(t, ok)
+}
+
+func TestRedelimit(t *testing.T) {
+	const comment = "/*\n * Copyright Acme Inc\n */"
+
+	for i, tc := range []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "different delimiters",
+			content: "/**\n * Copyright Foo\n */\npackage foo\n",
+			want:    []string{"/*\n * Copyright Foo\n */\npackage foo\n"},
+		},
+		{
+			name:    "same delimiters",
+			content: "/*\n * Copyright Foo\n */\npackage foo\n",
+		},
+		{
+			name:    "different line prefix",
+			content: "// Copyright Foo\npackage foo\n",
+		},
+		{
+			name:    "unterminated comment",
+			content: "/**\n * Copyright Foo\n",
+		},
+	} {
+		assert.Equal(t, tc.want, commentstyle.Redelimit(tc.content, comment), "Case %d: %s", i, tc.name)
+	}
+
+	assert.Nil(t, commentstyle.Redelimit("/**\n * Copyright Foo\n */\n", "Copyright Acme Inc"))
+}
+
+func TestReflow(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		comment string
+		width   int
+		want    string
+	}{
+		{
+			name:    "long line is split",
+			comment: "// This file is licensed under the Apache License, Version 2.0.",
+			width:   30,
+			want:    "// This file is licensed under\n// the Apache License, Version\n// 2.0.",
+		},
+		{
+			name:    "short lines are joined",
+			comment: "# Licensed under\n# the Apache License,\n# Version 2.0.",
+			width:   80,
+			want:    "# Licensed under the Apache License, Version 2.0.",
+		},
+		{
+			name:    "paragraphs are not merged",
+			comment: "/*\n * Copyright Acme Inc\n *\n * All rights\n * reserved.\n */",
+			width:   80,
+			want:    "/*\n * Copyright Acme Inc\n *\n * All rights reserved.\n */",
+		},
+		{
+			name:    "indented lines are preserved",
+			comment: "// You may obtain a copy\n// of the License at\n//\n//     http://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required",
+			width:   20,
+			want:    "// You may obtain a\n// copy of the License\n// at\n//\n//     http://www.apache.org/licenses/LICENSE-2.0\n//\n// Unless required",
+		},
+		{
+			name:    "copyright lines are preserved",
+			comment: "// Copyright 2024 Acme Inc\n// Copyright 2024 Example Corp\n// Use of this source code\n// is governed by a license.",
+			width:   80,
+			want:    "// Copyright 2024 Acme Inc\n// Copyright 2024 Example Corp\n// Use of this source code is governed by a license.",
+		},
+		{
+			name:    "long word is on its own line",
+			comment: "// See https://www.apache.org/licenses/LICENSE-2.0 for details",
+			width:   10,
+			want:    "// See\n// https://www.apache.org/licenses/LICENSE-2.0\n// for\n// details",
+		},
+	} {
+		got, ok := commentstyle.Reflow(tc.comment, tc.width)
+		assert.True(t, ok, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, got, "Case %d: %s", i, tc.name)
+	}
+
+	_, ok := commentstyle.Reflow("Copyright Acme Inc", 80)
+	assert.False(t, ok)
+}
+
+func TestForFile(t *testing.T) {
+	for i, tc := range []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"Dockerfile", "hash", true},
+		{"config.yaml", "hash", true},
+		{".yml", "hash", true},
+		{"main.c", "slash", true},
+		{"page.go.tmpl", "gotmpl", true},
+		{"Dockerfile.dev", "", false},
+		{"README", "", false},
+	} {
+		got, ok := commentstyle.ForFile(tc.name)
+		assert.Equal(t, tc.wantOK, ok, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, got, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestRegisterFileInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		commentstyle.RegisterFile("Jenkinsfile", "unknown")
+	})
+	assert.Panics(t, func() {
+		commentstyle.RegisterFile("Dockerfile", "slash")
+	})
+}
+
+func TestLookupUnknown(t *testing.T) {
+	_, err := commentstyle.Lookup("unknown")
+	assert.EqualError(t, err, `unknown comment style "unknown": must be one of [block dash gotmpl hash javadoc semicolon slash xml]`)
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		commentstyle.Register("slash", commentstyle.Delimited{LinePrefix: "// "})
+	})
+}
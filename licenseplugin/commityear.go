@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"sort"
+
+	"github.com/palantir/go-license/golicense"
+)
+
+// commitYearChanges returns a change for each of the provided files that has the correct license header but whose
+// header has a year that does not end with the year in which the file was last modified according to the git repository
+// that contains the provided project directory (see commitYears), which is run using the provided runner. Files that
+// are not tracked by the repository are skipped. The returned changes do not modify the files and are sorted by path.
+func commitYearChanges(git *gitRunner, files []string, projectParam ProjectParam, projectDir string) ([]Change, error) {
+	years, err := commitYears(git, files, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	yearFiles := make(map[string][]string)
+	for _, file := range files {
+		if year, ok := years[file]; ok {
+			yearFiles[year] = append(yearFiles[year], file)
+		}
+	}
+
+	var changes []Change
+	for year, files := range yearFiles {
+		yearChanges, err := processFiles(files, projectParam, commitYearVisitor(year))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, yearChanges...)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// commitYearVisitor returns a visitor that considers content that has the license header to be changed if any year of
+// the header does not end with the provided year. The content itself is never modified.
+func commitYearVisitor(year string) func(content string, licenser golicense.Licenser) (string, bool) {
+	return func(content string, licenser golicense.Licenser) (string, bool) {
+		if skipContent(content) || licenser.Empty() || !licenser.Matches(content) {
+			return content, false
+		}
+		for _, endYear := range headerEndYears(content, licenser) {
+			if endYear != year {
+				return content, true
+			}
+		}
+		return content, false
+	}
+}
+
+// unchangedFiles returns the provided files that are not the path of any of the provided changes. The order of the
+// files is preserved.
+func unchangedFiles(files []string, changes []Change) []string {
+	changed := make(map[string]struct{}, len(changes))
+	for _, change := range changes {
+		changed[change.Path] = struct{}{}
+	}
+	var out []string
+	for _, file := range files {
+		if _, ok := changed[file]; !ok {
+			out = append(out, file)
+		}
+	}
+	return out
+}
@@ -0,0 +1,1201 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/palantir/godel-license-plugin/licenseplugin/commentstyle"
+	v0 "github.com/palantir/godel-license-plugin/licenseplugin/config/internal/v0"
+	"github.com/palantir/godel-license-plugin/licenseplugin/spdx"
+	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const yearPlaceholder = "{{YEAR}}"
+
+// autoCommentStyle is the comment style of file types whose comment style is determined by their file names and
+// extensions (see commentstyle.ForFile).
+const autoCommentStyle = "auto"
+
+type ProjectConfig v0.ProjectConfig
+
+// DefaultSearchPaths are the paths (relative to the project directory) of the candidate configuration files that are
+// searched in order by FindConfig when no configuration file is specified.
+var DefaultSearchPaths = []string{
+	"godel/config/license-plugin.yml",
+	"godel/config/license-plugin.yaml",
+	".license-plugin.yml",
+	".license-plugin.yaml",
+}
+
+// FindConfig returns the path of the first of the provided candidate configuration files that exists. Relative
+// candidate paths are resolved against the provided project directory. Returns an empty path if none of the candidates
+// exist, for which LoadConfig returns empty configuration.
+func FindConfig(projectDir string, candidates []string) (string, error) {
+	for _, candidate := range candidates {
+		path := candidate
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+		fi, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to stat %s", path)
+		}
+		if !fi.IsDir() {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// LoadConfig reads, upgrades and unmarshals the configuration in the provided file. Returns empty configuration if
+// the path is empty or the file does not exist.
+func LoadConfig(cfgFile string) (ProjectConfig, error) {
+	if cfgFile == "" {
+		return ProjectConfig{}, nil
+	}
+	cfgYML, err := os.ReadFile(cfgFile)
+	if os.IsNotExist(err) {
+		return ProjectConfig{}, nil
+	}
+	if err != nil {
+		return ProjectConfig{}, errors.Wrapf(err, "failed to read file %s", cfgFile)
+	}
+
+	upgradedBytes, err := UpgradeConfig(cfgYML)
+	if err != nil {
+		return ProjectConfig{}, errors.Wrapf(err, "failed to read file %s", cfgFile)
+	}
+
+	var cfg ProjectConfig
+	if err := yaml.Unmarshal(upgradedBytes, &cfg); err != nil {
+		return ProjectConfig{}, errors.Wrapf(err, "failed to unmarshal configuration as YAML")
+	}
+	return cfg, nil
+}
+
+// ToParam returns the project parameters for the configuration of the project in the working directory (see
+// ToParamInDir).
+func (cfg *ProjectConfig) ToParam() (licenseplugin.ProjectParam, error) {
+	return cfg.ToParamInDir(".")
+}
+
+// ToParamInDir returns the project parameters for the configuration of the project in the provided directory. If any
+// header contains the commit placeholder, the commit of HEAD of the git repository that contains the project directory
+// is determined once and used for all of the headers (including those of the parameters for modules).
+func (cfg *ProjectConfig) ToParamInDir(projectDir string) (licenseplugin.ProjectParam, error) {
+	if cfg.HeaderTemplate {
+		executed, err := cfg.executeHeaderTemplates()
+		if err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+		cfg = &executed
+	}
+	var commit string
+	if cfg.headersContain(licenseplugin.CommitPlaceholder) {
+		var err error
+		if commit, err = licenseplugin.HeadCommit(projectDir); err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to determine commit for the %s placeholder", licenseplugin.CommitPlaceholder)
+		}
+	}
+	return cfg.toParam(commit)
+}
+
+// toParam returns the project parameters for the configuration in which the commit placeholder is replaced with the
+// provided commit when headers are added.
+func (cfg *ProjectConfig) toParam(commit string) (licenseplugin.ProjectParam, error) {
+	var licenseText string
+	if cfg.FullLicense != "" {
+		license, err := spdx.Lookup(cfg.FullLicense)
+		if err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "invalid full-license")
+		}
+		if license.Text == "" {
+			return licenseplugin.ProjectParam{}, errors.Errorf("full text of license %s is not available", license.ID)
+		}
+		if !strings.Contains(cfg.Header, licenseplugin.LicenseTextPlaceholder) {
+			return licenseplugin.ProjectParam{}, errors.Errorf("header must contain the %s placeholder when full-license is specified", licenseplugin.LicenseTextPlaceholder)
+		}
+		licenseText = license.Text
+	}
+	if cfg.YearFormat != "" && strings.Count(cfg.YearFormat, yearPlaceholder) != 1 {
+		return licenseplugin.ProjectParam{}, errors.Errorf("year-format must contain %s exactly once: %q", yearPlaceholder, cfg.YearFormat)
+	}
+
+	if cfg.WrapWidth < 0 {
+		return licenseplugin.ProjectParam{}, errors.Errorf("wrap-width cannot be negative: %d", cfg.WrapWidth)
+	}
+
+	if cfg.CopyrightHoldersAnyOrder && cfg.ReorderCopyrightHolders {
+		return licenseplugin.ProjectParam{}, errors.Errorf("copyright-holders-any-order and reorder-copyright-holders cannot both be true")
+	}
+	if len(cfg.CopyrightHolders) > 0 {
+		if err := validateCopyrightHolders(cfg.CopyrightHolders); err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+		if count := holderLineCount(cfg.Header); count > 1 || (count == 0 && !cfg.HeaderTemplate) {
+			return licenseplugin.ProjectParam{}, errors.Errorf("header must contain the %s placeholder on exactly one line when copyright-holders is specified", licenseplugin.HolderPlaceholder)
+		}
+	} else if cfg.headersContain(licenseplugin.HolderPlaceholder) {
+		// the lines that contain the placeholder are repeated once for each holder, so they would be dropped
+		return licenseplugin.ProjectParam{}, errors.Errorf("copyright-holders must be specified when a header contains the %s placeholder", licenseplugin.HolderPlaceholder)
+	}
+
+	header, err := cfg.expandHeader(cfg.Header, licenseText)
+	if err != nil {
+		return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to expand header")
+	}
+
+	styles, err := cfg.commentStyles()
+	if err != nil {
+		return licenseplugin.ProjectParam{}, err
+	}
+
+	customHeaders := make([]golicense.CustomHeaderParam, len(cfg.CustomHeaders))
+	customHeaderTexts := make([]string, len(cfg.CustomHeaders))
+	for i, v := range cfg.CustomHeaders {
+		v := CustomHeaderConfig(v)
+		if v.SPDX != "" {
+			if v.Header != "" {
+				return licenseplugin.ProjectParam{}, errors.Errorf("header and spdx cannot both be specified for custom header %s", v.Name)
+			}
+			if v.Header, err = spdxHeader(v.SPDX, cfg.CopyrightHolders); err != nil {
+				return licenseplugin.ProjectParam{}, errors.Wrapf(err, "invalid spdx for custom header %s", v.Name)
+			}
+		}
+		if v.Header, err = cfg.expandHeader(v.Header, licenseText); err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to expand header for custom header %s", v.Name)
+		}
+		if len(cfg.CopyrightHolders) > 0 && holderLineCount(v.Header) > 1 {
+			return licenseplugin.ProjectParam{}, errors.Errorf("header for custom header %s must not contain the %s placeholder on more than one line", v.Name, licenseplugin.HolderPlaceholder)
+		}
+		headerVal, err := v.toParam(styles)
+		if err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+		headerVal.Licenser = cfg.newLicenser(v.Header, commit)
+		if v.CommentStyle != "" {
+			if headerVal.Licenser, err = cfg.newStyledLicenser(v.Header, v.CommentStyle, styles, commit); err != nil {
+				return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to render header for custom header %s in comment style %s", v.Name, v.CommentStyle)
+			}
+		}
+		customHeaders[i] = headerVal
+		customHeaderTexts[i] = v.Header
+	}
+	if err := validateCustomHeaderParams(customHeaders); err != nil {
+		return licenseplugin.ProjectParam{}, err
+	}
+
+	fileTypes := make([]licenseplugin.FileTypeParam, len(cfg.FileTypes))
+	fileTypeStyles := make([]string, len(cfg.FileTypes))
+	for i, v := range cfg.FileTypes {
+		v := FileTypeConfig(v)
+		fileTypeVal, err := v.toParam(styles)
+		if err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+		// the comment style was validated by toParam
+		style, _ := v.commentStyle(styles)
+		fileTypeStyles[i] = style
+		if style != "" {
+			if fileTypeVal.Licenser, err = cfg.newStyledLicenser(header, style, styles, commit); err != nil {
+				return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to render header in comment style %s for file type %s", style, v.Name)
+			}
+			fileTypeVal.CustomHeaderLicensers = make(map[string]golicense.Licenser)
+			for j, customHeader := range customHeaders {
+				licenser, err := cfg.newStyledLicenser(customHeaderTexts[j], style, styles, commit)
+				if err != nil {
+					return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to render header for custom header %s in comment style %s for file type %s", customHeader.Name, style, v.Name)
+				}
+				fileTypeVal.CustomHeaderLicensers[customHeader.Name] = licenser
+			}
+		}
+		if fileTypeVal.Encoding != nil {
+			for _, headerText := range append([]string{header}, customHeaderTexts...) {
+				if _, ok := fileTypeVal.Encoding.Encode(licenseplugin.ExpandHolders(headerText, cfg.CopyrightHolders)); !ok {
+					return licenseplugin.ProjectParam{}, errors.Errorf("headers must only contain characters that can be represented in encoding %s of file type %s", v.Encoding, v.Name)
+				}
+			}
+		}
+		fileTypes[i] = fileTypeVal
+	}
+	if err := validateFileTypeParams(fileTypes); err != nil {
+		return licenseplugin.ProjectParam{}, err
+	}
+	if err := validateFileTypeNameOverlaps(cfg.FileTypes, fileTypeStyles); err != nil {
+		return licenseplugin.ProjectParam{}, err
+	}
+
+	foreignLicenses := make([]licenseplugin.ForeignLicenseParam, len(cfg.ForeignLicenses))
+	for i, v := range cfg.ForeignLicenses {
+		v := ForeignLicenseConfig(v)
+		foreignLicenseVal, err := v.ToParam()
+		if err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+		foreignLicenses[i] = foreignLicenseVal
+	}
+	if err := validateForeignLicenseParams(foreignLicenses); err != nil {
+		return licenseplugin.ProjectParam{}, err
+	}
+
+	var testLicenser golicense.Licenser
+	if cfg.SeparateTestHeader {
+		if cfg.TestHeader == "" {
+			return licenseplugin.ProjectParam{}, errors.Errorf("test-header must be specified when separate-test-header is true")
+		}
+		if len(cfg.CopyrightHolders) > 0 && holderLineCount(cfg.TestHeader) > 1 {
+			return licenseplugin.ProjectParam{}, errors.Errorf("test-header must not contain the %s placeholder on more than one line", licenseplugin.HolderPlaceholder)
+		}
+		testHeader, err := cfg.expandHeader(cfg.TestHeader, licenseText)
+		if err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to expand test-header")
+		}
+		testLicenser = cfg.newLicenser(testHeader, commit)
+	}
+
+	var packageDocLicenser golicense.Licenser
+	if cfg.PackageDocFile != "" {
+		if cfg.PackageDocHeader == "" {
+			return licenseplugin.ProjectParam{}, errors.Errorf("package-doc-header must be specified when package-doc-file is specified")
+		}
+		if strings.ContainsAny(cfg.PackageDocFile, `/\`) || !strings.HasSuffix(cfg.PackageDocFile, ".go") || strings.HasSuffix(cfg.PackageDocFile, "_test.go") {
+			return licenseplugin.ProjectParam{}, errors.Errorf("package-doc-file must be the name of a Go file that is not a test file: %q", cfg.PackageDocFile)
+		}
+	}
+	if cfg.PackageDocHeader != "" {
+		if len(cfg.CopyrightHolders) > 0 && holderLineCount(cfg.PackageDocHeader) > 1 {
+			return licenseplugin.ProjectParam{}, errors.Errorf("package-doc-header must not contain the %s placeholder on more than one line", licenseplugin.HolderPlaceholder)
+		}
+		packageDocHeader, err := cfg.expandHeader(cfg.PackageDocHeader, licenseText)
+		if err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to expand package-doc-header")
+		}
+		packageDocLicenser = cfg.newLicenser(packageDocHeader, commit)
+	}
+
+	var headerEnd *regexp.Regexp
+	if cfg.HeaderEnd != "" {
+		if headerEnd, err = regexp.Compile(cfg.HeaderEnd); err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "invalid header-end regular expression")
+		}
+	}
+
+	if cfg.KeepGeneratedNotice && cfg.HeaderEnd == "" {
+		return licenseplugin.ProjectParam{}, errors.Errorf("header-end must be specified when keep-generated-notice is true")
+	}
+
+	var thirdPartyMarker *regexp.Regexp
+	if cfg.ThirdPartyMarker != "" {
+		if thirdPartyMarker, err = regexp.Compile(cfg.ThirdPartyMarker); err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "invalid third-party-marker regular expression")
+		}
+	}
+
+	var required matcher.Matcher
+	if len(cfg.RequiredGlobs) > 0 {
+		for _, glob := range cfg.RequiredGlobs {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return licenseplugin.ProjectParam{}, errors.Wrapf(err, "invalid required glob %q", glob)
+			}
+		}
+		required = matcher.Path(cfg.RequiredGlobs...)
+	}
+
+	var skipFilesOver int64
+	if cfg.SkipFilesOver != "" {
+		if skipFilesOver, err = parseSize(cfg.SkipFilesOver); err != nil {
+			return licenseplugin.ProjectParam{}, errors.Wrapf(err, "invalid skip-files-over")
+		}
+	}
+
+	licenser := cfg.newLicenser(header, commit)
+	if cfg.HeaderPattern != "" {
+		if cfg.Header == "" {
+			return licenseplugin.ProjectParam{}, errors.Errorf("header must be specified when header-pattern is specified")
+		}
+		if licenser, err = licenseplugin.NewPatternLicenser(licenser, cfg.HeaderPattern); err != nil {
+			return licenseplugin.ProjectParam{}, err
+		}
+	}
+	if len(cfg.AcceptedHeaders) > 0 {
+		if cfg.Header == "" {
+			return licenseplugin.ProjectParam{}, errors.Errorf("header must be specified when accepted-headers is specified")
+		}
+		accepted := make([]golicense.Licenser, len(cfg.AcceptedHeaders))
+		for i, acceptedHeader := range cfg.AcceptedHeaders {
+			if len(cfg.CopyrightHolders) > 0 && holderLineCount(acceptedHeader) > 1 {
+				return licenseplugin.ProjectParam{}, errors.Errorf("accepted header %d must not contain the %s placeholder on more than one line", i, licenseplugin.HolderPlaceholder)
+			}
+			expanded, err := cfg.expandHeader(acceptedHeader, licenseText)
+			if err != nil {
+				return licenseplugin.ProjectParam{}, errors.Wrapf(err, "failed to expand accepted header %d", i)
+			}
+			accepted[i] = cfg.newLicenser(expanded, commit)
+		}
+		licenser = licenseplugin.NewAcceptingLicenser(licenser, accepted...)
+	}
+
+	return licenseplugin.ProjectParam{
+		Licenser:                  licenser,
+		CustomHeaders:             customHeaders,
+		TestLicenser:              testLicenser,
+		PackageDocLicenser:        packageDocLicenser,
+		PackageDocFile:            cfg.PackageDocFile,
+		FileTypes:                 fileTypes,
+		ForeignLicenses:           foreignLicenses,
+		ThirdPartyMarker:          thirdPartyMarker,
+		HeaderEnd:                 headerEnd,
+		KeepGeneratedNotice:       cfg.KeepGeneratedNotice,
+		MergeCopyright:            cfg.MergeCopyright,
+		PostModifyCommand:         cfg.PostModifyCommand,
+		Exclude:                   cfg.excludeMatcher(),
+		Required:                  required,
+		UpdateYear:                cfg.UpdateYear,
+		RequireCurrentYear:        cfg.RequireCurrentYear,
+		EnsureFinalNewline:        cfg.EnsureFinalNewline,
+		PreserveLeadingBlankLines: cfg.PreserveLeadingBlankLines,
+		RequirePackageClause:      cfg.RequirePackageClause,
+		RejectPlaceholders:        cfg.RejectPlaceholders,
+		SkipFilesOver:             skipFilesOver,
+		ForModule:                 cfg.forModule(commit),
+	}, nil
+}
+
+// forModule returns a function that returns the parameters for the files of the Go module with the provided path,
+// which are the parameters of the configuration with the module placeholder of every header replaced with the path and
+// the commit placeholder replaced with the provided commit. Returns nil if no header contains the module placeholder.
+func (cfg *ProjectConfig) forModule(commit string) func(module string) (licenseplugin.ProjectParam, error) {
+	if !cfg.headersContain(licenseplugin.ModulePlaceholder) {
+		return nil
+	}
+	baseCfg := *cfg
+	return func(module string) (licenseplugin.ProjectParam, error) {
+		moduleCfg := baseCfg
+		moduleCfg.Header = strings.Replace(baseCfg.Header, licenseplugin.ModulePlaceholder, module, -1)
+		moduleCfg.TestHeader = strings.Replace(baseCfg.TestHeader, licenseplugin.ModulePlaceholder, module, -1)
+		moduleCfg.PackageDocHeader = strings.Replace(baseCfg.PackageDocHeader, licenseplugin.ModulePlaceholder, module, -1)
+		moduleCfg.AcceptedHeaders = make([]string, len(baseCfg.AcceptedHeaders))
+		for i, acceptedHeader := range baseCfg.AcceptedHeaders {
+			moduleCfg.AcceptedHeaders[i] = strings.Replace(acceptedHeader, licenseplugin.ModulePlaceholder, module, -1)
+		}
+		moduleCfg.CustomHeaders = make([]v0.CustomHeaderConfig, len(baseCfg.CustomHeaders))
+		for i, customHeader := range baseCfg.CustomHeaders {
+			customHeader.Header = strings.Replace(customHeader.Header, licenseplugin.ModulePlaceholder, module, -1)
+			moduleCfg.CustomHeaders[i] = customHeader
+		}
+		return moduleCfg.toParam(commit)
+	}
+}
+
+// executeHeaderTemplates returns a copy of the configuration in which every header has been executed as a template
+// (see licenseplugin.ExecuteHeaderTemplate).
+func (cfg *ProjectConfig) executeHeaderTemplates() (ProjectConfig, error) {
+	data := licenseplugin.NewHeaderTemplateData(cfg.CopyrightHolders)
+	executed := *cfg
+	var err error
+	if executed.Header, err = licenseplugin.ExecuteHeaderTemplate(cfg.Header, data); err != nil {
+		return ProjectConfig{}, errors.Wrapf(err, "invalid header")
+	}
+	if executed.TestHeader, err = licenseplugin.ExecuteHeaderTemplate(cfg.TestHeader, data); err != nil {
+		return ProjectConfig{}, errors.Wrapf(err, "invalid test-header")
+	}
+	if executed.PackageDocHeader, err = licenseplugin.ExecuteHeaderTemplate(cfg.PackageDocHeader, data); err != nil {
+		return ProjectConfig{}, errors.Wrapf(err, "invalid package-doc-header")
+	}
+	executed.AcceptedHeaders = make([]string, len(cfg.AcceptedHeaders))
+	for i, acceptedHeader := range cfg.AcceptedHeaders {
+		if executed.AcceptedHeaders[i], err = licenseplugin.ExecuteHeaderTemplate(acceptedHeader, data); err != nil {
+			return ProjectConfig{}, errors.Wrapf(err, "invalid accepted header %d", i)
+		}
+	}
+	executed.CustomHeaders = make([]v0.CustomHeaderConfig, len(cfg.CustomHeaders))
+	for i, customHeader := range cfg.CustomHeaders {
+		if customHeader.Header, err = licenseplugin.ExecuteHeaderTemplate(customHeader.Header, data); err != nil {
+			return ProjectConfig{}, errors.Wrapf(err, "invalid header for custom header %s", customHeader.Name)
+		}
+		executed.CustomHeaders[i] = customHeader
+	}
+	return executed, nil
+}
+
+// headersContain returns true if any of the headers of the configuration contains the provided placeholder.
+func (cfg *ProjectConfig) headersContain(placeholder string) bool {
+	headers := append([]string{cfg.Header, cfg.TestHeader, cfg.PackageDocHeader}, cfg.AcceptedHeaders...)
+	for _, customHeader := range cfg.CustomHeaders {
+		headers = append(headers, customHeader.Header)
+	}
+	for _, header := range headers {
+		if strings.Contains(header, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHeader expands the environment variable references, the year placeholders and the license text placeholder in
+// the provided header based on the configuration and reflows the header if the configuration specifies a wrap width.
+func (cfg *ProjectConfig) expandHeader(header, licenseText string) (string, error) {
+	if cfg.ExpandEnv {
+		var err error
+		if header, err = licenseplugin.ExpandEnv(header); err != nil {
+			return "", err
+		}
+	}
+	if cfg.YearFormat != "" {
+		header = strings.Replace(header, yearPlaceholder, cfg.YearFormat, -1)
+	}
+	if licenseText != "" {
+		header = licenseplugin.ExpandLicenseText(header, licenseText)
+	}
+	if !cfg.KeepTrailingWhitespace {
+		header = licenseplugin.TrimTrailingWhitespace(header)
+	}
+	if cfg.WrapWidth > 0 && header != "" {
+		comment := strings.TrimRight(header, "\n")
+		reflowed, ok := commentstyle.Reflow(comment, cfg.WrapWidth)
+		if !ok {
+			return "", errors.Errorf("header must be a comment in one of the comment styles %v when wrap-width is specified", commentstyle.Names())
+		}
+		header = reflowed + header[len(comment):]
+	}
+	return header, nil
+}
+
+// newLicenser returns the Licenser for the provided expanded header, which has the copyright holder lines of the
+// configuration. Unless trailing whitespace is kept, headers that differ from the header only in the trailing
+// whitespace of their lines are replaced when the header is added, as are headers whose paragraphs are spaced
+// differently if the configuration normalizes paragraph spacing and bare copyright lines if the configuration replaces
+// them. If the header contains the commit placeholder, the provided commit is added in its place and any
+// commit matches it.
+func (cfg *ProjectConfig) newLicenser(header, commit string) golicense.Licenser {
+	if strings.Contains(header, licenseplugin.CommitPlaceholder) {
+		licenser := cfg.newLicenser(strings.Replace(header, licenseplugin.CommitPlaceholder, commit, -1), commit)
+		return licenseplugin.NewCommitLicenser(licenser, licenseplugin.ExpandHolders(header, cfg.CopyrightHolders))
+	}
+	licenser := cfg.newHeaderLicenser(header)
+	if cfg.ReplaceCopyrightLine {
+		licenser = licenseplugin.NewCopyrightLineLicenser(licenser)
+	}
+	var replaced []golicense.Licenser
+	if !cfg.KeepTrailingWhitespace {
+		replaced = append(replaced, licenseplugin.NewTrailingWhitespaceLicenser(licenseplugin.ExpandHolders(header, cfg.CopyrightHolders)))
+	}
+	if cfg.NormalizeParagraphSpacing {
+		replaced = append(replaced, licenseplugin.NewParagraphSpacingLicenser(licenseplugin.ExpandHolders(header, cfg.CopyrightHolders)))
+	}
+	if len(replaced) == 0 {
+		return licenser
+	}
+	return licenseplugin.NewReplacingLicenser(licenser, replaced...)
+}
+
+// newHeaderLicenser returns the Licenser for the provided expanded header without replacing any other headers (other
+// than reordering the copyright holder lines of headers if the configuration reorders them).
+func (cfg *ProjectConfig) newHeaderLicenser(header string) golicense.Licenser {
+	yearRanges := cfg.UpdateYear || cfg.RequireCurrentYear
+	if len(cfg.CopyrightHolders) > 0 && strings.Contains(header, licenseplugin.HolderPlaceholder) {
+		switch {
+		case cfg.CopyrightHoldersAnyOrder:
+			return licenseplugin.NewUnorderedHoldersLicenser(header, cfg.CopyrightHolders, yearRanges)
+		case cfg.ReorderCopyrightHolders:
+			return licenseplugin.NewReorderingHoldersLicenser(header, cfg.CopyrightHolders, yearRanges)
+		}
+	}
+	if yearRanges {
+		return licenseplugin.NewYearRangeLicenser(licenseplugin.ExpandHolders(header, cfg.CopyrightHolders))
+	}
+	return licenseplugin.NewLicenser(licenseplugin.ExpandHolders(header, cfg.CopyrightHolders))
+}
+
+// newStyledLicenser returns the Licenser for the provided header rendered in the comment style with the provided
+// name, which is a registered comment style or one of the provided comment styles of the configuration. When the header
+// is added to content that starts with the header rendered in a different one of these comment styles, that header is
+// replaced. The commit placeholder is handled as described for newLicenser.
+func (cfg *ProjectConfig) newStyledLicenser(header, styleName string, styles commentStyles, commit string) (golicense.Licenser, error) {
+	var licenser golicense.Licenser
+	var replaced []golicense.Licenser
+	for _, name := range styles.names() {
+		// names are registered or defined, so lookup cannot fail
+		style, _ := styles.lookup(name)
+		styledHeader, err := restyleHeader(header, style, styles)
+		if err != nil {
+			return nil, err
+		}
+		if name == styleName {
+			licenser = cfg.newLicenser(styledHeader, commit)
+		} else {
+			replaced = append(replaced, cfg.newLicenser(styledHeader, commit))
+		}
+	}
+	return licenseplugin.NewReplacingLicenser(licenser, replaced...), nil
+}
+
+// restyleHeader returns the provided header rendered in the provided comment style. The header must be a comment in
+// one of the registered comment styles or the provided comment styles of the configuration. Trailing newlines of the
+// header are preserved.
+func restyleHeader(header string, style commentstyle.CommentStyle, styles commentStyles) (string, error) {
+	if header == "" {
+		return "", nil
+	}
+	comment := strings.TrimRight(header, "\n")
+	text, ok := styles.uncomment(comment)
+	if !ok {
+		return "", errors.Errorf("header is not a comment in any of the comment styles %v", styles.names())
+	}
+	return style.Comment(text) + header[len(comment):], nil
+}
+
+// holderLineCount returns the number of lines of the provided header that contain the holder placeholder.
+func holderLineCount(header string) int {
+	count := 0
+	for _, line := range strings.Split(header, "\n") {
+		if strings.Contains(line, licenseplugin.HolderPlaceholder) {
+			count++
+		}
+	}
+	return count
+}
+
+func validateCopyrightHolders(holders []string) error {
+	allHolders := make(map[string]struct{})
+	collisions := make(map[string]struct{})
+	for _, holder := range holders {
+		if holder == "" {
+			return errors.Errorf("copyright holder cannot be blank")
+		}
+		if _, seen := allHolders[holder]; seen {
+			collisions[holder] = struct{}{}
+		}
+		allHolders[holder] = struct{}{}
+	}
+	if len(collisions) > 0 {
+		return errors.Errorf("copyright holder(s) specified multiple times: %v", sortedKeys(collisions))
+	}
+	return nil
+}
+
+func validateCustomHeaderParams(headerParams []golicense.CustomHeaderParam) error {
+	allNames := make(map[string]struct{})
+	collisions := make(map[string]struct{})
+	for _, param := range headerParams {
+		if _, seen := allNames[param.Name]; seen {
+			collisions[param.Name] = struct{}{}
+		}
+		allNames[param.Name] = struct{}{}
+	}
+	if len(collisions) > 0 {
+		return errors.Errorf("custom header(s) defined multiple times: %v", sortedKeys(collisions))
+	}
+
+	// map from path to custom header entries that have the path
+	pathsToCustomEntries := make(map[string][]string)
+	for _, ch := range headerParams {
+		for _, path := range ch.IncludePaths {
+			pathsToCustomEntries[path] = append(pathsToCustomEntries[path], ch.Name)
+		}
+	}
+	var customPathCollisionMsgs []string
+	for _, k := range sortedKeys(pathsToCustomEntries) {
+		v := pathsToCustomEntries[k]
+		if len(v) > 1 {
+			customPathCollisionMsgs = append(customPathCollisionMsgs, fmt.Sprintf("%s: %s", k, strings.Join(v, ", ")))
+		}
+	}
+	if len(customPathCollisionMsgs) > 0 {
+		return errors.New(strings.Join(append([]string{"the same path is defined by multiple custom header entries:"}, customPathCollisionMsgs...), "\n\t"))
+	}
+	return nil
+}
+
+func validateFileTypeParams(fileTypeParams []licenseplugin.FileTypeParam) error {
+	allNames := make(map[string]struct{})
+	collisions := make(map[string]struct{})
+	for _, param := range fileTypeParams {
+		if _, seen := allNames[param.Name]; seen {
+			collisions[param.Name] = struct{}{}
+		}
+		allNames[param.Name] = struct{}{}
+	}
+	if len(collisions) > 0 {
+		return errors.Errorf("file type(s) defined multiple times: %v", sortedKeys(collisions))
+	}
+
+	// map from extension to file types that have the extension
+	extsToFileTypes := make(map[string][]string)
+	for _, param := range fileTypeParams {
+		for _, ext := range param.Extensions {
+			extsToFileTypes[ext] = append(extsToFileTypes[ext], param.Name)
+		}
+	}
+	var extCollisionMsgs []string
+	for _, k := range sortedKeys(extsToFileTypes) {
+		if v := extsToFileTypes[k]; len(v) > 1 {
+			extCollisionMsgs = append(extCollisionMsgs, fmt.Sprintf("%s: %s", k, strings.Join(v, ", ")))
+		}
+	}
+	if len(extCollisionMsgs) > 0 {
+		return errors.New(strings.Join(append([]string{"the same extension is defined by multiple file types:"}, extCollisionMsgs...), "\n\t"))
+	}
+
+	// map from file name to file types that have the file name
+	filenamesToFileTypes := make(map[string][]string)
+	for _, param := range fileTypeParams {
+		for _, filename := range param.Filenames {
+			filenamesToFileTypes[filename] = append(filenamesToFileTypes[filename], param.Name)
+		}
+	}
+	var filenameCollisionMsgs []string
+	for _, k := range sortedKeys(filenamesToFileTypes) {
+		if v := filenamesToFileTypes[k]; len(v) > 1 {
+			filenameCollisionMsgs = append(filenameCollisionMsgs, fmt.Sprintf("%s: %s", k, strings.Join(v, ", ")))
+		}
+	}
+	if len(filenameCollisionMsgs) > 0 {
+		return errors.New(strings.Join(append([]string{"the same filename is defined by multiple file types:"}, filenameCollisionMsgs...), "\n\t"))
+	}
+	return nil
+}
+
+// validateFileTypeNameOverlaps returns an error if a literal name of a file type (such as "Jenkinsfile") is matched by
+// the names of another file type with a different comment style (as provided by the corresponding element of styles),
+// unless an extension or file name of either file type also matches it. Such a file is matched by both file types with
+// the same specificity, so its comment style would depend on the order in which the file types are declared. Names
+// that are not literals are not checked against each other because whether two regular expressions match a common
+// name cannot be determined in general, so such overlaps are resolved by declaration order.
+func validateFileTypeNameOverlaps(fileTypes []v0.FileTypeConfig, styles []string) error {
+	for i, fileType := range fileTypes {
+		for _, name := range fileType.Names {
+			literal, complete := regexp.MustCompile(name).LiteralPrefix()
+			if !complete || matchesByExtensionOrFilename(fileType, literal) {
+				continue
+			}
+			for j, other := range fileTypes {
+				if i == j || styles[i] == styles[j] || matchesByExtensionOrFilename(other, literal) {
+					continue
+				}
+				for _, otherName := range other.Names {
+					if regexp.MustCompile(`^(?:` + otherName + `)$`).MatchString(literal) {
+						return errors.Errorf("file types %s and %s have different comment styles and both match files named %q by name", fileType.Name, other.Name, literal)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchesByExtensionOrFilename returns true if an extension or file name of the provided file type matches the provided file name.
+func matchesByExtensionOrFilename(fileType v0.FileTypeConfig, name string) bool {
+	for _, ext := range fileType.Extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	for _, filename := range fileType.Filenames {
+		if name == filename {
+			return true
+		}
+	}
+	return false
+}
+
+func validateForeignLicenseParams(foreignLicenseParams []licenseplugin.ForeignLicenseParam) error {
+	allNames := make(map[string]struct{})
+	collisions := make(map[string]struct{})
+	for _, param := range foreignLicenseParams {
+		if _, seen := allNames[param.Name]; seen {
+			collisions[param.Name] = struct{}{}
+		}
+		allNames[param.Name] = struct{}{}
+	}
+	if len(collisions) > 0 {
+		return errors.Errorf("foreign license(s) defined multiple times: %v", sortedKeys(collisions))
+	}
+	return nil
+}
+
+// sizeUnits maps the units of sizes to their number of bytes.
+var sizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+}
+
+// parseSize returns the number of bytes of the provided size, which is a non-negative integer optionally followed by
+// one of the units of sizeUnits (case-insensitive, optionally separated from the integer by whitespace).
+func parseSize(size string) (int64, error) {
+	trimmed := strings.TrimSpace(size)
+	digitsEnd := strings.IndexFunc(trimmed, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if digitsEnd == -1 {
+		digitsEnd = len(trimmed)
+	}
+	multiplier, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(trimmed[digitsEnd:]))]
+	if digitsEnd == 0 || !ok {
+		return 0, errors.Errorf("invalid size %q: must be a non-negative integer optionally followed by B, KB, MB or GB", size)
+	}
+	n, err := strconv.ParseInt(trimmed[:digitsEnd], 10, 64)
+	if err != nil || n > math.MaxInt64/multiplier {
+		return 0, errors.Errorf("invalid size %q: too large", size)
+	}
+	return n * multiplier, nil
+}
+
+// excludeMatcher returns the matcher for the excludes of the configuration, which is case-insensitive if
+// CaseInsensitiveExclude is true.
+func (cfg *ProjectConfig) excludeMatcher() matcher.Matcher {
+	if cfg.CaseInsensitiveExclude == nil || !*cfg.CaseInsensitiveExclude {
+		return cfg.Exclude.Matcher()
+	}
+	names := make([]string, len(cfg.Exclude.Names))
+	for i, name := range cfg.Exclude.Names {
+		names[i] = `(?i)` + name
+	}
+	paths := make([]string, len(cfg.Exclude.Paths))
+	for i, path := range cfg.Exclude.Paths {
+		paths[i] = strings.ToLower(path)
+	}
+	return matcher.Any(matcher.Name(names...), lowerCaseMatcher{matcher.Path(paths...)})
+}
+
+// ResolveExcludePaths makes the paths of the excludes of the configuration, which was loaded from the provided
+// configuration file, relative to the provided project directory if ExcludeRelativeToConfig is true. The paths are
+// joined with the directory of the configuration file relative to the project directory, so the excludes that are added
+// to the configuration afterwards (such as those of the gödel configuration) remain relative to the project directory,
+// and ExcludeRelativeToConfig is set to false. Returns an error if ExcludeRelativeToConfig is true and the
+// configuration file is not in the project directory. Does nothing if ExcludeRelativeToConfig is false or the
+// configuration file is empty.
+func (cfg *ProjectConfig) ResolveExcludePaths(projectDir, cfgFile string) error {
+	if !cfg.ExcludeRelativeToConfig || cfgFile == "" {
+		return nil
+	}
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine absolute path of %s", projectDir)
+	}
+	absCfgDir, err := filepath.Abs(filepath.Dir(cfgFile))
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine absolute path of %s", cfgFile)
+	}
+	cfgDir, err := filepath.Rel(absProjectDir, absCfgDir)
+	if err != nil || cfgDir == ".." || strings.HasPrefix(cfgDir, ".."+string(filepath.Separator)) {
+		return errors.Errorf("configuration file %s must be in the project directory when exclude-relative-to-config is true", cfgFile)
+	}
+	paths := make([]string, len(cfg.Exclude.Paths))
+	for i, path := range cfg.Exclude.Paths {
+		paths[i] = filepath.Join(cfgDir, path)
+	}
+	cfg.Exclude.Paths = paths
+	cfg.ExcludeRelativeToConfig = false
+	return nil
+}
+
+// lowerCaseMatcher is a matcher that matches paths whose lower-case form is matched by the wrapped matcher.
+type lowerCaseMatcher struct {
+	matcher.Matcher
+}
+
+func (m lowerCaseMatcher) Match(relPath string) bool {
+	return m.Matcher.Match(strings.ToLower(relPath))
+}
+
+// IsCaseInsensitiveFS returns true if the file system of the provided directory is case-insensitive, which is the
+// case if the directory can also be accessed using its absolute path with the case of its letters swapped. Returns
+// false if this cannot be determined (for example, because the path has no letters).
+func IsCaseInsensitiveFS(dir string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	swapped := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, absDir)
+	if swapped == absDir {
+		return false
+	}
+	fi, err := os.Stat(absDir)
+	if err != nil {
+		return false
+	}
+	swappedFi, err := os.Stat(swapped)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi, swappedFi)
+}
+
+// vcsDirNames are the names of the directories in which version control systems store their data. These directories
+// are never processed, so RemoveHiddenExcludes keeps them excluded.
+var vcsDirNames = []string{".git", ".hg", ".svn", ".bzr"}
+
+// RemoveHiddenExcludes returns the provided exclude configuration without the name patterns that exclude hidden files
+// and directories (names that start with "."), such as the `\..+` pattern of the default gödel configuration. A name
+// pattern excludes hidden files and directories if it matches ".a" but not "a". Other name patterns and all paths are
+// kept. The directories of version control systems (such as ".git") that were excluded by a removed pattern remain
+// excluded.
+func RemoveHiddenExcludes(exclude matcher.NamesPathsCfg) matcher.NamesPathsCfg {
+	out := matcher.NamesPathsCfg{
+		Paths: exclude.Paths,
+	}
+	excludedVCSDirs := make(map[string]bool)
+	for _, name := range exclude.Names {
+		nameRegexp, err := regexp.Compile(`^(?:` + name + `)$`)
+		if err == nil && nameRegexp.MatchString(".a") && !nameRegexp.MatchString("a") {
+			for _, vcsDirName := range vcsDirNames {
+				if nameRegexp.MatchString(vcsDirName) {
+					excludedVCSDirs[regexp.QuoteMeta(vcsDirName)] = true
+				}
+			}
+			continue
+		}
+		out.Names = append(out.Names, name)
+	}
+	for _, vcsDirName := range vcsDirNames {
+		if name := regexp.QuoteMeta(vcsDirName); excludedVCSDirs[name] && !containsString(out.Names, name) {
+			out.Names = append(out.Names, name)
+		}
+	}
+	return out
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type CustomHeaderConfig v0.CustomHeaderConfig
+
+func ToCustomHeaderConfigs(in []CustomHeaderConfig) []v0.CustomHeaderConfig {
+	if in == nil {
+		return nil
+	}
+	out := make([]v0.CustomHeaderConfig, len(in))
+	for i, v := range in {
+		out[i] = v0.CustomHeaderConfig(v)
+	}
+	return out
+}
+
+func (cfg *CustomHeaderConfig) ToParam() (golicense.CustomHeaderParam, error) {
+	return cfg.toParam(nil)
+}
+
+// toParam returns the parameters of the custom header, whose comment style can be a registered comment style or one of
+// the provided comment styles of the configuration.
+func (cfg *CustomHeaderConfig) toParam(styles commentStyles) (golicense.CustomHeaderParam, error) {
+	if cfg.Name == "" {
+		return golicense.CustomHeaderParam{}, errors.Errorf("custom header name cannot be blank")
+	}
+	if cfg.CommentStyle != "" {
+		if _, err := styles.lookup(cfg.CommentStyle); err != nil {
+			return golicense.CustomHeaderParam{}, errors.Wrapf(err, "invalid comment-style for custom header %s", cfg.Name)
+		}
+	}
+	return golicense.CustomHeaderParam{
+		Name:         cfg.Name,
+		Licenser:     licenseplugin.NewLicenser(cfg.Header),
+		IncludePaths: cfg.Paths,
+	}, nil
+}
+
+// commentStyle returns the name of the comment style of the file type: the name of its comment style (a registered
+// comment style or one of the provided comment styles of the configuration) or, if the comment style is
+// autoCommentStyle, the name of the comment style registered for its file names and extensions. Returns an empty name
+// if the file type does not specify a comment style.
+func (cfg *FileTypeConfig) commentStyle(styles commentStyles) (string, error) {
+	switch cfg.CommentStyle {
+	case "":
+		return "", nil
+	case autoCommentStyle:
+	default:
+		if _, err := styles.lookup(cfg.CommentStyle); err != nil {
+			return "", err
+		}
+		return cfg.CommentStyle, nil
+	}
+	patternsByStyle := make(map[string][]string)
+	for _, pattern := range append(append([]string{}, cfg.Filenames...), cfg.Extensions...) {
+		if style, ok := commentstyle.ForFile(pattern); ok {
+			patternsByStyle[style] = append(patternsByStyle[style], pattern)
+		}
+	}
+	switch len(patternsByStyle) {
+	case 0:
+		return "", errors.Errorf("no comment style is registered for the filenames and extensions of the file type")
+	case 1:
+		for style := range patternsByStyle {
+			return style, nil
+		}
+	}
+	var msgs []string
+	for _, style := range sortedKeys(patternsByStyle) {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", style, strings.Join(patternsByStyle[style], ", ")))
+	}
+	return "", errors.New(strings.Join(append([]string{"the filenames and extensions of the file type have different comment styles:"}, msgs...), "\n\t"))
+}
+
+// spdxHeader returns the header of the license with the provided SPDX identifier rendered using "//" line comments.
+// The copyright holder of the header is rendered using the holder placeholder, so an error is returned if the header
+// has a copyright holder and no copyright holders are provided.
+func spdxHeader(id string, copyrightHolders []string) (string, error) {
+	license, err := spdx.Lookup(id)
+	if err != nil {
+		return "", err
+	}
+	header := license.HeaderText(licenseplugin.HolderPlaceholder)
+	if strings.Contains(header, licenseplugin.HolderPlaceholder) && len(copyrightHolders) == 0 {
+		return "", errors.Errorf("copyright-holders must be specified because the header of license %s has a copyright holder", id)
+	}
+	return strings.TrimSuffix(lineComment(header, "//"), "\n"), nil
+}
+
+type FileTypeConfig v0.FileTypeConfig
+
+func ToFileTypeConfigs(in []FileTypeConfig) []v0.FileTypeConfig {
+	if in == nil {
+		return nil
+	}
+	out := make([]v0.FileTypeConfig, len(in))
+	for i, v := range in {
+		out[i] = v0.FileTypeConfig(v)
+	}
+	return out
+}
+
+func (cfg *FileTypeConfig) ToParam() (licenseplugin.FileTypeParam, error) {
+	return cfg.toParam(nil)
+}
+
+// toParam returns the parameters of the file type, whose comment style can be a registered comment style or one of the
+// provided comment styles of the configuration.
+func (cfg *FileTypeConfig) toParam(styles commentStyles) (licenseplugin.FileTypeParam, error) {
+	if cfg.Name == "" {
+		return licenseplugin.FileTypeParam{}, errors.Errorf("file type name cannot be blank")
+	}
+	if len(cfg.Names) == 0 && len(cfg.Extensions) == 0 && len(cfg.Filenames) == 0 && cfg.ContentPattern == "" {
+		return licenseplugin.FileTypeParam{}, errors.Errorf("file type %s must specify at least one name, extension, filename or content-pattern", cfg.Name)
+	}
+	for _, name := range cfg.Names {
+		if _, err := regexp.Compile(name); err != nil {
+			return licenseplugin.FileTypeParam{}, errors.Wrapf(err, "invalid name regular expression for file type %s", cfg.Name)
+		}
+	}
+	names := append([]string{}, cfg.Names...)
+	for _, ext := range cfg.Extensions {
+		if !strings.HasPrefix(ext, ".") {
+			return licenseplugin.FileTypeParam{}, errors.Errorf("extension %q for file type %s must start with \".\"", ext, cfg.Name)
+		}
+		names = append(names, `.+`+regexp.QuoteMeta(ext))
+	}
+	for _, filename := range cfg.Filenames {
+		if filename == "" || strings.ContainsAny(filename, `/\`) {
+			return licenseplugin.FileTypeParam{}, errors.Errorf("filename %q for file type %s must be a non-empty name without path separators", filename, cfg.Name)
+		}
+		names = append(names, regexp.QuoteMeta(filename))
+	}
+	if _, err := cfg.commentStyle(styles); err != nil {
+		return licenseplugin.FileTypeParam{}, errors.Wrapf(err, "invalid comment-style for file type %s", cfg.Name)
+	}
+	var firstLine *regexp.Regexp
+	if cfg.FirstLine != "" {
+		var err error
+		firstLine, err = regexp.Compile(cfg.FirstLine)
+		if err != nil {
+			return licenseplugin.FileTypeParam{}, errors.Wrapf(err, "invalid first-line regular expression for file type %s", cfg.Name)
+		}
+	}
+	if firstLine != nil && len(cfg.InsertAfter) > 0 {
+		return licenseplugin.FileTypeParam{}, errors.Errorf("first-line and insert-after cannot both be specified for file type %s", cfg.Name)
+	}
+	var insertAfter []*regexp.Regexp
+	for _, expr := range cfg.InsertAfter {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return licenseplugin.FileTypeParam{}, errors.Wrapf(err, "invalid insert-after regular expression for file type %s", cfg.Name)
+		}
+		insertAfter = append(insertAfter, pattern)
+	}
+	var contentPattern *regexp.Regexp
+	if cfg.ContentPattern != "" {
+		var err error
+		if contentPattern, err = regexp.Compile(cfg.ContentPattern); err != nil {
+			return licenseplugin.FileTypeParam{}, errors.Wrapf(err, "invalid content-pattern regular expression for file type %s", cfg.Name)
+		}
+	}
+	if cfg.BlankLinesAfterHeader != nil && *cfg.BlankLinesAfterHeader < 0 {
+		return licenseplugin.FileTypeParam{}, errors.Errorf("blank-lines-after-header for file type %s cannot be negative: %d", cfg.Name, *cfg.BlankLinesAfterHeader)
+	}
+	var encoding licenseplugin.Encoding
+	if cfg.Encoding != "" {
+		var err error
+		if encoding, err = licenseplugin.LookupEncoding(cfg.Encoding); err != nil {
+			return licenseplugin.FileTypeParam{}, errors.Wrapf(err, "invalid encoding for file type %s", cfg.Name)
+		}
+	}
+	return licenseplugin.FileTypeParam{
+		Name:                  cfg.Name,
+		Matcher:               matcher.Name(names...),
+		Extensions:            cfg.Extensions,
+		Filenames:             cfg.Filenames,
+		FirstLine:             firstLine,
+		InsertAfter:           insertAfter,
+		ContentPattern:        contentPattern,
+		BlankLinesAfterHeader: cfg.BlankLinesAfterHeader,
+		Encoding:              encoding,
+	}, nil
+}
+
+type CommentStyleConfig v0.CommentStyleConfig
+
+// ToCommentStyle returns the comment style defined by the configuration. The first and the last line of the text use
+// the prefix and the suffix of the other lines unless they specify their own.
+func (cfg *CommentStyleConfig) ToCommentStyle() (commentstyle.Lines, error) {
+	if cfg.Name == "" {
+		return commentstyle.Lines{}, errors.Errorf("comment style name cannot be blank")
+	}
+	if cfg.Name == autoCommentStyle {
+		return commentstyle.Lines{}, errors.Errorf("comment style name cannot be %q", autoCommentStyle)
+	}
+	middle := commentstyle.Affixes{Prefix: cfg.LinePrefix, Suffix: cfg.LineSuffix}
+	style := commentstyle.Lines{
+		Start:  cfg.Start,
+		First:  middle,
+		Middle: middle,
+		Last:   middle,
+		End:    cfg.End,
+	}
+	if cfg.FirstLinePrefix != nil {
+		style.First.Prefix = *cfg.FirstLinePrefix
+	}
+	if cfg.FirstLineSuffix != nil {
+		style.First.Suffix = *cfg.FirstLineSuffix
+	}
+	if cfg.LastLinePrefix != nil {
+		style.Last.Prefix = *cfg.LastLinePrefix
+	}
+	if cfg.LastLineSuffix != nil {
+		style.Last.Suffix = *cfg.LastLineSuffix
+	}
+	if style == (commentstyle.Lines{}) {
+		return commentstyle.Lines{}, errors.Errorf("comment style %s must specify at least one of start, end or a line prefix or suffix", cfg.Name)
+	}
+	return style, nil
+}
+
+// commentStyles are the comment styles defined by a configuration (see ProjectConfig.CommentStyles) by name. The
+// registered comment styles are available in addition to them.
+type commentStyles map[string]commentstyle.CommentStyle
+
+// commentStyles returns the comment styles defined by the configuration. Returns an error if a comment style is not
+// valid or its name is not unique or is the name of a registered comment style.
+func (cfg *ProjectConfig) commentStyles() (commentStyles, error) {
+	styles := make(commentStyles)
+	for _, v := range cfg.CommentStyles {
+		v := CommentStyleConfig(v)
+		style, err := v.ToCommentStyle()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := commentstyle.Lookup(v.Name); err == nil {
+			return nil, errors.Errorf("comment style %s is already registered", v.Name)
+		}
+		if _, ok := styles[v.Name]; ok {
+			return nil, errors.Errorf("comment style %s is defined more than once", v.Name)
+		}
+		styles[v.Name] = style
+	}
+	return styles, nil
+}
+
+// lookup returns the comment style with the provided name, which is either registered or defined by the configuration.
+func (s commentStyles) lookup(name string) (commentstyle.CommentStyle, error) {
+	if style, ok := s[name]; ok {
+		return style, nil
+	}
+	if style, err := commentstyle.Lookup(name); err == nil {
+		return style, nil
+	}
+	return nil, errors.Errorf("unknown comment style %q: must be one of %v", name, s.names())
+}
+
+// names returns the sorted names of the registered comment styles and of the comment styles defined by the
+// configuration.
+func (s commentStyles) names() []string {
+	names := commentstyle.Names()
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// uncomment returns the text of the provided comment using the first registered comment style that the comment is in
+// (see commentstyle.Uncomment) or, if it is not in a registered comment style, the first comment style defined by the
+// configuration (in the order of their names) that it is in. Returns false if the comment is not in any of them.
+func (s commentStyles) uncomment(comment string) (string, bool) {
+	if text, ok := commentstyle.Uncomment(comment); ok {
+		return text, true
+	}
+	for _, name := range sortedKeys(s) {
+		if text, ok := s[name].Uncomment(comment); ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+type ForeignLicenseConfig v0.ForeignLicenseConfig
+
+func (cfg *ForeignLicenseConfig) ToParam() (licenseplugin.ForeignLicenseParam, error) {
+	if cfg.Name == "" {
+		return licenseplugin.ForeignLicenseParam{}, errors.Errorf("foreign license name cannot be blank")
+	}
+	if cfg.Signature == "" {
+		return licenseplugin.ForeignLicenseParam{}, errors.Errorf("foreign license %s must specify a signature", cfg.Name)
+	}
+	signature, err := regexp.Compile(cfg.Signature)
+	if err != nil {
+		return licenseplugin.ForeignLicenseParam{}, errors.Wrapf(err, "invalid signature regular expression for foreign license %s", cfg.Name)
+	}
+	return licenseplugin.ForeignLicenseParam{
+		Name:      cfg.Name,
+		Signature: signature,
+	}, nil
+}
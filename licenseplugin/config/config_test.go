@@ -0,0 +1,1744 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package config_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin/config"
+	"github.com/palantir/godel-license-plugin/licenseplugin/spdx"
+	"github.com/palantir/pkg/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestProjectConfigToParam(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "valid file types",
+			yml: `header: "// Header"
+file-types:
+  - name: php
+    names: ['.*\.php']
+    first-line: '^<\?php'
+`,
+		},
+		{
+			name: "file type without name",
+			yml: `file-types:
+  - names: ['.*\.php']
+`,
+			wantErr: "file type name cannot be blank",
+		},
+		{
+			name: "file type with content pattern",
+			yml: `header: "// Header"
+file-types:
+  - name: shell
+    content-pattern: '\A#!.*\bsh\b'
+`,
+		},
+		{
+			name: "file type with invalid content pattern",
+			yml: `file-types:
+  - name: shell
+    content-pattern: '('
+`,
+			wantErr: "invalid content-pattern regular expression for file type shell: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "file type without names",
+			yml: `file-types:
+  - name: php
+`,
+			wantErr: "file type php must specify at least one name, extension, filename or content-pattern",
+		},
+		{
+			name: "file type with invalid first line",
+			yml: `file-types:
+  - name: php
+    names: ['.*\.php']
+    first-line: '('
+`,
+			wantErr: "invalid first-line regular expression for file type php: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "file type with insert after",
+			yml: `header: "// Header"
+file-types:
+  - name: python
+    extensions: [.py]
+    comment-style: hash
+    insert-after: ['^#!', '^# -\*- coding']
+`,
+		},
+		{
+			name: "file type with invalid insert after",
+			yml: `file-types:
+  - name: python
+    extensions: [.py]
+    insert-after: ['^#!', '(']
+`,
+			wantErr: "invalid insert-after regular expression for file type python: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "file type with first line and insert after",
+			yml: `file-types:
+  - name: php
+    names: ['.*\.php']
+    first-line: '^<\?php'
+    insert-after: ['^declare']
+`,
+			wantErr: "first-line and insert-after cannot both be specified for file type php",
+		},
+		{
+			name: "duplicate file types",
+			yml: `file-types:
+  - name: php
+    names: ['.*\.php']
+  - name: php
+    names: ['.*\.php5']
+`,
+			wantErr: "file type(s) defined multiple times: [php]",
+		},
+		{
+			name: "same extension in multiple file types",
+			yml: `file-types:
+  - name: php
+    extensions: [.php, .inc]
+  - name: php5
+    extensions: [.php5, .php]
+`,
+			wantErr: "the same extension is defined by multiple file types:\n\t.php: php, php5",
+		},
+		{
+			name: "literal name matched by the names of a file type with a different comment style",
+			yml: `header: "// Header"
+file-types:
+  - name: groovy
+    names: [Jenkinsfile]
+  - name: make
+    names: ['.*file']
+    comment-style: hash
+`,
+			wantErr: `file types groovy and make have different comment styles and both match files named "Jenkinsfile" by name`,
+		},
+		{
+			name: "literal name matched by the names of a file type with the same comment style",
+			yml: `header: "// Header"
+file-types:
+  - name: groovy
+    names: [Jenkinsfile]
+    comment-style: hash
+  - name: make
+    names: ['.*file']
+    comment-style: hash
+`,
+		},
+		{
+			name: "literal name matched by the names of a file type with a different comment style and its extension",
+			yml: `header: "// Header"
+file-types:
+  - name: groovy
+    names: [build\.groovy]
+  - name: script
+    names: ['build\..*']
+    extensions: [.groovy]
+    comment-style: hash
+`,
+		},
+		{
+			name: "extension without leading dot",
+			yml: `file-types:
+  - name: php
+    extensions: [php]
+`,
+			wantErr: `extension "php" for file type php must start with "."`,
+		},
+		{
+			name: "duplicate custom header paths",
+			yml: `custom-headers:
+  - name: foo
+    paths: [foo]
+  - name: bar
+    paths: [foo]
+`,
+			wantErr: "the same path is defined by multiple custom header entries:\n\tfoo: foo, bar",
+		},
+		{
+			name: "header pattern",
+			yml: `header: "// Copyright {{YEAR}} Palantir Technologies, Inc."
+header-pattern: '// Copyright {{YEAR}} Palantir Technologies(,)? Inc\.?'
+`,
+		},
+		{
+			name: "header pattern without header",
+			yml: `header-pattern: '// Copyright'
+`,
+			wantErr: "header must be specified when header-pattern is specified",
+		},
+		{
+			name: "invalid header pattern",
+			yml: `header: "// Header"
+header-pattern: '('
+`,
+			wantErr: "invalid header pattern: error parsing regexp: missing closing ): `\\A(?:()\\n`",
+		},
+		{
+			name: "accepted headers",
+			yml: `header: "// Copyright {{YEAR}} Palantir Technologies, Inc."
+accepted-headers:
+  - "// Copyright {{YEAR}} Palantir Technologies"
+`,
+		},
+		{
+			name: "accepted headers without header",
+			yml: `accepted-headers:
+  - "// Copyright {{YEAR}} Palantir Technologies"
+`,
+			wantErr: "header must be specified when accepted-headers is specified",
+		},
+		{
+			name: "accepted header with unset environment variable",
+			yml: `header: "// Header"
+expand-env: true
+accepted-headers:
+  - "// ${LICENSE_PLUGIN_TEST_UNSET_VAR}"
+`,
+			wantErr: "failed to expand accepted header 0: environment variable(s) referenced in header are not set: [LICENSE_PLUGIN_TEST_UNSET_VAR]",
+		},
+		{
+			name: "skip files over",
+			yml: `header: "// Header"
+skip-files-over: 1MB
+`,
+		},
+		{
+			name: "invalid skip files over",
+			yml: `header: "// Header"
+skip-files-over: 1TB
+`,
+			wantErr: `invalid skip-files-over: invalid size "1TB": must be a non-negative integer optionally followed by B, KB, MB or GB`,
+		},
+		{
+			name: "invalid header end",
+			yml: `header: "// Header"
+header-end: '('
+`,
+			wantErr: "invalid header-end regular expression: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "invalid third-party marker",
+			yml: `header: "// Header"
+third-party-marker: '('
+`,
+			wantErr: "invalid third-party-marker regular expression: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "valid foreign licenses",
+			yml: `header: "// Header"
+foreign-licenses:
+  - name: MIT
+    signature: 'Permission is hereby granted, free of charge'
+`,
+		},
+		{
+			name: "foreign license without signature",
+			yml: `foreign-licenses:
+  - name: MIT
+`,
+			wantErr: "foreign license MIT must specify a signature",
+		},
+		{
+			name: "foreign license with invalid signature",
+			yml: `foreign-licenses:
+  - name: MIT
+    signature: '('
+`,
+			wantErr: "invalid signature regular expression for foreign license MIT: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "duplicate foreign licenses",
+			yml: `foreign-licenses:
+  - name: MIT
+    signature: 'MIT'
+  - name: MIT
+    signature: 'Permission is hereby granted'
+`,
+			wantErr: "foreign license(s) defined multiple times: [MIT]",
+		},
+		{
+			name: "file type with negative blank lines after header",
+			yml: `file-types:
+  - name: sh
+    extensions: [.sh]
+    blank-lines-after-header: -1
+`,
+			wantErr: "blank-lines-after-header for file type sh cannot be negative: -1",
+		},
+		{
+			name: "file type with encoding",
+			yml: `header: "# Copyright M\u00fcller"
+file-types:
+  - name: properties
+    extensions: [.properties]
+    encoding: ISO-8859-1
+`,
+		},
+		{
+			name: "file type with unknown encoding",
+			yml: `file-types:
+  - name: properties
+    extensions: [.properties]
+    encoding: shift-jis
+`,
+			wantErr: `invalid encoding for file type properties: unknown encoding "shift-jis": must be one of [iso-8859-1 latin-1]`,
+		},
+		{
+			name: "header that cannot be encoded",
+			yml: `header: "# Copyright \u2014 Acme"
+file-types:
+  - name: properties
+    extensions: [.properties]
+    encoding: latin-1
+`,
+			wantErr: "headers must only contain characters that can be represented in encoding latin-1 of file type properties",
+		},
+		{
+			name: "valid test header",
+			yml: `header: "// Header"
+separate-test-header: true
+test-header: "// Test header"
+`,
+		},
+		{
+			name: "separate test header without test header",
+			yml: `header: "// Header"
+separate-test-header: true
+`,
+			wantErr: "test-header must be specified when separate-test-header is true",
+		},
+		{
+			name: "keep generated notice without header end",
+			yml: `header: "// Header"
+keep-generated-notice: true
+`,
+			wantErr: "header-end must be specified when keep-generated-notice is true",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		_, err := cfg.ToParam()
+		if tc.wantErr == "" {
+			assert.NoError(t, err, "Case %d: %s", i, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
+func TestProjectConfigToParamExpandEnv(t *testing.T) {
+	t.Setenv("LICENSE_TEST_HOLDER", "Acme Inc")
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "environment variables are expanded if expand-env is true",
+			yml: `header: "// Copyright ${LICENSE_TEST_HOLDER} costs $5"
+expand-env: true
+`,
+			want: "// Copyright Acme Inc costs $5\npackage foo\n",
+		},
+		{
+			name: "environment variables are not expanded if expand-env is false",
+			yml: `header: "// Copyright ${LICENSE_TEST_HOLDER}"
+`,
+			want: "// Copyright ${LICENSE_TEST_HOLDER}\npackage foo\n",
+		},
+		{
+			name: "unset environment variables are an error",
+			yml: `header: "// Copyright ${LICENSE_TEST_UNSET}"
+expand-env: true
+`,
+			wantErr: "failed to expand header: environment variable(s) referenced in header are not set: [LICENSE_TEST_UNSET]",
+		},
+		{
+			name: "environment variables in custom headers are expanded",
+			yml: `expand-env: true
+custom-headers:
+  - name: foo
+    header: "// ${LICENSE_TEST_UNSET}"
+    paths: [foo]
+`,
+			wantErr: "failed to expand header for custom header foo: environment variable(s) referenced in header are not set: [LICENSE_TEST_UNSET]",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, param.Licenser.Add("package foo\n"), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamHeaderTemplate(t *testing.T) {
+	t.Setenv("LICENSE_TEST_PROJECT", "widgets")
+
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		content   string
+		wantMatch bool
+		wantErr   string
+	}{
+		{
+			name: "placeholders are preserved",
+			yml: `header: "// Copyright {{YEAR}} {{HOLDER}}"
+copyright-holders: [Acme Inc, Foo LLC]
+header-template: true
+`,
+			content:   "// Copyright 2016 Acme Inc\n// Copyright 2018 Foo LLC\npackage foo\n",
+			wantMatch: true,
+		},
+		{
+			name: "holders are rendered by a loop",
+			yml: `header: |-
+  {{range $i, $holder := .Holders}}{{if $i}}
+  {{end}}// Copyright {{$.Year}} {{$holder}}{{end}}
+copyright-holders: [Acme Inc, Foo LLC]
+header-template: true
+`,
+			content:   "// Copyright 2016 Acme Inc\n// Copyright 2018 Foo LLC\npackage foo\n",
+			wantMatch: true,
+		},
+		{
+			name: "conditionals and environment variables are rendered",
+			yml: `header: "// Copyright {{.Year}} Acme Inc{{if .Env.LICENSE_TEST_PROJECT}} ({{.Env.LICENSE_TEST_PROJECT}}){{end}}"
+header-template: true
+`,
+			content:   "// Copyright 2016 Acme Inc (widgets)\npackage foo\n",
+			wantMatch: true,
+		},
+		{
+			name: "headers are not executed as templates by default",
+			yml: `header: "// Copyright {{YEAR}} {{.Env.LICENSE_TEST_PROJECT}}"
+`,
+			content:   "// Copyright 2016 {{.Env.LICENSE_TEST_PROJECT}}\npackage foo\n",
+			wantMatch: true,
+		},
+		{
+			name: "unset environment variables are an error",
+			yml: `header: "// Copyright {{.Env.LICENSE_TEST_UNSET}}"
+header-template: true
+`,
+			wantErr: `invalid header: failed to execute header template: template: header:1:19: executing "header" at <.Env.LICENSE_TEST_UNSET>: map has no entry for key "LICENSE_TEST_UNSET"`,
+		},
+		{
+			name: "invalid custom header templates are an error",
+			yml: `header-template: true
+custom-headers:
+  - name: foo
+    header: "// Copyright {{if .Year}}"
+    paths: [foo]
+`,
+			wantErr: "invalid header for custom header foo: failed to parse header template: template: header:1: unexpected EOF",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantMatch, param.Licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamPackageDoc(t *testing.T) {
+	for i, tc := range []struct {
+		name     string
+		yml      string
+		wantFile string
+		wantErr  string
+	}{
+		{
+			name: "package doc header",
+			yml: `header: "// Copyright Acme Inc"
+package-doc-header: "// Copyright Acme Inc\n\n// Package documentation follows."
+`,
+		},
+		{
+			name: "package doc header and file",
+			yml: `header: "// Copyright Acme Inc"
+package-doc-header: "// Copyright Acme Inc\n\n// Package documentation follows."
+package-doc-file: package.go
+`,
+			wantFile: "package.go",
+		},
+		{
+			name: "package doc file without header",
+			yml: `header: "// Copyright Acme Inc"
+package-doc-file: package.go
+`,
+			wantErr: "package-doc-header must be specified when package-doc-file is specified",
+		},
+		{
+			name: "package doc file is a test file",
+			yml: `package-doc-header: "// Copyright Acme Inc"
+package-doc-file: doc_test.go
+`,
+			wantErr: `package-doc-file must be the name of a Go file that is not a test file: "doc_test.go"`,
+		},
+		{
+			name: "package doc file is a path",
+			yml: `package-doc-header: "// Copyright Acme Inc"
+package-doc-file: foo/doc.go
+`,
+			wantErr: `package-doc-file must be the name of a Go file that is not a test file: "foo/doc.go"`,
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, "// Copyright Acme Inc\n\n// Package documentation follows.\npackage foo\n", param.PackageDocLicenser.Add("package foo\n"), "Case %d: %s", i, tc.name)
+		assert.Equal(t, "// Copyright Acme Inc\npackage foo\n", param.Licenser.Add("package foo\n"), "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantFile, param.PackageDocFile, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamFullLicense(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: |
+  // Copyright 2024 Acme Inc
+  //
+  // {{LICENSE}}
+full-license: ISC
+`), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+
+	wantHeader := `// Copyright 2024 Acme Inc
+//
+// Permission to use, copy, modify, and/or distribute this software for any
+// purpose with or without fee is hereby granted, provided that the above
+// copyright notice and this permission notice appear in all copies.
+//
+// THE SOFTWARE IS PROVIDED "AS IS" AND THE AUTHOR DISCLAIMS ALL WARRANTIES
+// WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF
+// MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL THE AUTHOR BE LIABLE FOR
+// ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES
+// WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN
+// ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF
+// OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.
+`
+	licensed := param.Licenser.Add("package foo\n")
+	assert.Equal(t, wantHeader+"\npackage foo\n", licensed)
+	assert.True(t, param.Licenser.Matches(licensed))
+	assert.Equal(t, "package foo\n", param.Licenser.Remove(licensed))
+
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: |
+  // {{LICENSE}}
+full-license: CC0-1.0
+`), &cfg))
+	param, err = cfg.ToParam()
+	require.NoError(t, err)
+	licensed = param.Licenser.Add("package foo\n")
+	assert.True(t, strings.HasPrefix(licensed, "// Creative Commons Legal Code\n//\n// CC0 1.0 Universal\n"))
+	assert.Contains(t, licensed, "// Statement of Purpose\n")
+	assert.True(t, param.Licenser.Matches(licensed))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "unknown license",
+			yml: `header: "// {{LICENSE}}"
+full-license: Foo-1.0
+`,
+			wantErr: `invalid full-license: unknown SPDX license identifier "Foo-1.0": must be one of [Apache-2.0 BSD-2-Clause BSD-3-Clause CC0-1.0 GPL-3.0-or-later ISC MIT MPL-2.0 Unlicense]`,
+		},
+		{
+			name: "license without full text",
+			yml: `header: "// {{LICENSE}}"
+full-license: MPL-2.0
+`,
+			wantErr: "full text of license MPL-2.0 is not available",
+		},
+		{
+			name: "header without placeholder",
+			yml: `header: "// Copyright"
+full-license: MIT
+`,
+			wantErr: "header must contain the {{LICENSE}} placeholder when full-license is specified",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamCustomHeaderSPDX(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: "// Copyright {{YEAR}} {{HOLDER}}"
+copyright-holders:
+  - Acme Inc
+custom-headers:
+  - name: bsd
+    spdx: BSD-3-Clause
+    paths:
+      - bsd
+`), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+	require.Len(t, param.CustomHeaders, 1)
+
+	licenser := param.CustomHeaders[0].Licenser
+	content := `// Copyright (c) 2019 Acme Inc
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package foo
+`
+	assert.True(t, licenser.Matches(content))
+	assert.Equal(t, "package foo\n", licenser.Remove(content))
+	assert.True(t, licenser.Matches(licenser.Add("package foo\n")))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "unknown license",
+			yml: `header: "// Copyright"
+custom-headers:
+  - name: foo
+    spdx: Foo-1.0
+    paths:
+      - foo
+`,
+			wantErr: `invalid spdx for custom header foo: unknown SPDX license identifier "Foo-1.0": must be one of [Apache-2.0 BSD-2-Clause BSD-3-Clause CC0-1.0 GPL-3.0-or-later ISC MIT MPL-2.0 Unlicense]`,
+		},
+		{
+			name: "header and spdx",
+			yml: `header: "// Copyright"
+custom-headers:
+  - name: foo
+    header: "// Foo"
+    spdx: Unlicense
+    paths:
+      - foo
+`,
+			wantErr: "header and spdx cannot both be specified for custom header foo",
+		},
+		{
+			name: "license with holder and no copyright holders",
+			yml: `header: "// Copyright"
+custom-headers:
+  - name: foo
+    spdx: MIT
+    paths:
+      - foo
+`,
+			wantErr: "invalid spdx for custom header foo: copyright-holders must be specified because the header of license MIT has a copyright holder",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamYearFormat(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		yml       string
+		content   string
+		wantMatch bool
+		wantErr   string
+	}{
+		{
+			name: "year is rendered using year format",
+			yml: `header: "// Copyright {{YEAR}} Acme Inc"
+year-format: "(c) {{YEAR}}"
+`,
+			content:   "// Copyright (c) 2016 Acme Inc\npackage foo\n",
+			wantMatch: true,
+		},
+		{
+			name: "content without year format does not match",
+			yml: `header: "// Copyright {{YEAR}} Acme Inc"
+year-format: "(c) {{YEAR}}"
+`,
+			content: "// Copyright 2016 Acme Inc\npackage foo\n",
+		},
+		{
+			name: "year format is applied to custom headers",
+			yml: `year-format: "© {{YEAR}}"
+custom-headers:
+  - name: foo
+    header: "// Copyright {{YEAR}} Foo"
+    paths: [foo]
+`,
+			content:   "// Copyright © 2016 Foo\npackage foo\n",
+			wantMatch: true,
+		},
+		{
+			name: "year format without year placeholder",
+			yml: `header: "// Copyright {{YEAR}} Acme Inc"
+year-format: "(c)"
+`,
+			wantErr: `year-format must contain {{YEAR}} exactly once: "(c)"`,
+		},
+		{
+			name: "year format with multiple year placeholders",
+			yml: `header: "// Copyright {{YEAR}} Acme Inc"
+year-format: "{{YEAR}}-{{YEAR}}"
+`,
+			wantErr: `year-format must contain {{YEAR}} exactly once: "{{YEAR}}-{{YEAR}}"`,
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		licenser := param.Licenser
+		if len(param.CustomHeaders) > 0 {
+			licenser = param.CustomHeaders[0].Licenser
+		}
+		assert.Equal(t, tc.wantMatch, licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamWrapWidth(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: |-
+  // Copyright {{YEAR}} Acme Inc
+  //
+  // Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except in compliance with the License.
+custom-headers:
+  - name: sub
+    header: |-
+      /*
+       * Use of this source code is governed by a BSD-style license that can be found in the LICENSE file.
+       */
+    paths:
+      - sub
+wrap-width: 40
+`), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+
+	content := `// Copyright 2019 Acme Inc
+//
+// Licensed under the Apache License,
+// Version 2.0 (the "License"); you may not
+// use this file except in compliance with
+// the License.
+package foo
+`
+	assert.True(t, param.Licenser.Matches(content))
+	assert.Equal(t, "package foo\n", param.Licenser.Remove(content))
+
+	wantCustomHeader := `/*
+ * Use of this source code is governed by a
+ * BSD-style license that can be found in
+ * the LICENSE file.
+ */`
+	require.Len(t, param.CustomHeaders, 1)
+	assert.Equal(t, wantCustomHeader+"\npackage foo\n", param.CustomHeaders[0].Licenser.Add("package foo\n"))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "negative width",
+			yml: `header: "// Copyright"
+wrap-width: -1
+`,
+			wantErr: "wrap-width cannot be negative: -1",
+		},
+		{
+			name: "header that is not a comment",
+			yml: `header: "Copyright"
+wrap-width: 80
+`,
+			wantErr: "failed to expand header: header must be a comment in one of the comment styles [block dash gotmpl hash javadoc semicolon slash xml] when wrap-width is specified",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamTrailingWhitespace(t *testing.T) {
+	for i, tc := range []struct {
+		name        string
+		yml         string
+		content     string
+		wantMatch   bool
+		wantApplied string
+	}{
+		{
+			name: "trailing whitespace is removed from header",
+			yml: `header: "// Copyright 2016 Acme Inc  \n// \n// All rights reserved.\t"
+`,
+			content:     "// Copyright 2016 Acme Inc\n//\n// All rights reserved.\npackage foo\n",
+			wantMatch:   true,
+			wantApplied: "// Copyright 2016 Acme Inc\n//\n// All rights reserved.\npackage foo\n",
+		},
+		{
+			name: "header with trailing whitespace is replaced",
+			yml: `header: "// Copyright 2016 Acme Inc\n//\n// All rights reserved."
+`,
+			content:     "// Copyright 2016 Acme Inc \n// \n// All rights reserved.\npackage foo\n",
+			wantApplied: "// Copyright 2016 Acme Inc\n//\n// All rights reserved.\npackage foo\n",
+		},
+		{
+			name: "trailing whitespace is kept",
+			yml: `header: "// Copyright 2016 Acme Inc \n// \n// All rights reserved."
+keep-trailing-whitespace: true
+`,
+			content:     "// Copyright 2016 Acme Inc \n// \n// All rights reserved.\npackage foo\n",
+			wantMatch:   true,
+			wantApplied: "// Copyright 2016 Acme Inc \n// \n// All rights reserved.\npackage foo\n",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantMatch, param.Licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		applied := tc.content
+		if !tc.wantMatch {
+			applied = param.Licenser.Add(tc.content)
+		}
+		assert.Equal(t, tc.wantApplied, applied, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamParagraphSpacing(t *testing.T) {
+	for i, tc := range []struct {
+		name        string
+		yml         string
+		content     string
+		wantMatch   bool
+		wantApplied string
+	}{
+		{
+			name: "header with paragraphs separated by more blank lines is replaced",
+			yml: `header: "// Copyright 2016 Acme Inc\n//\n// All rights reserved.\n//\n// Licensed under Apache."
+normalize-paragraph-spacing: true
+`,
+			content:     "// Copyright 2016 Acme Inc\n//\n//\n// All rights reserved.\n//\n// Licensed under Apache.\npackage foo\n",
+			wantApplied: "// Copyright 2016 Acme Inc\n//\n// All rights reserved.\n//\n// Licensed under Apache.\npackage foo\n",
+		},
+		{
+			name: "header with the configured paragraph spacing matches",
+			yml: `header: "// Copyright 2016 Acme Inc\n//\n//\n// All rights reserved."
+normalize-paragraph-spacing: true
+`,
+			content:     "// Copyright 2016 Acme Inc\n//\n//\n// All rights reserved.\npackage foo\n",
+			wantMatch:   true,
+			wantApplied: "// Copyright 2016 Acme Inc\n//\n//\n// All rights reserved.\npackage foo\n",
+		},
+		{
+			name: "header with fewer blank lines in a block comment is replaced",
+			yml: `header: "/*\n * Copyright 2016 Acme Inc\n *\n *\n * All rights reserved.\n */"
+normalize-paragraph-spacing: true
+`,
+			content:     "/*\n * Copyright 2016 Acme Inc\n *\n * All rights reserved.\n */\npackage foo\n",
+			wantApplied: "/*\n * Copyright 2016 Acme Inc\n *\n *\n * All rights reserved.\n */\npackage foo\n",
+		},
+		{
+			name: "header with different paragraph spacing is not replaced by default",
+			yml: `header: "// Copyright 2016 Acme Inc\n//\n// All rights reserved."
+`,
+			content:     "// Copyright 2016 Acme Inc\n//\n//\n// All rights reserved.\npackage foo\n",
+			wantApplied: "// Copyright 2016 Acme Inc\n//\n// All rights reserved.\n// Copyright 2016 Acme Inc\n//\n//\n// All rights reserved.\npackage foo\n",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantMatch, param.Licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		applied := tc.content
+		if !tc.wantMatch {
+			applied = param.Licenser.Add(tc.content)
+		}
+		assert.Equal(t, tc.wantApplied, applied, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamReplaceCopyrightLine(t *testing.T) {
+	const content = "// Copyright 2015 Other Tool\npackage foo\n"
+	for i, tc := range []struct {
+		name        string
+		yml         string
+		wantApplied string
+	}{
+		{
+			name: "copyright line is kept",
+			yml: `header: "// Copyright 2016 Acme Inc\n// All rights reserved."
+`,
+			wantApplied: "// Copyright 2016 Acme Inc\n// All rights reserved.\n" + content,
+		},
+		{
+			name: "copyright line is replaced",
+			yml: `header: "// Copyright 2016 Acme Inc\n// All rights reserved."
+replace-copyright-line: true
+`,
+			wantApplied: "// Copyright 2016 Acme Inc\n// All rights reserved.\npackage foo\n",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.False(t, param.Licenser.Matches(content), "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantApplied, param.Licenser.Add(content), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamModule(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: "// Copyright 2016 Acme Inc ({{MODULE}})"
+custom-headers:
+  - name: sub
+    header: "// Sub {{MODULE}}"
+    paths: [sub]
+`), &cfg))
+
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+	require.NotNil(t, param.ForModule)
+
+	moduleParam, err := param.ForModule("example.com/foo")
+	require.NoError(t, err)
+	assert.Nil(t, moduleParam.ForModule)
+	assert.Equal(t, "// Copyright 2016 Acme Inc (example.com/foo)\npackage foo\n", moduleParam.Licenser.Add("package foo\n"))
+	require.Len(t, moduleParam.CustomHeaders, 1)
+	assert.Equal(t, "// Sub example.com/foo\npackage foo\n", moduleParam.CustomHeaders[0].Licenser.Add("package foo\n"))
+
+	cfg = config.ProjectConfig{Header: "// Copyright 2016 Acme Inc"}
+	param, err = cfg.ToParam()
+	require.NoError(t, err)
+	assert.Nil(t, param.ForModule)
+}
+
+func TestProjectConfigToParamCopyrightHolders(t *testing.T) {
+	const header = `header: |
+  // Copyright {{YEAR}} {{HOLDER}}
+  //
+  // Licensed under the MIT License.
+copyright-holders: [Acme Inc, Foo LLC]
+`
+	for i, tc := range []struct {
+		name        string
+		yml         string
+		content     string
+		wantMatch   bool
+		wantRemoved string
+	}{
+		{
+			name:        "holder lines in configured order match",
+			yml:         header,
+			content:     "// Copyright 2016 Acme Inc\n// Copyright 2018 Foo LLC\n//\n// Licensed under the MIT License.\n\npackage foo\n",
+			wantMatch:   true,
+			wantRemoved: "package foo\n",
+		},
+		{
+			name:    "holder lines in different order do not match by default",
+			yml:     header,
+			content: "// Copyright 2018 Foo LLC\n// Copyright 2016 Acme Inc\n//\n// Licensed under the MIT License.\n\npackage foo\n",
+		},
+		{
+			name:        "holder lines in different order match if any order is allowed",
+			yml:         header + "copyright-holders-any-order: true\n",
+			content:     "// Copyright 2018 Foo LLC\n// Copyright 2016 Acme Inc\n//\n// Licensed under the MIT License.\n\npackage foo\n",
+			wantMatch:   true,
+			wantRemoved: "package foo\n",
+		},
+		{
+			name:    "holder lines in different order do not match if holders are reordered",
+			yml:     header + "reorder-copyright-holders: true\n",
+			content: "// Copyright 2018 Foo LLC\n// Copyright 2016 Acme Inc\n//\n// Licensed under the MIT License.\n\npackage foo\n",
+		},
+		{
+			name:    "missing holder line does not match if any order is allowed",
+			yml:     header + "copyright-holders-any-order: true\n",
+			content: "// Copyright 2018 Foo LLC\n// Copyright 2018 Foo LLC\n//\n// Licensed under the MIT License.\n\npackage foo\n",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantMatch, param.Licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		if tc.wantMatch {
+			assert.Equal(t, tc.wantRemoved, param.Licenser.Remove(tc.content), "Case %d: %s", i, tc.name)
+		}
+		assert.Regexp(t, `^// Copyright \d{4} Acme Inc\n// Copyright \d{4} Foo LLC\n//\n`, param.Licenser.Add("package foo\n"), "Case %d: %s", i, tc.name)
+	}
+
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(header+"reorder-copyright-holders: true\n"), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+	assert.Equal(t, "// Copyright 2016 Acme Inc\n// Copyright 2018 Foo LLC\n//\n// Licensed under the MIT License.\n\npackage foo\n",
+		param.Licenser.Add("// Copyright 2018 Foo LLC\n// Copyright 2016 Acme Inc\n//\n// Licensed under the MIT License.\n\npackage foo\n"))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name:    "any order and reordered holders",
+			yml:     header + "copyright-holders-any-order: true\nreorder-copyright-holders: true\n",
+			wantErr: "copyright-holders-any-order and reorder-copyright-holders cannot both be true",
+		},
+		{
+			name: "header without holder placeholder",
+			yml: `header: "// Copyright Acme Inc"
+copyright-holders: [Acme Inc]
+`,
+			wantErr: "header must contain the {{HOLDER}} placeholder on exactly one line when copyright-holders is specified",
+		},
+		{
+			name: "duplicate holders",
+			yml: `header: "// Copyright {{HOLDER}}"
+copyright-holders: [Acme Inc, Foo LLC, Acme Inc]
+`,
+			wantErr: "copyright holder(s) specified multiple times: [Acme Inc]",
+		},
+		{
+			name:    "holder placeholder without holders",
+			yml:     `header: "// Copyright {{HOLDER}}"`,
+			wantErr: "copyright-holders must be specified when a header contains the {{HOLDER}} placeholder",
+		},
+		{
+			name: "custom header holder placeholder without holders",
+			yml: `header: "// Copyright Acme Inc"
+custom-headers:
+  - name: foo
+    header: "// Copyright {{HOLDER}}"
+    paths: [foo]
+`,
+			wantErr: "copyright-holders must be specified when a header contains the {{HOLDER}} placeholder",
+		},
+		{
+			name: "custom header with multiple holder lines",
+			yml: `header: "// Copyright {{HOLDER}}"
+copyright-holders: [Acme Inc]
+custom-headers:
+  - name: foo
+    header: "// Copyright {{HOLDER}}\n// Also {{HOLDER}}"
+    paths: [foo]
+`,
+			wantErr: "header for custom header foo must not contain the {{HOLDER}} placeholder on more than one line",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamCommentStyle(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: |
+  // Copyright 2024 Acme Inc
+  //
+  // Licensed under the MIT License.
+custom-headers:
+  - name: foo
+    header: |
+      /*
+       * Copyright 2024 Foo
+       */
+    paths: [foo]
+file-types:
+  - name: shell
+    extensions: [.sh]
+    comment-style: hash
+`), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+
+	require.Len(t, param.FileTypes, 1)
+	assert.Equal(t, "# Copyright 2024 Acme Inc\n#\n# Licensed under the MIT License.\n\necho foo\n", param.FileTypes[0].Licenser.Add("echo foo\n"))
+	assert.Equal(t, "# Copyright 2024 Foo\n\necho foo\n", param.FileTypes[0].CustomHeaderLicensers["foo"].Add("echo foo\n"))
+
+	// header with the correct text in a different comment style does not match and is replaced when the header is added
+	slashStyled := "// Copyright 2024 Acme Inc\n//\n// Licensed under the MIT License.\n\necho foo\n"
+	assert.False(t, param.FileTypes[0].Licenser.Matches(slashStyled))
+	assert.Equal(t, "# Copyright 2024 Acme Inc\n#\n# Licensed under the MIT License.\n\necho foo\n", param.FileTypes[0].Licenser.Add(slashStyled))
+	blockStyled := "/*\n * Copyright 2024 Foo\n */\n\necho foo\n"
+	assert.False(t, param.FileTypes[0].CustomHeaderLicensers["foo"].Matches(blockStyled))
+	assert.Equal(t, "# Copyright 2024 Foo\n\necho foo\n", param.FileTypes[0].CustomHeaderLicensers["foo"].Add(blockStyled))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "unknown comment style",
+			yml: `file-types:
+  - name: shell
+    extensions: [.sh]
+    comment-style: unknown
+`,
+			wantErr: `invalid comment-style for file type shell: unknown comment style "unknown": must be one of [block dash gotmpl hash javadoc semicolon slash xml]`,
+		},
+		{
+			name: "header that is not a comment",
+			yml: `header: "Copyright Acme Inc"
+file-types:
+  - name: shell
+    extensions: [.sh]
+    comment-style: hash
+`,
+			wantErr: "failed to render header in comment style hash for file type shell: header is not a comment in any of the comment styles [block dash gotmpl hash javadoc semicolon slash xml]",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamDefinedCommentStyle(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: |
+  // Copyright 2024 Acme Inc
+  //
+  // Licensed under the MIT License.
+custom-headers:
+  - name: foo
+    header: |
+      // Copyright 2024 Foo
+    comment-style: closed-block
+    paths: [foo]
+comment-styles:
+  - name: inner-doc
+    first-line-prefix: "//! "
+    line-prefix: "// "
+  - name: closed-block
+    first-line-prefix: "/* "
+    line-prefix: " * "
+    last-line-suffix: " */"
+file-types:
+  - name: rust
+    extensions: [.rs]
+    comment-style: inner-doc
+`), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+
+	require.Len(t, param.FileTypes, 1)
+	assert.Equal(t, "//! Copyright 2024 Acme Inc\n//\n// Licensed under the MIT License.\n\nfn main() {}\n", param.FileTypes[0].Licenser.Add("fn main() {}\n"))
+	assert.Equal(t, "/* Copyright 2024 Foo */\n\nfn main() {}\n", param.CustomHeaders[0].Licenser.Add("fn main() {}\n"))
+
+	// the prefix of the first line is verified separately from the prefix of the other lines
+	assert.True(t, param.FileTypes[0].Licenser.Matches("//! Copyright 2024 Acme Inc\n//\n// Licensed under the MIT License.\n\nfn main() {}\n"))
+	slashStyled := "// Copyright 2024 Acme Inc\n//\n// Licensed under the MIT License.\n\nfn main() {}\n"
+	assert.False(t, param.FileTypes[0].Licenser.Matches(slashStyled))
+	assert.Equal(t, "//! Copyright 2024 Acme Inc\n//\n// Licensed under the MIT License.\n\nfn main() {}\n", param.FileTypes[0].Licenser.Add(slashStyled))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "comment style with the name of a registered comment style",
+			yml: `comment-styles:
+  - name: slash
+    line-prefix: "// "
+`,
+			wantErr: "comment style slash is already registered",
+		},
+		{
+			name: "comment style defined more than once",
+			yml: `comment-styles:
+  - name: inner-doc
+    line-prefix: "// "
+  - name: inner-doc
+    line-prefix: "//! "
+`,
+			wantErr: "comment style inner-doc is defined more than once",
+		},
+		{
+			name: "comment style without prefixes or suffixes",
+			yml: `comment-styles:
+  - name: empty
+`,
+			wantErr: "comment style empty must specify at least one of start, end or a line prefix or suffix",
+		},
+		{
+			name: "unknown comment style lists defined comment styles",
+			yml: `comment-styles:
+  - name: inner-doc
+    line-prefix: "// "
+file-types:
+  - name: rust
+    extensions: [.rs]
+    comment-style: unknown
+`,
+			wantErr: `invalid comment-style for file type rust: unknown comment style "unknown": must be one of [block dash gotmpl hash inner-doc javadoc semicolon slash xml]`,
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamFilenames(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: |
+  // Copyright 2024 Acme Inc
+file-types:
+  - name: files
+    names: [".*file"]
+  - name: docker
+    filenames: [Dockerfile, Containerfile]
+    comment-style: auto
+  - name: config
+    extensions: [.yaml, .yml, .json]
+    comment-style: auto
+`), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+
+	require.Len(t, param.FileTypes, 3)
+	for i, tc := range []struct {
+		file string
+		want bool
+	}{
+		{"Dockerfile", true},
+		{"build/Containerfile", true},
+		{"Dockerfile.dev", false},
+		{"MyDockerfile", false},
+	} {
+		assert.Equal(t, tc.want, param.FileTypes[1].Matcher.Match(tc.file), "Case %d: %s", i, tc.file)
+	}
+	assert.Equal(t, "# Copyright 2024 Acme Inc\n\nFROM scratch\n", param.FileTypes[1].Licenser.Add("FROM scratch\n"))
+	assert.Equal(t, "# Copyright 2024 Acme Inc\n\nkey: value\n", param.FileTypes[2].Licenser.Add("key: value\n"))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "filename with path separator",
+			yml: `file-types:
+  - name: docker
+    filenames: [build/Dockerfile]
+`,
+			wantErr: `filename "build/Dockerfile" for file type docker must be a non-empty name without path separators`,
+		},
+		{
+			name: "filename defined by multiple file types",
+			yml: `file-types:
+  - name: docker
+    filenames: [Dockerfile]
+  - name: container
+    filenames: [Dockerfile]
+`,
+			wantErr: "the same filename is defined by multiple file types:\n\tDockerfile: docker, container",
+		},
+		{
+			name: "auto comment style without registered style",
+			yml: `file-types:
+  - name: docs
+    extensions: [.txt]
+    comment-style: auto
+`,
+			wantErr: "invalid comment-style for file type docs: no comment style is registered for the filenames and extensions of the file type",
+		},
+		{
+			name: "auto comment style with different styles",
+			yml: `file-types:
+  - name: mixed
+    filenames: [Dockerfile]
+    extensions: [.sql, .lua]
+    comment-style: auto
+`,
+			wantErr: "invalid comment-style for file type mixed: the filenames and extensions of the file type have different comment styles:\n\tdash: .sql, .lua\n\thash: Dockerfile",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamCustomHeaderCommentStyle(t *testing.T) {
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: |
+  // Copyright 2024 Acme Inc
+custom-headers:
+  - name: native
+    header: |
+      // Copyright 2024 Native
+    comment-style: block
+    paths: [native]
+file-types:
+  - name: c
+    extensions: [.c]
+  - name: shell
+    extensions: [.sh]
+    comment-style: hash
+`), &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+
+	require.Len(t, param.CustomHeaders, 1)
+	licenser := param.CustomHeaders[0].Licenser
+	assert.Equal(t, "/*\n * Copyright 2024 Native\n */\n\nint x;\n", licenser.Add("int x;\n"))
+	// header with the correct text in a different comment style does not match and is replaced when the header is added
+	slashStyled := "// Copyright 2024 Native\n\nint x;\n"
+	assert.False(t, licenser.Matches(slashStyled))
+	assert.Equal(t, "/*\n * Copyright 2024 Native\n */\n\nint x;\n", licenser.Add(slashStyled))
+
+	// comment style of a file type takes precedence
+	require.Len(t, param.FileTypes, 2)
+	assert.Nil(t, param.FileTypes[0].CustomHeaderLicensers)
+	assert.Equal(t, "# Copyright 2024 Native\n\necho foo\n", param.FileTypes[1].CustomHeaderLicensers["native"].Add("echo foo\n"))
+
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		wantErr string
+	}{
+		{
+			name: "unknown comment style",
+			yml: `custom-headers:
+  - name: native
+    header: "// Copyright Native"
+    comment-style: unknown
+    paths: [native]
+`,
+			wantErr: `invalid comment-style for custom header native: unknown comment style "unknown": must be one of [block dash gotmpl hash javadoc semicolon slash xml]`,
+		},
+		{
+			name: "header that is not a comment",
+			yml: `custom-headers:
+  - name: native
+    header: "Copyright Native"
+    comment-style: block
+    paths: [native]
+`,
+			wantErr: "failed to render header for custom header native in comment style block: header is not a comment in any of the comment styles [block dash gotmpl hash javadoc semicolon slash xml]",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+		_, err := cfg.ToParam()
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestProjectConfigToParamCaseInsensitiveExclude(t *testing.T) {
+	for i, tc := range []struct {
+		name string
+		yml  string
+		want map[string]bool
+	}{
+		{
+			name: "excludes are case-sensitive by default",
+			yml: `exclude:
+  names: [vendor]
+  paths: [foo/bar]
+`,
+			want: map[string]bool{
+				"vendor/a.go":  true,
+				"Vendor/a.go":  false,
+				"foo/bar/a.go": true,
+				"Foo/Bar/a.go": false,
+			},
+		},
+		{
+			name: "case-insensitive excludes",
+			yml: `exclude:
+  names: [vendor]
+  paths: [foo/bar]
+case-insensitive-exclude: true
+`,
+			want: map[string]bool{
+				"vendor/a.go":  true,
+				"Vendor/a.go":  true,
+				"foo/bar/a.go": true,
+				"Foo/Bar/a.go": true,
+				"foo/baz/a.go": false,
+			},
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		for path, want := range tc.want {
+			assert.Equal(t, want, param.Exclude.Match(path), "Case %d: %s: %s", i, tc.name, path)
+		}
+	}
+}
+
+func TestProjectConfigToParamInDirCommit(t *testing.T) {
+	projectDir := t.TempDir()
+	gitCmd := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = projectDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	gitCmd("init")
+	gitCmd("commit", "--allow-empty", "-m", "base")
+	commit := gitCmd("rev-parse", "--short", "HEAD")
+
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`header: "// Built from commit {{COMMIT}}"
+`), &cfg))
+	// the commit is determined in the project directory rather than in the working directory
+	param, err := cfg.ToParamInDir(projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, "// Built from commit "+commit+"\npackage foo\n", param.Licenser.Add("package foo\n"))
+}
+
+func TestProjectConfigToParamRequiredGlobs(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		want    map[string]bool
+		wantErr string
+	}{
+		{
+			name: "all files are required by default",
+			yml: `header: "// Copyright 2016 Acme Inc"
+`,
+		},
+		{
+			name: "required globs match paths and their subpaths",
+			yml: `header: "// Copyright 2016 Acme Inc"
+required-globs:
+  - api
+  - internal/*/public.go
+`,
+			want: map[string]bool{
+				"api/a.go":                   true,
+				"api/v1/b.go":                true,
+				"internal/foo/public.go":     true,
+				"internal/foo/private.go":    false,
+				"internal/foo/bar/public.go": false,
+				"main.go":                    false,
+			},
+		},
+		{
+			name: "invalid required glob",
+			yml: `header: "// Copyright 2016 Acme Inc"
+required-globs: ["api/[a-"]
+`,
+			wantErr: `invalid required glob "api/[a-": syntax error in pattern`,
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		param, err := cfg.ToParam()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		if tc.want == nil {
+			assert.Nil(t, param.Required, "Case %d: %s", i, tc.name)
+			continue
+		}
+		for path, want := range tc.want {
+			assert.Equal(t, want, param.Required.Match(path), "Case %d: %s: %s", i, tc.name, path)
+		}
+	}
+}
+
+func TestProjectConfigResolveExcludePaths(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		cfgFile string
+		want    map[string]bool
+		wantErr string
+	}{
+		{
+			name: "paths are relative to the project directory by default",
+			yml: `exclude:
+  paths: [generated]
+`,
+			cfgFile: filepath.Join("project", "sub", "license-plugin.yml"),
+			want: map[string]bool{
+				"generated/a.go":     true,
+				"sub/generated/a.go": false,
+			},
+		},
+		{
+			name: "paths are relative to the directory of the configuration file",
+			yml: `exclude:
+  names: [vendor]
+  paths: [generated]
+exclude-relative-to-config: true
+`,
+			cfgFile: filepath.Join("project", "sub", "license-plugin.yml"),
+			want: map[string]bool{
+				"generated/a.go":     false,
+				"sub/generated/a.go": true,
+				"vendor/a.go":        true,
+			},
+		},
+		{
+			name: "configuration file in the project directory",
+			yml: `exclude:
+  paths: [generated]
+exclude-relative-to-config: true
+`,
+			cfgFile: filepath.Join("project", "license-plugin.yml"),
+			want: map[string]bool{
+				"generated/a.go": true,
+			},
+		},
+		{
+			name: "configuration file outside of the project directory",
+			yml: `exclude:
+  paths: [generated]
+exclude-relative-to-config: true
+`,
+			cfgFile: "license-plugin.yml",
+			wantErr: "configuration file license-plugin.yml must be in the project directory when exclude-relative-to-config is true",
+		},
+	} {
+		var cfg config.ProjectConfig
+		require.NoError(t, yaml.UnmarshalStrict([]byte(tc.yml), &cfg), "Case %d: %s", i, tc.name)
+
+		err := cfg.ResolveExcludePaths("project", tc.cfgFile)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		param, err := cfg.ToParam()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		for path, want := range tc.want {
+			assert.Equal(t, want, param.Exclude.Match(path), "Case %d: %s: %s", i, tc.name, path)
+		}
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "godel", "config"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".license-plugin.yml"), []byte("header: // Header\n"), 0644))
+	// directories are not configuration files
+	require.NoError(t, os.Mkdir(filepath.Join(projectDir, ".license-plugin.yaml"), 0755))
+
+	got, err := config.FindConfig(projectDir, config.DefaultSearchPaths)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, ".license-plugin.yml"), got)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "godel", "config", "license-plugin.yml"), []byte("header: // Header\n"), 0644))
+	got, err = config.FindConfig(projectDir, config.DefaultSearchPaths)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, "godel", "config", "license-plugin.yml"), got)
+
+	// search order is overridable
+	got, err = config.FindConfig(projectDir, []string{".license-plugin.yaml", ".license-plugin.yml", "godel/config/license-plugin.yml"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, ".license-plugin.yml"), got)
+
+	got, err = config.FindConfig(projectDir, []string{"missing.yml"})
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	cfg, err := config.LoadConfig(got)
+	require.NoError(t, err)
+	assert.Equal(t, config.ProjectConfig{}, cfg)
+}
+
+func TestRemoveHiddenExcludes(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		exclude matcher.NamesPathsCfg
+		want    matcher.NamesPathsCfg
+	}{
+		{
+			"hidden name patterns are removed",
+			matcher.NamesPathsCfg{
+				Names: []string{`\..+`, "vendor", `\.git`, `.*\.pb\.go`},
+				Paths: []string{"godel", ".github"},
+			},
+			matcher.NamesPathsCfg{
+				Names: []string{"vendor", `\.git`, `.*\.pb\.go`, `\.hg`, `\.svn`, `\.bzr`},
+				Paths: []string{"godel", ".github"},
+			},
+		},
+		{
+			"version control directories excluded by a removed pattern remain excluded",
+			matcher.NamesPathsCfg{
+				Names: []string{`\..+`, "vendor"},
+			},
+			matcher.NamesPathsCfg{
+				Names: []string{"vendor", `\.git`, `\.hg`, `\.svn`, `\.bzr`},
+			},
+		},
+		{
+			"only the version control directories matched by a removed pattern remain excluded",
+			matcher.NamesPathsCfg{
+				Names: []string{`\.[a-g].*`},
+			},
+			matcher.NamesPathsCfg{
+				Names: []string{`\.git`, `\.bzr`},
+			},
+		},
+	} {
+		got := config.RemoveHiddenExcludes(tc.exclude)
+		assert.Equal(t, tc.want, got, "Case %d: %s", i, tc.name)
+
+		excludeMatcher := got.Matcher()
+		assert.True(t, excludeMatcher.Match(".git"), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestStarterConfig(t *testing.T) {
+	license, err := spdx.Lookup("MPL-2.0")
+	require.NoError(t, err)
+
+	got, err := config.StarterConfig(license, "Acme Inc")
+	require.NoError(t, err)
+	assert.Equal(t, `header: |
+  // Copyright (c) {{YEAR}} {{HOLDER}}
+  //
+  // This Source Code Form is subject to the terms of the Mozilla Public
+  // License, v. 2.0. If a copy of the MPL was not distributed with this
+  // file, You can obtain one at https://mozilla.org/MPL/2.0/.
+copyright-holders:
+- Acme Inc
+`, string(got))
+
+	var cfg config.ProjectConfig
+	require.NoError(t, yaml.UnmarshalStrict(got, &cfg))
+	param, err := cfg.ToParam()
+	require.NoError(t, err)
+	assert.True(t, param.Licenser.Matches("// Copyright (c) 2024 Acme Inc\n//\n// This Source Code Form is subject to the terms of the Mozilla Public\n// License, v. 2.0. If a copy of the MPL was not distributed with this\n// file, You can obtain one at https://mozilla.org/MPL/2.0/.\n\npackage foo\n"))
+
+	license, err = spdx.Lookup("Unlicense")
+	require.NoError(t, err)
+	got, err = config.StarterConfig(license, "Acme Inc")
+	require.NoError(t, err)
+	assert.Equal(t, `header: |
+  // This is free and unencumbered software released into the public domain.
+  // For more information, please refer to <https://unlicense.org>
+`, string(got))
+
+	_, err = spdx.Lookup("Foo-1.0")
+	assert.EqualError(t, err, `unknown SPDX license identifier "Foo-1.0": must be one of [Apache-2.0 BSD-2-Clause BSD-3-Clause CC0-1.0 GPL-3.0-or-later ISC MIT MPL-2.0 Unlicense]`)
+}
+
+func TestUpgradeConfig(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		yml     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "v0 configuration with file types is unmodified",
+			yml: `header: "// Header"
+file-types:
+  - name: php
+    names: ['.*\.php']
+`,
+			want: `header: "// Header"
+file-types:
+  - name: php
+    names: ['.*\.php']
+`,
+		},
+		{
+			name: "legacy configuration is upgraded",
+			yml: `legacy-config: true
+header: "// Header"
+`,
+			want: `header: "// Header"
+`,
+		},
+		{
+			name: "unknown fields are rejected",
+			yml: `unknown: true
+`,
+			wantErr: "failed to unmarshal license-plugin v0 configuration: yaml: unmarshal errors:\n  line 1: field unknown not found in type v0.ProjectConfig",
+		},
+	} {
+		got, err := config.UpgradeConfig([]byte(tc.yml))
+		if tc.wantErr == "" {
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.name)
+		} else {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	v0 "github.com/palantir/godel-license-plugin/licenseplugin/config/internal/v0"
+	"github.com/palantir/godel-license-plugin/licenseplugin/spdx"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// StarterConfig returns the YAML of a configuration whose header is the header of the provided license. The copyright
+// year and holder are rendered using the year and holder placeholders so that the header is valid for files created in
+// any year, and the provided holder is the sole copyright holder of the configuration. The holder is ignored if the
+// header of the license does not have a copyright holder. The header uses "//" line comments.
+func StarterConfig(license spdx.License, holder string) ([]byte, error) {
+	header := license.HeaderText(licenseplugin.HolderPlaceholder)
+	var holders []string
+	if strings.Contains(header, licenseplugin.HolderPlaceholder) {
+		holders = []string{holder}
+	}
+	cfgBytes, err := yaml.Marshal(v0.ProjectConfig{
+		Header:           lineComment(header, "//"),
+		CopyrightHolders: holders,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal configuration")
+	}
+	return cfgBytes, nil
+}
+
+// lineComment returns the provided text with every line prefixed by the provided comment marker. Empty lines are
+// prefixed by the marker alone so that they do not have trailing whitespace.
+func lineComment(text, marker string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = marker
+			continue
+		}
+		lines[i] = marker + " " + line
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
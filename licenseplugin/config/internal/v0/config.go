@@ -0,0 +1,375 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package v0
+
+import (
+	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+type ProjectConfig struct {
+	// Header is the expected license header. All applicable files are expected to start with this header followed
+	// by a newline. Any occurrences of the string {{YEAR}} is treated specially: when generating a license, the current
+	// year will be substituted for it, and when verifying a license, any 4-digit string will be considered a match.
+	// Any occurrences of the string {{MODULE}} in this header or the headers of CustomHeaders are replaced with the
+	// path of the Go module that contains the file (as defined by the nearest go.mod file in its directory or a parent
+	// directory), so files that are not in a module cannot be processed. Any occurrences of the string {{COMMIT}} in
+	// the headers are replaced with the abbreviated hash of the HEAD commit of the git repository when a header is
+	// added, and any commit hash is considered a match when a header is verified.
+	Header string `yaml:"header,omitempty"`
+
+	// SeparateTestHeader specifies that Go test files (files whose name ends in "_test.go", which includes all of the
+	// files of external "_test" packages) use TestHeader rather than Header. Custom headers still take precedence for
+	// the paths that they match.
+	SeparateTestHeader bool `yaml:"separate-test-header,omitempty"`
+
+	// TestHeader is the header of Go test files if SeparateTestHeader is true. It is expanded in the same manner as
+	// Header. Must be specified if SeparateTestHeader is true.
+	TestHeader string `yaml:"test-header,omitempty"`
+
+	// PackageDocHeader is the header of the package doc file of each Go package, which is typically Header followed by
+	// a package doc comment. It is expanded in the same manner as Header. The package doc file of a package is the file
+	// named PackageDocFile if the package has one and its first Go file in lexical order otherwise, and the other files
+	// of the package use Header. Custom headers still take precedence for the paths that they match. If empty, all of
+	// the files of a package use Header.
+	PackageDocHeader string `yaml:"package-doc-header,omitempty"`
+
+	// PackageDocFile is the name of the Go file of each package that has PackageDocHeader if the package has a file
+	// with the name. If empty, "doc.go" is used. If specified, PackageDocHeader must also be specified.
+	PackageDocFile string `yaml:"package-doc-file,omitempty"`
+
+	// ThirdPartyMarker is a regular expression that matches the leading content of third-party files (for example,
+	// "Imported from" or the copyright notice of an upstream project). Files whose leading content matches are not
+	// modified or verified, which allows third-party files that are not confined to specific directories to be skipped.
+	ThirdPartyMarker string `yaml:"third-party-marker,omitempty"`
+
+	// HeaderEnd is a regular expression that matches the last line of license headers (for example, a line of
+	// dashes). If specified, removing licenses also removes headers that do not match Header or the headers of
+	// CustomHeaders exactly: the content up to and including the first line that matches is removed, provided that the
+	// line is in the leading block of non-blank lines of the file.
+	HeaderEnd string `yaml:"header-end,omitempty"`
+
+	// KeepGeneratedNotice specifies that removing licenses keeps the generated-code notices (comment lines such as
+	// "// Code generated by stringer; DO NOT EDIT.") that are in the content that is removed up to the line that
+	// matches HeaderEnd, so only the license itself is removed from generated files whose notice is part of the leading
+	// block of comments. If true, HeaderEnd must also be specified.
+	KeepGeneratedNotice bool `yaml:"keep-generated-notice,omitempty"`
+
+	// MergeCopyright specifies that files that do not have the license header but start with a header that has a
+	// copyright line (such as files with a third-party copyright) keep their header: applying licenses adds the
+	// copyright lines of Header to the existing header after its last copyright line rather than adding Header before
+	// it, and verification accepts such files if the existing header has a copyright line for each holder of Header.
+	MergeCopyright bool `yaml:"merge-copyright,omitempty"`
+
+	// KeepTrailingWhitespace specifies that trailing whitespace on the lines of Header and of the headers of
+	// CustomHeaders is kept. By default, it is removed from the headers before they are applied or verified, so headers
+	// with trailing whitespace fail verification and are replaced by apply.
+	KeepTrailingWhitespace bool `yaml:"keep-trailing-whitespace,omitempty"`
+
+	// NormalizeParagraphSpacing specifies that headers that differ from Header (or from the headers of CustomHeaders)
+	// only in the number of blank lines between their paragraphs, such as a header whose paragraphs are separated by
+	// two blank lines rather than one, fail verification and are replaced by apply with the header as configured.
+	NormalizeParagraphSpacing bool `yaml:"normalize-paragraph-spacing,omitempty"`
+
+	// HeaderPattern is a regular expression that matches variations of Header that are also accepted. Content that
+	// starts with a match of the pattern followed by a newline is considered to have the header, so verify accepts it
+	// and apply does not modify it, but apply always adds Header itself. Each {{YEAR}} in the pattern matches any
+	// 4-digit string. Applies to the files that use Header as-is (Go files and file types without a comment style). If
+	// specified, Header must also be specified.
+	HeaderPattern string `yaml:"header-pattern,omitempty"`
+
+	// AcceptedHeaders specifies headers other than Header that verification also accepts (for example, the previous
+	// header of a project that is migrating to a new header). Applying licenses replaces these headers with Header and
+	// removing licenses also removes them. Each header is expanded in the same manner as Header. Applies to the files
+	// that use Header as-is (Go files and file types without a comment style). If specified, Header must also be
+	// specified.
+	AcceptedHeaders []string `yaml:"accepted-headers,omitempty"`
+
+	// ReplaceCopyrightLine specifies that applying licenses replaces a bare copyright line at the start of a file (a
+	// single comment line that mentions a copyright, such as one added by a different tool) with the header rather than
+	// adding the header above it. The rest of the file is kept.
+	ReplaceCopyrightLine bool `yaml:"replace-copyright-line,omitempty"`
+
+	// CustomHeaders specifies the custom header parameters. Custom header parameters can be used to specify that
+	// certain directories or files in the project should use a header that is different from "Header". If multiple
+	// entries match a file, the entry with the longest matching path is used, and if multiple entries match a file
+	// with paths of the same length, the entry that is declared first is used. The same path cannot be specified by
+	// multiple entries.
+	CustomHeaders []CustomHeaderConfig `yaml:"custom-headers,omitempty"`
+
+	// ExpandEnv specifies that ${VAR} references in Header and in the headers of CustomHeaders should be replaced
+	// with the value of the corresponding environment variable. The expansion is performed before the header is
+	// applied or verified. It is an error for a header to reference an environment variable that is not set.
+	ExpandEnv bool `yaml:"expand-env,omitempty"`
+
+	// HeaderTemplate specifies that Header, TestHeader, PackageDocHeader, AcceptedHeaders and the headers of
+	// CustomHeaders are Go text/template templates that are executed before any other expansion, which allows headers
+	// to use conditionals and loops. The data of the templates has the fields Year, Holder, Module, Commit and License,
+	// which render as the corresponding placeholders (so "{{.Year}}" is equivalent to "{{YEAR}}"), Holders, which is
+	// CopyrightHolders, and Env, which maps the names of the environment variables to their values. A header whose
+	// template renders CopyrightHolders itself (for example, using "{{range .Holders}}") does not need to contain the
+	// {{HOLDER}} placeholder. Referencing an environment variable that is not set is an error. The placeholders
+	// {{YEAR}}, {{HOLDER}}, {{MODULE}}, {{COMMIT}} and {{LICENSE}} are also valid actions of the templates that render
+	// as themselves, so headers that only use the placeholders are unchanged by executing them as templates.
+	//
+	// The templates are executed once per project (or once per module for headers that use {{MODULE}}), not once per
+	// file, so the data has no field for the name of the file and a header cannot render text that differs between
+	// the files it is applied to. Use CustomHeaders to apply a different header to the files that match specific
+	// paths.
+	HeaderTemplate bool `yaml:"header-template,omitempty"`
+
+	// FullLicense is the SPDX identifier of the license whose full text replaces the {{LICENSE}} placeholder in
+	// Header and in the headers of CustomHeaders. Each line of the license text is prefixed and suffixed by the
+	// content that surrounds the placeholder on its line, so a line of "// {{LICENSE}}" produces the full license text
+	// commented using "//". If specified, Header must contain the placeholder.
+	FullLicense string `yaml:"full-license,omitempty"`
+
+	// YearFormat specifies how each {{YEAR}} in Header and in the headers of CustomHeaders is rendered. It must
+	// contain {{YEAR}} exactly once, and every {{YEAR}} in the headers is replaced by it before the headers are applied
+	// or verified. For example, a YearFormat of "(c) {{YEAR}}" renders a header of "// Copyright {{YEAR}} Acme" as
+	// "// Copyright (c) 2024 Acme". If empty, {{YEAR}} is rendered as the year alone.
+	YearFormat string `yaml:"year-format,omitempty"`
+
+	// WrapWidth specifies the width to which the text of Header and of the headers of CustomHeaders is reflowed before
+	// the headers are applied or verified. The text of each header is uncommented, every paragraph of the text is
+	// reflowed so that no line is longer than WrapWidth and the text is commented again in the style of the header.
+	// Empty lines are preserved, paragraphs are never merged, and lines that are indented or that start with
+	// "Copyright" are kept as they are. If 0, headers are not reflowed.
+	WrapWidth int `yaml:"wrap-width,omitempty"`
+
+	// CopyrightHolders specifies the copyright holders of the project. If non-empty, Header must contain the
+	// {{HOLDER}} placeholder on exactly one line, and that line is repeated once for each holder (in the order
+	// specified) with the placeholder replaced by the holder. This also applies to the headers of CustomHeaders that
+	// contain the placeholder. It is an error for any header to contain the placeholder if CopyrightHolders is empty.
+	CopyrightHolders []string `yaml:"copyright-holders,omitempty"`
+
+	// CopyrightHoldersAnyOrder specifies that a header matches if its copyright holder lines are in any order. Headers
+	// are always added with the lines in the order of CopyrightHolders.
+	CopyrightHoldersAnyOrder bool `yaml:"copyright-holders-any-order,omitempty"`
+
+	// ReorderCopyrightHolders specifies that verification requires the copyright holder lines of headers to be in the
+	// order of CopyrightHolders and that applying licenses puts the holder lines of headers whose lines are in any other
+	// order into that order (keeping their years) rather than adding a second header. Cannot be true if
+	// CopyrightHoldersAnyOrder is true.
+	ReorderCopyrightHolders bool `yaml:"reorder-copyright-holders,omitempty"`
+
+	// UpdateYear specifies that the years of the header of a file are updated to end with the current year whenever
+	// the file is modified by applying licenses (for example, "2019" becomes "2019-2024"). Headers whose years are
+	// ranges of years are considered to match the header. Verification does not require the years to be current.
+	UpdateYear bool `yaml:"update-year,omitempty"`
+
+	// RequireCurrentYear specifies that verification fails for files whose header years do not end with the current
+	// year and that applying licenses updates such years. Implies the matching behavior of UpdateYear.
+	RequireCurrentYear bool `yaml:"require-current-year,omitempty"`
+
+	// EnsureFinalNewline specifies that files must end with exactly one newline: applying licenses replaces the
+	// newlines at the end of files with a single newline and verification fails for files that do not end with
+	// exactly one newline.
+	EnsureFinalNewline bool `yaml:"ensure-final-newline,omitempty"`
+
+	// PreserveLeadingBlankLines specifies that the blank lines at the start of files are kept below the header when
+	// licenses are applied and that verification accepts blank lines after the header even if it is strict. File
+	// types that specify blank-lines-after-header still require exactly that number of blank lines.
+	PreserveLeadingBlankLines bool `yaml:"preserve-leading-blank-lines,omitempty"`
+
+	// RequirePackageClause specifies that verification also fails for Go files that have the license header but whose
+	// header is not followed by the package clause: the first line after the header that is not blank and is not part
+	// of a comment (such as a package comment or a build constraint) must be a package clause. Only applies to Go files
+	// that are not of a type specified by FileTypes.
+	RequirePackageClause bool `yaml:"require-package-clause,omitempty"`
+
+	// RejectPlaceholders specifies that license headers must not contain template placeholders (such as "{{YEAR}}" or
+	// "{{.Holder}}") that were not expanded, which catches placeholders that are mistyped or not supported: verification
+	// fails for files whose header contains a placeholder and applying licenses fails rather than writing such a header.
+	RejectPlaceholders bool `yaml:"reject-placeholders,omitempty"`
+
+	// RequiredGlobs are the paths or glob patterns (matched in the same manner as the paths of Exclude) of the files
+	// that must have license headers. If specified, verification only verifies the files that match one of them and
+	// ignores all other files. Applying and removing licenses are not affected.
+	RequiredGlobs []string `yaml:"required-globs,omitempty"`
+
+	// SkipFilesOver is the size above which files are not verified (for example, "1MB" to skip large generated files).
+	// The size is a non-negative integer optionally followed by a unit of "B", "KB", "MB" or "GB" (where "KB" is 1024
+	// bytes). Applying and removing licenses still processes such files. If empty, files of any size are verified.
+	SkipFilesOver string `yaml:"skip-files-over,omitempty"`
+
+	// FileTypes specifies the file types other than Go files that should have license headers and how the headers
+	// should be applied to them.
+	FileTypes []FileTypeConfig `yaml:"file-types,omitempty"`
+
+	// CommentStyles defines comment styles in addition to the registered comment styles (such as "slash" and "block")
+	// that can be used as the comment style of file types and custom headers.
+	CommentStyles []CommentStyleConfig `yaml:"comment-styles,omitempty"`
+
+	// ForeignLicenses specifies the signatures of licenses other than the license of the project. Files that do not
+	// have the correct header but that match the signature of a foreign license are reported separately by verify as
+	// having the header of a different license (for example, when migrating a project from one license to another).
+	ForeignLicenses []ForeignLicenseConfig `yaml:"foreign-licenses,omitempty"`
+
+	// PostModifyCommand is the command (the executable followed by its arguments) that is run for each file after the
+	// file is modified by applying or removing licenses (for example, ["goimports", "-w", "{{FILE}}"]). Each {{FILE}}
+	// in the arguments is replaced with the path of the file, and the path is appended to the arguments if none of them
+	// contain {{FILE}}. The files of which the command fails are reported along with the output of the command.
+	PostModifyCommand []string `yaml:"post-modify-command,omitempty"`
+
+	// Exclude matches the files and directories that should be excluded from consideration for verifying or applying
+	// licenses.
+	Exclude matcher.NamesPathsCfg `yaml:"exclude,omitempty"`
+
+	// CaseInsensitiveExclude specifies whether the names and paths of Exclude (and of the excludes of the gödel
+	// configuration) match files and directories regardless of case. If not specified, the excludes are
+	// case-insensitive if the file system of the project directory is case-insensitive (as is typical on macOS and
+	// Windows) and case-sensitive otherwise.
+	CaseInsensitiveExclude *bool `yaml:"case-insensitive-exclude,omitempty"`
+
+	// ExcludeRelativeToConfig specifies that the paths of Exclude are relative to the directory that contains the
+	// configuration file rather than to the project directory, which is useful if the configuration file is in a
+	// subdirectory of the project. The configuration file must be in the project directory or one of its
+	// subdirectories. Does not affect the names of Exclude or the excludes of the gödel configuration.
+	ExcludeRelativeToConfig bool `yaml:"exclude-relative-to-config,omitempty"`
+}
+
+type CustomHeaderConfig struct {
+	// Name is the identifier used to identify this custom license parameter. Must be unique.
+	Name string `yaml:"name,omitempty"`
+
+	// Header is the expected license header. All applicable files are expected to start with this header followed
+	// by a newline. Any occurrences of the string {{YEAR}} is treated specially: when generating a license, the current
+	// year will be substituted for it, and when verifying a license, any 4-digit string will be considered a match.
+	Header string `yaml:"header,omitempty"`
+
+	// SPDX is the SPDX identifier of a license whose header is used as the header of this custom license. The header
+	// is rendered using "//" line comments and its copyright holder is rendered using the {{HOLDER}} placeholder, so
+	// copyright-holders must be specified if the header of the license has a copyright holder. Cannot be specified if
+	// Header is specified.
+	SPDX string `yaml:"spdx,omitempty"`
+
+	// CommentStyle is the name of the comment style in which the header of this custom license is rendered (for
+	// example, "block" for "/* */" block comments). If specified, the text of Header is extracted from the comment it
+	// is written in (which can be any registered comment style) and rendered in this comment style. The comment style
+	// of a file type takes precedence for files of that type.
+	CommentStyle string `yaml:"comment-style,omitempty"`
+
+	// Paths specifies the paths for which this custom license is applicable. If multiple custom parameters match a
+	// file or directory, the parameter with the longest path match is used. If multiple custom parameters match a
+	// file or directory exactly (match length is equal), it is treated as an error.
+	Paths []string `yaml:"paths,omitempty"`
+}
+
+type FileTypeConfig struct {
+	// Name is the identifier used to identify this file type. Must be unique.
+	Name string `yaml:"name,omitempty"`
+
+	// Names specifies the regular expressions that match the names of the files of this file type. Unlike extensions
+	// and file names, the names of different file types may match the same file: if a file is matched only by names,
+	// the file type declared first is used. It is an error for a name that is a literal (such as "Jenkinsfile") to be
+	// matched only by the names of a file type with a different comment style. Other names are not checked against
+	// each other because whether two regular expressions match a common name cannot be determined in general.
+	Names []string `yaml:"names,omitempty"`
+
+	// Extensions specifies the extensions (including the leading ".", for example ".php" or ".go.tmpl") of the files
+	// of this file type. If a file matches multiple file types, the file type that matches the longest extension is
+	// used, a match on an extension takes precedence over a match on a name and the file type declared first is used
+	// if the matches are otherwise equal. An extension cannot be specified by more than one file type.
+	Extensions []string `yaml:"extensions,omitempty"`
+
+	// Filenames specifies the exact names of the files of this file type (for example, "Dockerfile" or "Makefile"),
+	// which is useful for files that do not have an extension. A match on a file name takes precedence over a match on
+	// an extension or a name. A file name cannot be specified by more than one file type.
+	Filenames []string `yaml:"filenames,omitempty"`
+
+	// CommentStyle is the name of the comment style used for the headers of files of this type (for example, "hash"
+	// for "#" line comments or "block" for "/* */" block comments). If specified, the text of Header and of the
+	// headers of CustomHeaders is extracted from the comment they are written in (which can be any registered comment
+	// style) and rendered in this comment style for files of this type. If "auto", the comment style registered for
+	// the file names and extensions of this file type (for example, "hash" for "Dockerfile" and ".yaml") is used, in
+	// which case at least one of them must have a registered comment style and all of those that do must have the same
+	// comment style.
+	CommentStyle string `yaml:"comment-style,omitempty"`
+
+	// FirstLine is a regular expression that matches a line that must remain the first line of files of this type
+	// (for example, "<?php" or "<?xml ...?>"). If the first line of a file matches, the license header is placed
+	// directly after it rather than at the start of the file.
+	FirstLine string `yaml:"first-line,omitempty"`
+
+	// InsertAfter specifies regular expressions that match the lines that must precede the license header of files of
+	// this type (for example, "^#!" for shebang lines or "^# -\*- coding" for encoding pragmas). The license header is
+	// placed directly after the leading lines of a file that each match any of the regular expressions, so verify
+	// expects the header at the same position. Cannot be specified with FirstLine.
+	InsertAfter []string `yaml:"insert-after,omitempty"`
+
+	// ContentPattern is a regular expression that identifies files of this type by their content rather than by their
+	// name (for example, "\A#!.*\b(ba)?sh\b" for shell scripts). If specified, files that would otherwise be
+	// processed as a different file type or as Go files (for example, because of a misleading extension) are processed
+	// as this file type if the start of their content after any license header of this file type matches. If the
+	// content of a file matches multiple file types, the file type declared first is used.
+	ContentPattern string `yaml:"content-pattern,omitempty"`
+
+	// BlankLinesAfterHeader is the number of blank lines that separate the license header from the content of files of
+	// this type. If specified, the header is added followed by exactly this many blank lines (replacing any blank lines
+	// at the start of the content), removing the header also removes the blank lines that follow it and files whose
+	// header is followed by a different number of blank lines fail verification. If not specified, the header is added
+	// directly before the content and any number of blank lines may follow it.
+	BlankLinesAfterHeader *int `yaml:"blank-lines-after-header,omitempty"`
+
+	// Encoding is the character encoding of files of this type (for example, "iso-8859-1" or its alias "latin-1"). If
+	// specified, the content of the files is decoded to UTF-8 before it is compared with the license header and is
+	// encoded again when the header is added or removed, so files whose header differs from the expected header only
+	// in its byte representation do not fail verification. The headers must only contain characters that can be
+	// represented in the encoding. If not specified, files are processed as UTF-8.
+	Encoding string `yaml:"encoding,omitempty"`
+}
+
+type CommentStyleConfig struct {
+	// Name is the name of the comment style, which is used as the comment-style of file types and custom headers.
+	// Must be unique and cannot be the name of a registered comment style.
+	Name string `yaml:"name,omitempty"`
+
+	// Start is the line that precedes the text of the comment (for example, "/*"). If empty, no line precedes the
+	// text.
+	Start string `yaml:"start,omitempty"`
+
+	// FirstLinePrefix is the prefix of the first line of the text (for example, "//! "). If unspecified, LinePrefix
+	// is used.
+	FirstLinePrefix *string `yaml:"first-line-prefix,omitempty"`
+
+	// FirstLineSuffix is the suffix of the first line of the text. If unspecified, LineSuffix is used.
+	FirstLineSuffix *string `yaml:"first-line-suffix,omitempty"`
+
+	// LinePrefix is the prefix of the lines of the text other than the first and the last line (for example, " * ").
+	LinePrefix string `yaml:"line-prefix,omitempty"`
+
+	// LineSuffix is the suffix of the lines of the text other than the first and the last line.
+	LineSuffix string `yaml:"line-suffix,omitempty"`
+
+	// LastLinePrefix is the prefix of the last line of the text. If unspecified, LinePrefix is used.
+	LastLinePrefix *string `yaml:"last-line-prefix,omitempty"`
+
+	// LastLineSuffix is the suffix of the last line of the text (for example, " */"). If unspecified, LineSuffix is
+	// used.
+	LastLineSuffix *string `yaml:"last-line-suffix,omitempty"`
+
+	// End is the line that follows the text of the comment (for example, " */"). If empty, no line follows the text.
+	End string `yaml:"end,omitempty"`
+}
+
+type ForeignLicenseConfig struct {
+	// Name is the name of the license that is used to report the files that have its header (for example, "MIT").
+	// Must be unique.
+	Name string `yaml:"name,omitempty"`
+
+	// Signature is a regular expression that matches the header of the license (for example, "Permission is hereby
+	// granted, free of charge"). It is matched against the leading content of files.
+	Signature string `yaml:"signature,omitempty"`
+}
+
+func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
+	var cfg ProjectConfig
+	if err := yaml.UnmarshalStrict(cfgBytes, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal license-plugin v0 configuration")
+	}
+	return cfgBytes, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-license/golicense"
+)
+
+var (
+	// copyrightLineRegexp matches a line of a license header that is its copyright line.
+	copyrightLineRegexp = regexp.MustCompile(`(?i)copyright`)
+	// textRegexp matches a line that has text other than comment markers.
+	textRegexp = regexp.MustCompile(`[[:alnum:]]`)
+
+	// copyrightlessPatterns caches the result of copyrightlessPattern by the header that a Licenser adds.
+	copyrightlessPatterns sync.Map
+)
+
+var (
+	// lineCommentMarkers are the markers of the line comments that can be bare copyright lines.
+	lineCommentMarkers = []string{"//", "#", "--", ";"}
+	// blockCommentDelimiters are the opening and closing delimiters of the block comments that can be bare copyright
+	// lines.
+	blockCommentDelimiters = [][2]string{{"/*", "*/"}, {"<!--", "-->"}}
+)
+
+// bareCopyrightLineLen returns the length (including the trailing newline) of the bare copyright line that the provided
+// content starts with: a line comment that contains "copyright" and is not followed by another line of the same
+// comment, or a block comment on a single line that contains "copyright". Returns 0 if the content does not start with
+// a bare copyright line.
+func bareCopyrightLineLen(content string) int {
+	lineEnd := strings.IndexByte(content, '\n')
+	if lineEnd == -1 {
+		return 0
+	}
+	line := strings.TrimRight(content[:lineEnd], " \t\r")
+	if !copyrightLineRegexp.MatchString(line) {
+		return 0
+	}
+	for _, marker := range lineCommentMarkers {
+		if strings.HasPrefix(line, marker) {
+			if strings.HasPrefix(content[lineEnd+1:], marker) {
+				return 0
+			}
+			return lineEnd + 1
+		}
+	}
+	for _, delimiters := range blockCommentDelimiters {
+		if strings.HasPrefix(line, delimiters[0]) && strings.HasSuffix(line, delimiters[1]) {
+			return lineEnd + 1
+		}
+	}
+	return 0
+}
+
+// missingCopyright returns true if the provided content, which does not match the provided licenser, starts with the
+// header of the licenser except that the copyright line of the header (its first line that contains "copyright") is
+// missing or does not match, for example because the line was removed or modified when the file was edited. Returns
+// false if the header does not have a copyright line or does not have any other line with text.
+func missingCopyright(content string, licenser golicense.Licenser) bool {
+	if l, ok := licenser.(*leadingLinesLicenser); ok {
+		_, content = l.split(content)
+	}
+	pattern := copyrightlessPattern(licenser)
+	return pattern != nil && pattern.MatchString(content)
+}
+
+// copyrightlessPattern returns a regular expression that matches the start of content that has the header of the
+// provided licenser with any line or no line at all in place of its copyright line. Returns nil if the header does not
+// have a copyright line or does not have any other line with text.
+func copyrightlessPattern(licenser golicense.Licenser) *regexp.Regexp {
+	header := strings.TrimRight(licenser.Add(""), "\n")
+	if pattern, ok := copyrightlessPatterns.Load(header); ok {
+		return pattern.(*regexp.Regexp)
+	}
+
+	lines := strings.Split(header, "\n")
+	copyrightIdx := -1
+	hasText := false
+	for i, line := range lines {
+		if copyrightIdx == -1 && copyrightLineRegexp.MatchString(line) {
+			copyrightIdx = i
+		} else if textRegexp.MatchString(line) {
+			hasText = true
+		}
+	}
+	var pattern *regexp.Regexp
+	if copyrightIdx != -1 && hasText {
+		// header added by the licenser has the current year for every year of the header
+		currentYear := strconv.Itoa(time.Now().Year())
+		parts := make([]string, len(lines))
+		for i, line := range lines {
+			if i == copyrightIdx {
+				parts[i] = `(?:[^\n]*\n)?`
+				continue
+			}
+			yearParts := strings.Split(line, currentYear)
+			for j, yearPart := range yearParts {
+				yearParts[j] = regexp.QuoteMeta(yearPart)
+			}
+			parts[i] = strings.Join(yearParts, `\d\d\d\d(?:-\d\d\d\d)?`) + `\n`
+		}
+		pattern = regexp.MustCompile(`\A` + strings.Join(parts, ""))
+	}
+	copyrightlessPatterns.Store(header, pattern)
+	return pattern
+}
+
+// changeFailures returns a map from the reported paths of the provided changes of files that failed verification to
+// how the files differ from having the correct license header. Files that do not have the header at all are not
+// included.
+func changeFailures(changes []Change, runParam RunParam) map[string]failure {
+	failures := make(map[string]failure)
+	for _, change := range changes {
+		if f := changeFailure(change); f != (failure{}) {
+			failures[displayPath(change.Path, runParam)] = f
+		}
+	}
+	return failures
+}
+
+// changeFailure returns how the file of the provided change differed from having the correct license header before
+// the change.
+func changeFailure(change Change) failure {
+	return failure{
+		foreignLicense:        change.ForeignLicense,
+		missingCopyright:      change.MissingCopyright,
+		wrongDelimiters:       change.WrongDelimiters,
+		missingPackageClause:  change.MissingPackageClause,
+		notInManifest:         change.NotInManifest,
+		unexpandedPlaceholder: change.UnexpandedPlaceholder,
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/godel-license-plugin/licenseplugin/commentstyle"
+)
+
+// redelimit returns the provided content, which does not match the provided licenser, with its leading comment
+// rendered with the comment delimiters of the header of the licenser if the comment is the header with different
+// delimiters (for example, a header that starts with "/**" when the header starts with "/*"). Returns false if the
+// content does not start with such a comment or the header is not a comment in a registered comment style.
+func redelimit(content string, licenser golicense.Licenser) (string, bool) {
+	if licenser.Empty() {
+		return "", false
+	}
+	var leadingLines string
+	if l, ok := licenser.(*leadingLinesLicenser); ok {
+		leadingLines, content = l.split(content)
+	}
+	header := strings.TrimRight(licenser.Add(""), "\n")
+	for _, variant := range commentstyle.Redelimit(content, header) {
+		if licenser.Matches(leadingLines + variant) {
+			return leadingLines + variant, true
+		}
+	}
+	return "", false
+}
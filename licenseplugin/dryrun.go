@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// planActionAdd is the planned action for a file to which the license header would be added.
+	planActionAdd = "add"
+	// planActionUpdate is the planned action for a file whose existing header would be replaced or modified.
+	planActionUpdate = "update"
+	// planActionRemove is the planned action for a file from which the license header would be removed.
+	planActionRemove = "remove"
+	// planActionNone is the planned action for a file that would not be modified.
+	planActionNone = "none"
+)
+
+// planRecord is the JSON object that describes the planned action for a file when the changes of a dry run are written
+// in the OutputJSON or OutputNDJSON format.
+type planRecord struct {
+	// Path is the path to the file.
+	Path string `json:"path"`
+	// Action is the action that would be taken for the file.
+	Action string `json:"action"`
+	// Header is the license header that the file would have after the change. Only set if RunParam.DryRunHeaders is
+	// true and the file would have a header after the change.
+	Header string `json:"header,omitempty"`
+}
+
+// licensedFiles returns the provided files that are Go files or files of a configured file type and are not excluded
+// by the provided parameters.
+func licensedFiles(files []string, projectParam ProjectParam) []string {
+	var out []string
+	for _, file := range files {
+		if _, ok := fileLicenser(file, projectParam); ok {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// planRecords returns the records that describe the planned action for each of the provided files given the provided
+// changes that the operation would make, sorted by path. Files that are not modified by any change have the action
+// planActionNone.
+func planRecords(files []string, changes []Change, runParam RunParam) []planRecord {
+	var records []planRecord
+	for _, change := range changes {
+		record := planRecord{
+			Path:   filepath.ToSlash(displayPath(change.Path, runParam)),
+			Action: planActionUpdate,
+		}
+		switch {
+		case runParam.Remove:
+			record.Action = planActionRemove
+		case !change.HadHeader:
+			record.Action = planActionAdd
+		}
+		if runParam.DryRunHeaders {
+			record.Header = change.Header
+		}
+		records = append(records, record)
+	}
+	for _, file := range unchangedFiles(files, changes) {
+		records = append(records, planRecord{
+			Path:   filepath.ToSlash(displayPath(file, runParam)),
+			Action: planActionNone,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Path < records[j].Path
+	})
+	return records
+}
+
+// writePlan writes the planned action for each of the provided files given the provided changes that a dry run of
+// apply or remove determined. OutputJSON writes a JSON array of the records of all of the files and OutputNDJSON
+// writes the record of each file on its own line. Otherwise, a line that consists of the action and the path is
+// written for each file that would be modified.
+func writePlan(files []string, changes []Change, runParam RunParam, stdout io.Writer) error {
+	records := planRecords(files, changes, runParam)
+	switch runParam.Output {
+	case OutputJSON:
+		if records == nil {
+			records = []planRecord{}
+		}
+		out, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal output")
+		}
+		if _, err := fmt.Fprintln(stdout, string(out)); err != nil {
+			return errors.Wrapf(err, "failed to write output")
+		}
+	case OutputNDJSON:
+		for _, record := range records {
+			out, err := json.Marshal(record)
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal output")
+			}
+			if _, err := fmt.Fprintln(stdout, string(out)); err != nil {
+				return errors.Wrapf(err, "failed to write output")
+			}
+		}
+	default:
+		for _, record := range records {
+			if record.Action == planActionNone {
+				continue
+			}
+			if _, err := fmt.Fprintf(stdout, "%s %s\n", record.Action, record.Path); err != nil {
+				return errors.Wrapf(err, "failed to write output")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/pkg/errors"
+)
+
+// Encoding converts between the content of files in a character encoding other than UTF-8 and UTF-8.
+type Encoding interface {
+	// Decode returns the provided content, which is in the encoding, converted to UTF-8.
+	Decode(content string) string
+
+	// Encode returns the provided UTF-8 content converted to the encoding. Returns false if the content contains
+	// characters that cannot be represented in the encoding.
+	Encode(content string) (string, bool)
+}
+
+// latin1 is the ISO-8859-1 encoding, in which every byte is the code point of the character that it represents.
+type latin1 struct{}
+
+func (latin1) Decode(content string) string {
+	var sb strings.Builder
+	sb.Grow(len(content))
+	for i := 0; i < len(content); i++ {
+		sb.WriteRune(rune(content[i]))
+	}
+	return sb.String()
+}
+
+func (latin1) Encode(content string) (string, bool) {
+	out := make([]byte, 0, len(content))
+	for _, r := range content {
+		if r > 0xff {
+			return "", false
+		}
+		out = append(out, byte(r))
+	}
+	return string(out), true
+}
+
+var encodings = map[string]Encoding{
+	"iso-8859-1": latin1{},
+	"latin-1":    latin1{},
+}
+
+// LookupEncoding returns the Encoding with the provided name. Returns an error if no encoding has the name.
+func LookupEncoding(name string) (Encoding, error) {
+	encoding, ok := encodings[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.Errorf("unknown encoding %q: must be one of %v", name, EncodingNames())
+	}
+	return encoding, nil
+}
+
+// EncodingNames returns the sorted names of the supported encodings.
+func EncodingNames() []string {
+	names := make([]string, 0, len(encodings))
+	for name := range encodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encodingLicenser is a Licenser for content in a character encoding other than UTF-8. The content is converted to
+// UTF-8 before it is compared with the header of the wrapped Licenser, and content to which the header is added or
+// from which it is removed is converted back to the encoding.
+type encodingLicenser struct {
+	golicense.Licenser
+	encoding Encoding
+}
+
+// newEncodingLicenser returns a Licenser that processes content in the provided encoding using the provided Licenser.
+// Returns the provided Licenser if the encoding is nil.
+func newEncodingLicenser(licenser golicense.Licenser, encoding Encoding) golicense.Licenser {
+	if encoding == nil {
+		return licenser
+	}
+	return &encodingLicenser{
+		Licenser: licenser,
+		encoding: encoding,
+	}
+}
+
+func (l *encodingLicenser) Add(content string) string {
+	if encoded, ok := l.encoding.Encode(l.Licenser.Add(l.encoding.Decode(content))); ok {
+		return encoded
+	}
+	// the header cannot be represented in the encoding, so the content is left unmodified
+	return content
+}
+
+func (l *encodingLicenser) Remove(content string) string {
+	// removing the header only removes characters that were decoded from the content, so the rest can be encoded
+	encoded, _ := l.encoding.Encode(l.Licenser.Remove(l.encoding.Decode(content)))
+	return encoded
+}
+
+func (l *encodingLicenser) Matches(content string) bool {
+	return l.Licenser.Matches(l.encoding.Decode(content))
+}
+
+func (l *encodingLicenser) accepts(content string) bool {
+	return accepts(l.encoding.Decode(content), l.Licenser)
+}
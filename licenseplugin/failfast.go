@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"context"
+)
+
+// failFast stops a verify operation at its first failure (see RunParam.FailFast). Its context is cancelled once a
+// failure has been found, which stops the files that have not been processed from being processed. A nil *failFast
+// never stops an operation.
+type failFast struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newFailFast returns the failFast for an operation with the provided parameters. Returns nil if the parameters are
+// not for a verify operation that stops at its first failure.
+func newFailFast(runParam RunParam) *failFast {
+	if !runParam.FailFast || !runParam.Verify {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &failFast{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// context returns the context that is cancelled once a failure has been found.
+func (f *failFast) context() context.Context {
+	if f == nil {
+		return context.Background()
+	}
+	return f.ctx
+}
+
+// stop records that a failure has been found.
+func (f *failFast) stop() {
+	if f == nil {
+		return
+	}
+	f.cancel()
+}
+
+// stopped returns true if a failure has been found.
+func (f *failFast) stopped() bool {
+	return f != nil && f.ctx.Err() != nil
+}
+
+// first returns the first of the provided changes, which are failures, and records that a failure has been found if
+// there are any. Returns all of the changes if f is nil.
+func (f *failFast) first(changes []Change) []Change {
+	if f == nil || len(changes) == 0 {
+		return changes
+	}
+	f.stop()
+	return changes[:1]
+}
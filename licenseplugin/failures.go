@@ -0,0 +1,40 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrVerifyFailed is the error returned when files fail verification. Its message is empty because the files that
+// fail verification have already been written to the output, so callers that print errors print nothing for it.
+var ErrVerifyFailed = fmt.Errorf("")
+
+// ErrFilesChanged is the error returned by apply and remove operations that modified files if RunParam.ErrOnChange is
+// true. It does not indicate a failure (all of the changes have been written), so its message is empty.
+var ErrFilesChanged = fmt.Errorf("")
+
+// writeFailuresFile writes the provided paths to the file at the provided path, one per line, in the manner described
+// for writeLinesFile.
+func writeFailuresFile(path string, failedPaths []string) error {
+	return writeLinesFile(path, "failures file", failedPaths)
+}
+
+// writeLinesFile writes the provided lines to the file at the provided path, one per line. The file is written using
+// writeFileAtomic, so readers never observe a partially written file. The provided description of the file is used in
+// errors.
+func writeLinesFile(path, description string, lines []string) error {
+	var content string
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s %s", description, path)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// fileErrors records the errors that the operating system returns for the paths of individual files (for example,
+// because a path is too long or is a reserved name on Windows) so that an operation can continue with the other files
+// (see RunParam.ContinueOnFileErrors). It is safe for concurrent use. A nil *fileErrors records nothing.
+type fileErrors struct {
+	mu   sync.Mutex
+	errs map[string]error
+}
+
+// newFileErrors returns the fileErrors for an operation with the provided parameters. Returns nil if the parameters do
+// not specify that the operation continues when errors occur for individual files.
+func newFileErrors(runParam RunParam) *fileErrors {
+	if !runParam.ContinueOnFileErrors {
+		return nil
+	}
+	return &fileErrors{
+		errs: make(map[string]error),
+	}
+}
+
+// record records the provided error for the provided file if it is an error that the operating system returned for a
+// path. Returns true if the error was recorded, in which case the operation should continue without the file.
+func (e *fileErrors) record(file string, err error) bool {
+	var pathErr *fs.PathError
+	if e == nil || !errors.As(err, &pathErr) {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs[file] = err
+	return true
+}
+
+// without returns the provided files for which no error was recorded.
+func (e *fileErrors) without(files []string) []string {
+	if e == nil {
+		return files
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.errs) == 0 {
+		return files
+	}
+	var out []string
+	for _, file := range files {
+		if _, ok := e.errs[file]; !ok {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// complete returns the error of an operation that returned the provided error and for which the errors were recorded.
+// If any errors were recorded and the operation otherwise succeeded or only failed because files failed verification
+// or were changed, an error that lists the files and their errors sorted by path is returned. Otherwise, the provided
+// error is returned.
+func (e *fileErrors) complete(err error, runParam RunParam) error {
+	if e == nil || (err != nil && err != ErrVerifyFailed && err != ErrFilesChanged) {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.errs) == 0 {
+		return err
+	}
+	files := make([]string, 0, len(e.errs))
+	for file := range e.errs {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	plural := "files"
+	if len(files) == 1 {
+		plural = "file"
+	}
+	parts := []string{fmt.Sprintf("failed to process %d %s:", len(files), plural)}
+	for _, file := range files {
+		parts = append(parts, fmt.Sprintf("%s: %v", displayPath(file, runParam), e.errs[file]))
+	}
+	return errors.New(strings.Join(parts, "\n\t"))
+}
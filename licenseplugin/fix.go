@@ -0,0 +1,40 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// verifyThenFix runs the operation that the provided function runs for the provided parameters, which must be for
+// verify with FixAfter set, first as verify and then, if any files failed verification, as apply. The apply operation
+// adds headers even if runParam.Remove is true and writes the files that it fixed. Returns the error returned by verify if it fails for any reason other than files
+// failing verification and the error returned by apply otherwise.
+func verifyThenFix(runParam RunParam, run func(runParam RunParam) error) error {
+	verifyParam := runParam
+	verifyParam.FixAfter = false
+	if err := run(verifyParam); err != ErrVerifyFailed {
+		return err
+	}
+	applyParam := runParam
+	applyParam.Verify = false
+	applyParam.Remove = false
+	return run(applyParam)
+}
+
+// printFixed prints the paths of the files of the provided changes, which were written by the apply operation that
+// follows verify if runParam.FixAfter is true.
+func printFixed(changes []Change, runParam RunParam, stdout io.Writer) {
+	if !runParam.FixAfter || len(changes) == 0 {
+		return
+	}
+	plural := "files were"
+	if len(changes) == 1 {
+		plural = "file was"
+	}
+	_, _ = fmt.Fprintf(stdout, "%d %s fixed:\n\t%s\n", len(changes), plural, strings.Join(displayPaths(changes, runParam), "\n\t"))
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+)
+
+// foreignLicenseDetectionWindow is the number of leading bytes of content that are examined to determine whether the
+// content has the header of a foreign license.
+const foreignLicenseDetectionWindow = 4000
+
+// ForeignLicenseParam specifies a license other than the license of the project whose header can be detected in
+// content.
+type ForeignLicenseParam struct {
+	// Name is the name of the license. Must be unique.
+	Name string
+
+	// Signature matches the header of the license.
+	Signature *regexp.Regexp
+}
+
+// foreignLicense returns the name of the first foreign license in the provided parameters whose signature matches
+// the leading content of the provided content. Returns the empty string if no foreign license matches.
+func foreignLicense(content string, projectParam ProjectParam) string {
+	if len(content) > foreignLicenseDetectionWindow {
+		content = content[:foreignLicenseDetectionWindow]
+	}
+	for _, foreign := range projectParam.ForeignLicenses {
+		if foreign.Signature != nil && foreign.Signature.MatchString(content) {
+			return foreign.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// filesAddedSince returns the subset of the provided files, which are relative to the working directory, that were
+// added to the git repository that contains the provided project directory after the provided ref. A file is
+// considered to be added if it does not exist at the ref and either exists in the working tree or is untracked (but
+// not ignored). The order of the provided files is preserved.
+func filesAddedSince(git *gitRunner, files []string, projectDir, ref string) ([]string, error) {
+	if projectDir == "" {
+		projectDir = "."
+	}
+	if _, err := git.run(projectDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, errors.Errorf("invalid git ref %q", ref)
+	}
+
+	// paths of both commands are relative to the project directory
+	outputs, err := git.runAll(projectDir,
+		[]string{"diff", "--name-only", "--relative", "--diff-filter=A", "-z", ref, "--"},
+		[]string{"ls-files", "--others", "--exclude-standard", "-z"},
+	)
+	if err != nil {
+		return nil, err
+	}
+	added, untracked := outputs[0], outputs[1]
+
+	addedPaths := make(map[string]struct{})
+	for _, path := range append(splitNul(added), splitNul(untracked)...) {
+		absPath, err := filepath.Abs(filepath.Join(projectDir, path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine absolute path of %s", path)
+		}
+		addedPaths[absPath] = struct{}{}
+	}
+
+	var out []string
+	for _, file := range files {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine absolute path of %s", file)
+		}
+		if _, ok := addedPaths[absPath]; ok {
+			out = append(out, file)
+		}
+	}
+	return out, nil
+}
+
+// commitYears returns a map from each of the provided files, which are relative to the working directory, to the year
+// (as a 4-digit string) in which the file was last modified in the git repository that contains the provided project
+// directory. Files with uncommitted modifications were last modified in the current year and files that are not
+// tracked by the repository are not in the map.
+func commitYears(git *gitRunner, files []string, projectDir string) (map[string]string, error) {
+	if projectDir == "" {
+		projectDir = "."
+	}
+
+	// paths of both commands are relative to the project directory. The log lists the paths modified by each commit
+	// (newest first) after the year of the commit, which is marked with a leading \x01.
+	outputs, err := git.runAll(projectDir,
+		[]string{"log", "--format=%x01%ad", "--date=format:%Y", "--name-only", "--no-renames", "--relative", "-z"},
+		[]string{"diff", "--name-only", "--relative", "-z", "HEAD", "--"},
+	)
+	if err != nil {
+		return nil, err
+	}
+	log, modified := outputs[0], outputs[1]
+
+	pathYears := make(map[string]string)
+	addPathYear := func(path, year string) error {
+		absPath, err := filepath.Abs(filepath.Join(projectDir, path))
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine absolute path of %s", path)
+		}
+		if _, ok := pathYears[absPath]; !ok {
+			pathYears[absPath] = year
+		}
+		return nil
+	}
+	currentYear := strconv.Itoa(time.Now().Year())
+	for _, path := range splitNul(modified) {
+		if err := addPathYear(path, currentYear); err != nil {
+			return nil, err
+		}
+	}
+	var year string
+	for _, part := range splitNul(log) {
+		if part = strings.TrimPrefix(part, "\n"); strings.HasPrefix(part, "\x01") {
+			year = part[1:]
+		} else if part != "" {
+			if err := addPathYear(part, year); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out := make(map[string]string)
+	for _, file := range files {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine absolute path of %s", file)
+		}
+		if year, ok := pathYears[absPath]; ok {
+			out[file] = year
+		}
+	}
+	return out, nil
+}
+
+// gitRunner runs git commands. The number of git processes that it runs at once is bounded separately from the number
+// of files that are processed at once so that large projects do not spawn an unbounded number of processes. Safe for
+// concurrent use.
+type gitRunner struct {
+	sem chan struct{}
+}
+
+// newGitRunner returns a gitRunner that runs at most the provided number of git processes at once. If parallelism is
+// <= 0, the bound is runtime.GOMAXPROCS(0).
+func newGitRunner(parallelism int) *gitRunner {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	return &gitRunner{
+		sem: make(chan struct{}, parallelism),
+	}
+}
+
+// run runs git with the provided arguments in the provided directory and returns its output once fewer than the
+// maximum number of git processes are running.
+func (g *gitRunner) run(dir string, args ...string) (string, error) {
+	g.sem <- struct{}{}
+	defer func() {
+		<-g.sem
+	}()
+	return runGit(dir, args...)
+}
+
+// runAll runs git with each of the provided argument lists in the provided directory concurrently (subject to the
+// bound of the runner) and returns their outputs in the order of the argument lists. Returns the first error in the
+// order of the argument lists if any command fails.
+func (g *gitRunner) runAll(dir string, argLists ...[]string) ([]string, error) {
+	outputs := make([]string, len(argLists))
+	errs := make([]error, len(argLists))
+	var wg sync.WaitGroup
+	for i, args := range argLists {
+		wg.Add(1)
+		go func(i int, args []string) {
+			defer wg.Done()
+			outputs[i], errs[i] = g.run(dir, args...)
+		}(i, args)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// HeadCommit returns the abbreviated hash of the HEAD commit of the git repository that contains the provided
+// directory.
+func HeadCommit(dir string) (string, error) {
+	output, err := runGit(dir, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine HEAD commit")
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
+
+func splitNul(output string) []string {
+	var out []string
+	for _, part := range strings.Split(output, "\x00") {
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
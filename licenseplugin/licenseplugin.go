@@ -0,0 +1,1022 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
+)
+
+var (
+	goFileMatcher     = matcher.Name(`.*\.go`)
+	goTestFileMatcher = matcher.Name(`.*_test\.go`)
+)
+
+// GoFileType is the name of the file type of Go files that are not of a configured file type.
+const GoFileType = "go"
+
+// BackupSuffix is the suffix appended to the path of a file to form the path of its backup (see RunParam.Backup).
+const BackupSuffix = ".orig"
+
+// ignoreDirectiveRegexp matches a line that consists of a comment whose content is the "license:ignore" directive.
+// Files that contain such a line within their first ignoreDirectiveMaxLines lines are not processed.
+var ignoreDirectiveRegexp = regexp.MustCompile(`^\s*(//|#|/\*|\*|--|;|<!--|\{\{-?\s*/\*)\s*license:ignore\b`)
+
+// generatedNoticeRegexp matches a line that consists of a comment that is a generated-code notice in the form defined
+// by Go ("Code generated <by whom> DO NOT EDIT."), which is also used by the generators of other languages.
+var generatedNoticeRegexp = regexp.MustCompile(`^\s*(//|#|/\*|\*|--|;|<!--)\s*Code generated .*DO NOT EDIT\b`)
+
+const (
+	ignoreDirectiveMaxLines = 10
+
+	// binaryDetectionWindow is the number of leading bytes of content that are examined to determine whether the
+	// content is binary.
+	binaryDetectionWindow = 8000
+
+	// contentPatternWindow is the number of leading bytes of content (after the license header) that are matched
+	// against the content patterns of file types.
+	contentPatternWindow = 4000
+)
+
+// Change describes the modification that a license operation makes (or would make) to a single file.
+type Change struct {
+	// Path is the path to the file.
+	Path string
+	// Mode is the file mode of the file.
+	Mode os.FileMode
+	// Content is the content of the file after the change has been applied.
+	Content string
+	// ForeignLicense is the name of the foreign license whose header the file had before the change. Empty if the
+	// file did not have the header of a foreign license.
+	ForeignLicense string
+	// MissingCopyright specifies that the file had the license header except for a valid copyright line before the
+	// change.
+	MissingCopyright bool
+	// WrongDelimiters specifies that the file had the license header with different comment delimiters (for example,
+	// "/**" rather than "/*") before the change.
+	WrongDelimiters bool
+	// HadHeader specifies that the file started with the license header or with a leading comment that resembles a
+	// license header before the change (see hasHeader).
+	HadHeader bool
+	// MissingPackageClause specifies that the file is a Go file that has the license header but whose header is not
+	// followed by a package clause (see ProjectParam.RequirePackageClause). Such changes do not modify the file.
+	MissingPackageClause bool
+	// NotInManifest specifies that the file has the license header but is not listed in the manifest (see
+	// RunParam.ManifestClosed). Such changes do not modify the file.
+	NotInManifest bool
+	// UnexpandedPlaceholder specifies that the file has the license header but that its header contains a placeholder
+	// that was not expanded (see ProjectParam.RejectPlaceholders). Such changes do not modify the file.
+	UnexpandedPlaceholder bool
+	// Header is the license header of the file after the change has been applied. Empty if the file does not have
+	// the license header after the change (for example, because the header was removed).
+	Header string
+}
+
+// RunLicense runs the license operation using the provided arguments.
+func RunLicense(files []string, projectParam ProjectParam, runParam RunParam, stdout io.Writer) error {
+	if runParam.Archive == "" {
+		return RunLicenseProjects([]Project{{Files: files, Param: projectParam}}, runParam, stdout)
+	}
+	if !runParam.Verify {
+		return errors.Errorf("archives can only be verified")
+	}
+	if ok, err := verifyArchiveFile(runParam.Archive, projectParam, runParam, stdout); err != nil {
+		return err
+	} else if !ok {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// RunLicenseFile runs the license operation on the single file at the provided path (relative to the working directory
+// or absolute) without walking the project directory. An absolute path is made relative to the working directory. The
+// license header that applies to the file is determined in the same manner as for RunLicense, so the file is not
+// modified and is considered valid if it is not a Go file or a file of a configured file type or if it is excluded.
+// Returns an error if the file does not exist or is a directory.
+func RunLicenseFile(file string, projectParam ProjectParam, runParam RunParam, stdout io.Writer) error {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat %s", file)
+	}
+	if fi.IsDir() {
+		return errors.Errorf("%s is a directory", file)
+	}
+	relFile, err := relativeToWorkingDir(file)
+	if err != nil {
+		return err
+	}
+	return RunLicense([]string{relFile}, projectParam, runParam, stdout)
+}
+
+// RunLicenseContent runs the license operation on the content read from the provided reader, which is treated as the
+// content of the file at the provided path. The path is only used to determine the license header that applies to the
+// content and the file is not read or written. An absolute path is made relative to the working directory. For apply
+// and remove, the resulting content is written to the provided writer. For verify, nothing is written if the content
+// has the correct license header and an error is returned if it does not. Content of files that are not Go files or
+// files of a configured file type or that are excluded is written unmodified for apply and remove and is always
+// considered valid for verify, as is the content of files that are not matched by ProjectParam.Required.
+func RunLicenseContent(path string, in io.Reader, projectParam ProjectParam, runParam RunParam, stdout io.Writer) error {
+	contentBytes, err := io.ReadAll(in)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read content")
+	}
+	content := string(contentBytes)
+	if path, err = relativeToWorkingDir(path); err != nil {
+		return err
+	}
+
+	modules := newModuleResolver(projectParam)
+	licenser, ok, err := modules.fileLicenser(path)
+	if err != nil {
+		return err
+	}
+	contentLicenser, contentOK, err := modules.contentFileLicenser(path, content)
+	if err != nil {
+		return err
+	} else if contentOK {
+		licenser = contentLicenser
+	}
+	if runParam.Verify {
+		if !ok || projectParam.empty() || !requiredFile(path, projectParam, runParam) {
+			return nil
+		}
+		if _, changed := projectVisitor(applyVisitor(runParam, projectParam), projectParam)(content, licenser); changed {
+			failures := make(map[string]failure)
+			if !licenser.Matches(content) {
+				if license := foreignLicense(content, projectParam); license != "" {
+					failures[path] = failure{foreignLicense: license}
+				} else if _, ok := redelimit(content, licenser); ok {
+					failures[path] = failure{wrongDelimiters: true}
+				} else if missingCopyright(content, licenser) {
+					failures[path] = failure{missingCopyright: true}
+				}
+			}
+			if err := writeVerifyFailures([]string{path}, failures, runParam, stdout); err != nil {
+				return err
+			}
+			return ErrVerifyFailed
+		}
+		if fileType, _ := fileTypeName(path, projectParam); projectParam.RequirePackageClause && fileType == GoFileType && !contentOK {
+			if _, missing := projectVisitor(packageClauseVisitor, projectParam)(content, licenser); missing {
+				if err := writeVerifyFailures([]string{path}, map[string]failure{path: {missingPackageClause: true}}, runParam, stdout); err != nil {
+					return err
+				}
+				return ErrVerifyFailed
+			}
+		}
+		if projectParam.RejectPlaceholders {
+			if _, found := projectVisitor(placeholderVisitor, projectParam)(content, licenser); found {
+				if err := writeVerifyFailures([]string{path}, map[string]failure{path: {unexpandedPlaceholder: true}}, runParam, stdout); err != nil {
+					return err
+				}
+				return ErrVerifyFailed
+			}
+		}
+		return nil
+	}
+	if ok && !projectParam.empty() && !(runParam.addOnly() && hasHeader(content, licenser)) {
+		visitor := applyVisitor(runParam, projectParam)
+		if runParam.Remove {
+			visitor = removeVisitor(projectParam)
+		}
+		var changed bool
+		content, changed = projectVisitor(visitor, projectParam)(content, licenser)
+		if changed && !runParam.Remove && !licenser.Empty() && licenser.Matches(content) {
+			start, end := headerBounds(content, licenser)
+			if err := checkPlaceholders([]Change{{Path: path, Header: content[start:end]}}, projectParam); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := io.WriteString(stdout, content); err != nil {
+		return errors.Wrapf(err, "failed to write content")
+	}
+	return nil
+}
+
+// VerifyFiles verifies that all of the provided files have the correct license header. If any files do not, the files
+// are listed in the provided writer and false is returned. If runParam.FailuresFile is non-empty, the files are also
+// written to it. Files that start with multiple consecutive copies of the header are considered not to have the correct
+// header. The output is colored based on runParam.Color.
+func VerifyFiles(files []string, projectParam ProjectParam, runParam RunParam, stdout io.Writer) (bool, error) {
+	changes, err := processFiles(files, projectParam, applyVisitor(runParam, projectParam))
+	if err != nil {
+		return false, err
+	}
+	return reportVerifyFailures(displayPaths(changes, runParam), changeFailures(changes, runParam), runParam, stdout)
+}
+
+// displayPaths returns the paths of the provided changes as they should be reported based on the provided parameters.
+func displayPaths(changes []Change, runParam RunParam) []string {
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, displayPath(change.Path, runParam))
+	}
+	return paths
+}
+
+func verifyArchiveFile(archive string, projectParam ProjectParam, runParam RunParam, stdout io.Writer) (bool, error) {
+	format, err := ArchiveFormat(archive)
+	if err != nil {
+		return false, err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to open archive %s", archive)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	failed, err := VerifyArchive(f, format, projectParam)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to verify archive %s", archive)
+	}
+	return reportVerifyFailures(failed, nil, runParam, stdout)
+}
+
+// reportVerifyFailures prints the provided paths of the files that failed verification and writes them to the
+// failures file if one is specified. The provided map from paths to how the files differ from having the correct
+// license header is used to report the files that have the header of a foreign license or that are missing the
+// copyright line of the header separately. Returns true if there are no failures.
+func reportVerifyFailures(paths []string, failures map[string]failure, runParam RunParam, stdout io.Writer) (bool, error) {
+	if runParam.FailuresFile != "" {
+		if err := writeFailuresFile(runParam.FailuresFile, paths); err != nil {
+			return false, err
+		}
+	}
+	if err := writeVerifyFailures(paths, failures, runParam, stdout); err != nil {
+		return false, err
+	}
+	return len(paths) == 0, nil
+}
+
+// writeVerifyFailures writes the provided paths of the files that failed verification in the output format specified
+// by the provided parameters. Nothing is written for text output if there are no failures.
+func writeVerifyFailures(paths []string, failures map[string]failure, runParam RunParam, stdout io.Writer) error {
+	switch {
+	case runParam.CountOnly:
+		_, _ = fmt.Fprintln(stdout, len(paths))
+	case runParam.Output == OutputSARIF:
+		return writeSARIF(paths, failures, stdout)
+	case runParam.Output == OutputNDJSON:
+		for _, path := range paths {
+			if err := writeNDJSON(path, failures[path], stdout); err != nil {
+				return err
+			}
+		}
+	case runParam.Output == OutputJSON:
+		return writeJSON(paths, failures, stdout)
+	case runParam.Output == OutputCheckstyle:
+		return writeCheckstyle(paths, failures, stdout)
+	case runParam.Output == OutputGitHub:
+		for _, path := range paths {
+			if err := writeGitHubAnnotation(path, failures[path], stdout); err != nil {
+				return err
+			}
+		}
+	case len(paths) > 0 && runParam.TextLayout == TextLayoutTable:
+		return printVerifyTable(paths, failures, stdout)
+	case len(paths) > 0:
+		printVerifyFailures(paths, failures, runParam, stdout)
+	}
+	return nil
+}
+
+// printVerifyFailures prints the provided paths of the files that failed verification. The files that have the header
+// of a foreign license (along with the name of the license), the files that have the header with different comment
+// delimiters, the files that are missing the copyright line of the header, the files whose header is not followed by a
+// package clause, the files that have the header but are not listed in the manifest and the files whose header contains
+// unexpanded placeholders (as described by the provided map) are listed separately from the other files.
+func printVerifyFailures(paths []string, failures map[string]failure, runParam RunParam, stdout io.Writer) {
+	c := colorizer(runParam.Color.Enabled(stdout))
+	var missing, wrong, wrongDelimiters, noCopyright, noPackageClause, unlisted, placeholders []string
+	for _, path := range paths {
+		switch f := failures[path]; {
+		case f.foreignLicense != "":
+			wrong = append(wrong, path)
+		case f.wrongDelimiters:
+			wrongDelimiters = append(wrongDelimiters, path)
+		case f.missingCopyright:
+			noCopyright = append(noCopyright, path)
+		case f.missingPackageClause:
+			noPackageClause = append(noPackageClause, path)
+		case f.notInManifest:
+			unlisted = append(unlisted, path)
+		case f.unexpandedPlaceholder:
+			placeholders = append(placeholders, path)
+		default:
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		plural := "files do"
+		if len(missing) == 1 {
+			plural = "file does"
+		}
+		parts := []string{fmt.Sprintf("%s %s not have the correct license header:", c.bold(strconv.Itoa(len(missing))), plural)}
+		_, _ = fmt.Fprintln(stdout, strings.Join(append(parts, failureLines(missing, failures, runParam, c)...), "\n\t"))
+	}
+	if len(wrong) > 0 {
+		plural := "files have"
+		if len(wrong) == 1 {
+			plural = "file has"
+		}
+		parts := []string{fmt.Sprintf("%s %s the license header of a different license:", c.bold(strconv.Itoa(len(wrong))), plural)}
+		_, _ = fmt.Fprintln(stdout, strings.Join(append(parts, failureLines(wrong, failures, runParam, c)...), "\n\t"))
+	}
+	if len(wrongDelimiters) > 0 {
+		plural := "files have"
+		if len(wrongDelimiters) == 1 {
+			plural = "file has"
+		}
+		parts := []string{fmt.Sprintf("%s %s the license header with different comment delimiters:", c.bold(strconv.Itoa(len(wrongDelimiters))), plural)}
+		_, _ = fmt.Fprintln(stdout, strings.Join(append(parts, failureLines(wrongDelimiters, failures, runParam, c)...), "\n\t"))
+	}
+	if len(noCopyright) > 0 {
+		plural := "files have"
+		if len(noCopyright) == 1 {
+			plural = "file has"
+		}
+		parts := []string{fmt.Sprintf("%s %s the license header without a valid copyright line:", c.bold(strconv.Itoa(len(noCopyright))), plural)}
+		_, _ = fmt.Fprintln(stdout, strings.Join(append(parts, failureLines(noCopyright, failures, runParam, c)...), "\n\t"))
+	}
+	if len(noPackageClause) > 0 {
+		plural := "files do"
+		if len(noPackageClause) == 1 {
+			plural = "file does"
+		}
+		parts := []string{fmt.Sprintf("%s %s not have a package clause after the license header:", c.bold(strconv.Itoa(len(noPackageClause))), plural)}
+		_, _ = fmt.Fprintln(stdout, strings.Join(append(parts, failureLines(noPackageClause, failures, runParam, c)...), "\n\t"))
+	}
+	if len(unlisted) > 0 {
+		plural := "files have the license header but are"
+		if len(unlisted) == 1 {
+			plural = "file has the license header but is"
+		}
+		parts := []string{fmt.Sprintf("%s %s not listed in the manifest:", c.bold(strconv.Itoa(len(unlisted))), plural)}
+		_, _ = fmt.Fprintln(stdout, strings.Join(append(parts, failureLines(unlisted, failures, runParam, c)...), "\n\t"))
+	}
+	if len(placeholders) > 0 {
+		plural := "files have"
+		if len(placeholders) == 1 {
+			plural = "file has"
+		}
+		parts := []string{fmt.Sprintf("%s %s the license header with unexpanded placeholders:", c.bold(strconv.Itoa(len(placeholders))), plural)}
+		_, _ = fmt.Fprintln(stdout, strings.Join(append(parts, failureLines(placeholders, failures, runParam, c)...), "\n\t"))
+	}
+}
+
+// failureLines returns the lines that list the provided paths of files that failed verification. The name of the
+// foreign license of a file (if any, as described by the provided map) follows its path. If runParam.GroupByDir is
+// true, the paths are grouped by directory: the groups are in the order of the directories and the paths of each group
+// are preceded by a line that consists of the directory and are indented.
+func failureLines(paths []string, failures map[string]failure, runParam RunParam, c colorizer) []string {
+	line := func(path string) string {
+		if license := failures[path].foreignLicense; license != "" {
+			return fmt.Sprintf("%s (%s)", c.red(path), license)
+		}
+		return c.red(path)
+	}
+	var lines []string
+	if !runParam.GroupByDir {
+		for _, path := range paths {
+			lines = append(lines, line(path))
+		}
+		return lines
+	}
+	groups := make(map[string][]string)
+	for _, path := range paths {
+		dir := filepath.ToSlash(filepath.Dir(path))
+		groups[dir] = append(groups[dir], path)
+	}
+	var dirs []string
+	for dir := range groups {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		lines = append(lines, c.bold(dir+"/"))
+		for _, path := range groups[dir] {
+			lines = append(lines, "\t"+line(path))
+		}
+	}
+	return lines
+}
+
+// writeChanges writes the provided changes to their files and returns the changes that were written. No files are
+// written if more files would be modified than runParam.MaxChanges allows. The new content of every file is staged (see
+// stageFile) before any file is modified, so no files are modified if an error occurs while the content is staged and
+// an interrupted operation never leaves a partially written file (the temporary files that an interrupted operation
+// left behind are removed by the next operation that writes the files). If runParam.Backup is true, each file is backed
+// up (see writeBackup) before its content is staged. If an error that occurs for a file is recorded in the provided
+// errors, the file is skipped rather than the error being returned.
+func writeChanges(changes []Change, runParam RunParam, errs *fileErrors) ([]Change, error) {
+	if maxChanges := runParam.MaxChanges; maxChanges > 0 && len(changes) > maxChanges {
+		return nil, errors.Errorf("%d files would be modified, which exceeds the maximum of %d: no files were modified", len(changes), maxChanges)
+	}
+	removeStaleStagedFiles(changePaths(changes))
+	var staged []Change
+	var stagedFiles []stagedFile
+	for _, change := range changes {
+		f, err := stageChange(change, runParam)
+		if err != nil {
+			if errs.record(change.Path, err) {
+				continue
+			}
+			for _, f := range stagedFiles {
+				f.discard()
+			}
+			return nil, err
+		}
+		staged = append(staged, change)
+		stagedFiles = append(stagedFiles, f)
+	}
+	var written []Change
+	for i, change := range staged {
+		if err := stagedFiles[i].commit(); err != nil {
+			for _, f := range stagedFiles[i+1:] {
+				f.discard()
+			}
+			return written, errors.Wrapf(err, "failed to write file %s", change.Path)
+		}
+		written = append(written, change)
+	}
+	return written, nil
+}
+
+// stageChange stages the content of the provided change for its file, backing up the file first if runParam.Backup is
+// true.
+func stageChange(change Change, runParam RunParam) (stagedFile, error) {
+	if runParam.Backup {
+		if err := writeBackup(change); err != nil {
+			return stagedFile{}, err
+		}
+	}
+	staged, err := stageFile(change.Path, []byte(change.Content), change.Mode)
+	if err != nil {
+		return stagedFile{}, errors.Wrapf(err, "failed to write file %s", change.Path)
+	}
+	return staged, nil
+}
+
+// writeBackup writes the current content of the file of the provided change to the path of the file with BackupSuffix
+// appended. The backup has the permissions of the file.
+func writeBackup(change Change) error {
+	content, err := os.ReadFile(longPath(change.Path))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", change.Path)
+	}
+	backup := change.Path + BackupSuffix
+	if err := writeFileAtomic(backup, content, change.Mode); err != nil {
+		return errors.Wrapf(err, "failed to write backup %s", backup)
+	}
+	return nil
+}
+
+// postModifyCommands returns a map from the paths of the provided changes to the post-modify command of the provided
+// parameters. Returns an empty map if the parameters do not specify a post-modify command.
+func postModifyCommands(changes []Change, projectParam ProjectParam) map[string][]string {
+	commands := make(map[string][]string)
+	if len(projectParam.PostModifyCommand) == 0 {
+		return commands
+	}
+	for _, change := range changes {
+		commands[change.Path] = projectParam.PostModifyCommand
+	}
+	return commands
+}
+
+func changePaths(changes []Change) []string {
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	return paths
+}
+
+// processFiles determines the changes that the provided visitor makes to the provided files. The visitor is
+// called with the content of each file and the Licenser that applies to it (the Licenser for the header of the sidecar
+// file of the file if it has one) and returns the new content and whether or not the content was changed. The files
+// are processed in parallel, so the visitor must be safe for concurrent use. The returned changes are sorted by path.
+func processFiles(files []string, projectParam ProjectParam, visitor func(content string, licenser golicense.Licenser) (string, bool)) ([]Change, error) {
+	return processTimedFiles(files, projectParam, visitor, nil, nil, nil)
+}
+
+// processTimedFiles determines the changes that the provided visitor makes to the provided files in the manner
+// described for processFiles and records the time taken to process each file in the provided timings. Files for which
+// an error is recorded in the provided errors are skipped (see streamPaths). The changes are failures of verify that
+// stop processing if the provided failFast stops at the first failure, in which case at most one change is returned.
+func processTimedFiles(files []string, projectParam ProjectParam, visitor func(content string, licenser golicense.Licenser) (string, bool), timings *fileTimings, errs *fileErrors, ff *failFast) ([]Change, error) {
+	var changes []Change
+	if err := streamFiles(ff.context(), files, projectParam, visitor, timings, errs, func(change Change) error {
+		changes = append(changes, change)
+		ff.stop()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// streamFiles determines the changes that the provided visitor makes to the provided files in the manner described for
+// processFiles and calls the provided function with each change as soon as the change and the changes of all of the
+// files that precede it have been determined, so the function is called in the order of the files regardless of the
+// order in which they are processed. Stops calling the function and returns the error once determining a change or
+// the function returns an error or the provided context is cancelled. The time taken to process each file is recorded in
+// the provided timings and files for which an error is recorded in the provided errors are skipped (see streamPaths).
+func streamFiles(ctx context.Context, files []string, projectParam ProjectParam, visitor func(content string, licenser golicense.Licenser) (string, bool), timings *fileTimings, errs *fileErrors, emit func(Change) error) error {
+	paths := make(chan string)
+	go func() {
+		for _, file := range files {
+			paths <- file
+		}
+		close(paths)
+	}()
+	return streamPaths(ctx, paths, projectParam, visitor, timings, errs, emit)
+}
+
+// streamPaths determines the changes that the provided visitor makes to the files whose paths are received from the
+// provided channel in the manner described for streamFiles, where the order of the files is the order in which they
+// are received. Files are processed as soon as they are received. All of the paths are received from the channel
+// (until it is closed) even if an error occurs, but once an error occurs or the provided context is cancelled, the
+// files whose processing has not started are not processed and the function is not called again. Cancelling the
+// context is not an error. The time taken to process each file is recorded in the provided timings. If an error that
+// occurs for a file is recorded in the provided errors, the file is skipped rather than the error being returned.
+func streamPaths(ctx context.Context, paths <-chan string, projectParam ProjectParam, visitor func(content string, licenser golicense.Licenser) (string, bool), timings *fileTimings, errs *fileErrors, emit func(Change) error) error {
+	// if header and matchers do not exist, return (nothing to check)
+	if projectParam.empty() {
+		for range paths {
+		}
+		return nil
+	}
+
+	visitor = projectVisitor(visitor, projectParam)
+	modules := newModuleResolver(projectParam)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		idx  int
+		file string
+	}
+	type result struct {
+		idx    int
+		change *Change
+		err    error
+	}
+	jobs := make(chan job)
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if ctx.Err() != nil {
+					results <- result{idx: j.idx}
+					continue
+				}
+				start := time.Now()
+				change, err := processFile(j.file, projectParam, modules, visitor)
+				timings.record(j.file, time.Since(start))
+				if err != nil && errs.record(j.file, err) {
+					change, err = nil, nil
+				}
+				results <- result{idx: j.idx, change: change, err: err}
+			}
+		}()
+	}
+	go func() {
+		idx := 0
+		for file := range paths {
+			jobs <- job{idx: idx, file: file}
+			idx++
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// results are buffered until the results of all of the preceding files are available
+	pending := make(map[int]result)
+	next := 0
+	var err error
+	for r := range results {
+		pending[r.idx] = r
+		for ; ; next++ {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			switch {
+			case err != nil || ctx.Err() != nil:
+				// drain the remaining results
+			case r.err != nil:
+				err = r.err
+			case r.change != nil:
+				err = emit(*r.change)
+			}
+			if err != nil {
+				cancel()
+			}
+		}
+	}
+	return err
+}
+
+// processFile returns the change that the provided visitor makes to the provided file, whose Licenser is determined
+// using the provided resolver. Returns nil if the file does not have a license header or if the visitor does not
+// change it.
+func processFile(file string, projectParam ProjectParam, modules *moduleResolver, visitor func(content string, licenser golicense.Licenser) (string, bool)) (*Change, error) {
+	licenser, ok, err := modules.fileLicenser(file)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, nil
+	}
+	sidecar, sidecarOK, err := sidecarLicenser(file, projectParam)
+	if err != nil {
+		return nil, err
+	} else if sidecarOK {
+		if sidecar.Empty() {
+			// an empty sidecar file specifies that the file does not have a header
+			return nil, nil
+		}
+		licenser = sidecar
+	}
+	fi, err := os.Stat(longPath(file))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat %s", file)
+	}
+	bytes, err := os.ReadFile(longPath(file))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", file)
+	}
+	if !sidecarOK {
+		if contentLicenser, ok, err := modules.contentFileLicenser(file, string(bytes)); err != nil {
+			return nil, err
+		} else if ok {
+			licenser = contentLicenser
+		}
+	}
+	content, changed := visitor(string(bytes), licenser)
+	if !changed {
+		return nil, nil
+	}
+	change := &Change{
+		Path:      file,
+		Mode:      fi.Mode(),
+		Content:   content,
+		HadHeader: hasHeader(string(bytes), licenser),
+	}
+	if !licenser.Empty() && licenser.Matches(content) {
+		start, end := headerBounds(content, licenser)
+		change.Header = content[start:end]
+	}
+	if !licenser.Matches(string(bytes)) {
+		change.ForeignLicense = foreignLicense(string(bytes), projectParam)
+		if change.ForeignLicense == "" {
+			_, change.WrongDelimiters = redelimit(string(bytes), licenser)
+			change.MissingCopyright = !change.WrongDelimiters && missingCopyright(string(bytes), licenser)
+		}
+	}
+	return change, nil
+}
+
+// fileLicenser returns the Licenser that applies to the file at the provided path. Returns false if the file is not
+// a Go file or a file of a configured file type or if it is excluded.
+func fileLicenser(file string, projectParam ProjectParam) (golicense.Licenser, bool) {
+	if projectParam.Exclude != nil && projectParam.Exclude.Match(file) {
+		return nil, false
+	}
+	fileType, ok := fileTypeFor(file, projectParam)
+	if !ok && !goFileMatcher.Match(file) {
+		return nil, false
+	}
+	return fileTypeLicenser(file, fileType, ok, projectParam), true
+}
+
+// contentFileLicenser returns the Licenser that applies to the file at the provided path, which has the provided
+// content, as a file of the file type whose content pattern matches the content (see FileTypeParam.ContentPattern).
+// Returns false if the file is not a Go file or a file of a configured file type, if it is excluded or if the content
+// does not match the content pattern of any file type, in which case the Licenser returned by fileLicenser applies.
+func contentFileLicenser(file, content string, projectParam ProjectParam) (golicense.Licenser, bool) {
+	if _, ok := fileLicenser(file, projectParam); !ok {
+		return nil, false
+	}
+	for _, fileType := range projectParam.FileTypes {
+		if fileType.ContentPattern == nil {
+			continue
+		}
+		licenser := fileTypeLicenser(file, fileType, true, projectParam)
+		rest := content
+		if !licenser.Empty() && licenser.Matches(rest) {
+			rest = licenser.Remove(rest)
+		}
+		if len(rest) > contentPatternWindow {
+			rest = rest[:contentPatternWindow]
+		}
+		if fileType.ContentPattern.MatchString(rest) {
+			return licenser, true
+		}
+	}
+	return nil, false
+}
+
+// fileTypeLicenser returns the Licenser that applies to the file at the provided path, which is of the provided file
+// type if ok is true and is a Go file that is not of a configured file type otherwise.
+func fileTypeLicenser(file string, fileType FileTypeParam, ok bool, projectParam ProjectParam) golicense.Licenser {
+	licenser := projectParam.Licenser
+	customHeader := ""
+	if v, matched := customHeaderFor(file, projectParam); matched {
+		licenser = v.Licenser
+		customHeader = v.Name
+	}
+	if ok {
+		licenser = fileType.licenser(customHeader, licenser)
+	} else if customHeader == "" && projectParam.TestLicenser != nil && goTestFileMatcher.Match(file) {
+		licenser = projectParam.TestLicenser
+	}
+	if ok && fileType.BlankLinesAfterHeader != nil {
+		licenser = NewSeparatedLicenser(licenser, *fileType.BlankLinesAfterHeader)
+	}
+	if ok {
+		licenser = newEncodingLicenser(licenser, fileType.Encoding)
+		licenser = newLeadingLinesLicenser(licenser, fileType)
+	}
+	return licenser
+}
+
+// customHeaderFor returns the custom header parameter that applies to the provided file. Returns false if no custom
+// header parameter applies.
+func customHeaderFor(file string, projectParam ProjectParam) (golicense.CustomHeaderParam, bool) {
+	// file may match multiple custom header params -- if that is the case, use the longest match. Allows for
+	// hierarchical matching. If multiple params match equally specifically, the one that appears first wins so that
+	// the result does not depend on anything other than the order of the params.
+	var match golicense.CustomHeaderParam
+	matched := false
+	longestMatchLen := 0
+	for _, v := range projectParam.CustomHeaders {
+		for _, p := range v.IncludePaths {
+			if matcher.PathLiteral(p).Match(file) && (!matched || len(p) > longestMatchLen) {
+				match = v
+				matched = true
+				longestMatchLen = len(p)
+			}
+		}
+	}
+	return match, matched
+}
+
+// fileTypeFor returns the file type in the provided parameters that applies to the provided file. If multiple file
+// types match the file, the file type with the most specific match is used. A match on an extension is more specific
+// than a match on a name and longer extensions are more specific than shorter ones (so ".go.tmpl" is more specific
+// than ".tmpl"). If multiple file types match with the same specificity, the one that appears first in the parameters
+// is used. Returns false if no file type matches.
+func fileTypeFor(file string, projectParam ProjectParam) (FileTypeParam, bool) {
+	var match FileTypeParam
+	matched := false
+	matchSpecificity := 0
+	for _, fileType := range projectParam.FileTypes {
+		if fileType.Matcher == nil || !fileType.Matcher.Match(file) {
+			continue
+		}
+		if specificity := fileType.specificity(file); !matched || specificity > matchSpecificity {
+			match = fileType
+			matched = true
+			matchSpecificity = specificity
+		}
+	}
+	return match, matched
+}
+
+// fileTypeName returns the name of the file type of the provided file: the name of the configured file type that applies
+// to it or GoFileType for Go files that are not of a configured file type. Returns false if the file is neither.
+func fileTypeName(file string, projectParam ProjectParam) (string, bool) {
+	if fileType, ok := fileTypeFor(file, projectParam); ok {
+		return fileType.Name, true
+	}
+	if goFileMatcher.Match(file) {
+		return GoFileType, true
+	}
+	return "", false
+}
+
+// VerifyContent returns true if the provided content of the file at the provided path has the correct license
+// header. Files that are not Go files or files of a configured file type and files that are excluded are always
+// considered to have the correct header. The files are not grouped by package, so the package doc Licenser applies to
+// the files whose name is the name of the package doc file. Content whose header is accepted by the Licenser (see
+// NewAcceptingLicenser) is considered to have the correct header in the same manner as for RunLicense.
+func VerifyContent(path string, content []byte, projectParam ProjectParam) bool {
+	licenser, ok := fileLicenser(path, projectParam)
+	if !ok {
+		return true
+	}
+	licenser = archivePackageDocLicenser(path, licenser, projectParam)
+	if contentLicenser, ok := contentFileLicenser(path, string(content), projectParam); ok {
+		licenser = contentLicenser
+	}
+	_, changed := projectVisitor(applyVisitor(RunParam{Verify: true}, projectParam), projectParam)(string(content), licenser)
+	return !changed
+}
+
+// projectVisitor returns a visitor that behaves in the same manner as the provided visitor with the additional
+// behavior specified by the provided parameters: content with the third-party marker is not changed, the years of
+// headers are updated and content is given a final newline.
+func projectVisitor(visitor func(content string, licenser golicense.Licenser) (string, bool), projectParam ProjectParam) func(content string, licenser golicense.Licenser) (string, bool) {
+	return withThirdPartySkip(withFinalNewline(withYearUpdates(visitor, projectParam), projectParam), projectParam)
+}
+
+func applyLicense(content string, licenser golicense.Licenser) (string, bool) {
+	if skipContent(content) {
+		return content, false
+	}
+	if licenser.Matches(content) {
+		return removeDuplicateHeaders(content, licenser)
+	}
+	if redelimited, ok := redelimit(content, licenser); ok {
+		return redelimited, true
+	}
+	return licenser.Add(content), true
+}
+
+// applyLicenseStrict applies the license header in the same manner as applyLicense and also removes any blank lines
+// between the header and the content that follows it, so that the header is followed by the content in the same
+// manner as when the header is added to content that does not start with a blank line.
+func applyLicenseStrict(content string, licenser golicense.Licenser) (string, bool) {
+	content, changed := applyLicense(content, licenser)
+	if skipContent(content) || licenser.Empty() {
+		return content, changed
+	}
+	header, rest := splitHeader(content, licenser)
+	trimmed := trimLeadingBlankLines(rest)
+	if trimmed == rest {
+		return content, changed
+	}
+	return header + trimmed, true
+}
+
+// applyVisitor returns the visitor that applies the license header based on the provided parameters. For verify, the
+// visitor does not change content whose header is accepted by the Licenser (see NewAcceptingLicenser). Strict has no
+// effect on projects that preserve leading blank lines.
+func applyVisitor(runParam RunParam, projectParam ProjectParam) func(content string, licenser golicense.Licenser) (string, bool) {
+	visitor := applyLicense
+	if runParam.Strict && !projectParam.PreserveLeadingBlankLines {
+		visitor = applyLicenseStrict
+	}
+	visitor = withCopyrightMerge(visitor, projectParam)
+	if !runParam.Verify {
+		return visitor
+	}
+	return func(content string, licenser golicense.Licenser) (string, bool) {
+		if !licenser.Matches(content) && accepts(content, licenser) {
+			return content, false
+		}
+		return visitor(content, licenser)
+	}
+}
+
+// trimLeadingBlankLines returns the provided content with its leading lines that consist only of whitespace removed.
+func trimLeadingBlankLines(content string) string {
+	for content != "" {
+		line := content
+		if lineEnd := strings.IndexByte(content, '\n'); lineEnd != -1 {
+			line = content[:lineEnd+1]
+		}
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+		content = content[len(line):]
+	}
+	return content
+}
+
+// removeDuplicateHeaders collapses consecutive copies of the license header at the start of the provided content,
+// which must match the provided licenser, into a single copy. Returns the resulting content and whether any copies
+// were removed.
+func removeDuplicateHeaders(content string, licenser golicense.Licenser) (string, bool) {
+	if licenser.Empty() {
+		return content, false
+	}
+	changed := false
+	for {
+		rest := licenser.Remove(content)
+		if !licenser.Matches(rest) {
+			return content, changed
+		}
+		content, changed = rest, true
+	}
+}
+
+// removeVisitor returns the visitor that removes license headers based on the provided parameters. If
+// projectParam.HeaderEnd is non-nil, headers that do not match the Licenser are removed up to and including the line
+// that matches it.
+func removeVisitor(projectParam ProjectParam) func(content string, licenser golicense.Licenser) (string, bool) {
+	if projectParam.HeaderEnd == nil {
+		return removeLicense
+	}
+	return func(content string, licenser golicense.Licenser) (string, bool) {
+		if newContent, changed := removeLicense(content, licenser); changed || skipContent(content) {
+			return newContent, changed
+		}
+		return removeToHeaderEnd(content, licenser, projectParam.HeaderEnd, projectParam.KeepGeneratedNotice)
+	}
+}
+
+// removeToHeaderEnd removes the content up to and including the first line that matches the provided regular
+// expression, which marks the end of the header. The line must be in the leading block of lines of the content that
+// are not blank (after the leading lines of the content that the Licenser keeps), so content that does not start with a
+// header is not modified. If keepNotice is true, the removed lines that are generated-code notices are kept in place of
+// the header. Returns false if there is no such line.
+func removeToHeaderEnd(content string, licenser golicense.Licenser, headerEnd *regexp.Regexp, keepNotice bool) (string, bool) {
+	var leadingLines string
+	rest := content
+	if l, ok := licenser.(*leadingLinesLicenser); ok {
+		leadingLines, rest = l.split(content)
+	}
+	var notices string
+	offset := 0
+	for offset < len(rest) {
+		lineEnd := strings.IndexByte(rest[offset:], '\n')
+		next := len(rest)
+		if lineEnd != -1 {
+			next = offset + lineEnd + 1
+		}
+		line := strings.TrimRight(rest[offset:next], "\r\n")
+		if strings.TrimSpace(line) == "" {
+			return content, false
+		}
+		if keepNotice && isGeneratedNotice(line) {
+			notices += rest[offset:next]
+		}
+		if headerEnd.MatchString(line) {
+			return leadingLines + notices + rest[next:], true
+		}
+		offset = next
+	}
+	return content, false
+}
+
+func removeLicense(content string, licenser golicense.Licenser) (string, bool) {
+	if skipContent(content) || !accepts(content, licenser) {
+		return content, false
+	}
+	return licenser.Remove(content), true
+}
+
+// skipContent returns true if the provided content should not be processed: either because it is binary or because
+// it contains the ignore directive.
+func skipContent(content string) bool {
+	return isBinary(content) || hasIgnoreDirective(content)
+}
+
+// isBinary returns true if the provided content appears to be binary, which is the case if its first
+// binaryDetectionWindow bytes contain a NUL byte. Adding a license header to binary content would corrupt it.
+func isBinary(content string) bool {
+	if len(content) > binaryDetectionWindow {
+		content = content[:binaryDetectionWindow]
+	}
+	return strings.IndexByte(content, 0) != -1
+}
+
+// hasIgnoreDirective returns true if one of the first ignoreDirectiveMaxLines lines of the provided content is a
+// comment that consists of the "license:ignore" directive.
+func hasIgnoreDirective(content string) bool {
+	for i := 0; i < ignoreDirectiveMaxLines && content != ""; i++ {
+		line := content
+		if lineEnd := strings.IndexByte(content, '\n'); lineEnd != -1 {
+			line, content = content[:lineEnd], content[lineEnd+1:]
+		} else {
+			content = ""
+		}
+		if ignoreDirectiveRegexp.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneratedNotice returns true if the provided line is a generated-code notice.
+func isGeneratedNotice(line string) bool {
+	return generatedNoticeRegexp.MatchString(line)
+}
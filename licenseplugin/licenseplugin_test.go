@@ -0,0 +1,3053 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/palantir/pkg/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHeader = `// Copyright 2018 Palantir Technologies, Inc.`
+
+func TestRunLicense(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		files     map[string]string
+		runParam  licenseplugin.RunParam
+		wantErr   string
+		wantFiles map[string]string
+	}{
+		{
+			name: "apply adds header to files without one",
+			files: map[string]string{
+				"foo.go": "package foo\n",
+				"bar.go": testHeader + "\npackage bar\n",
+			},
+			wantFiles: map[string]string{
+				"foo.go": testHeader + "\npackage foo\n",
+				"bar.go": testHeader + "\npackage bar\n",
+			},
+		},
+		{
+			name: "remove removes header from files",
+			files: map[string]string{
+				"foo.go": testHeader + "\npackage foo\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Remove: true,
+			},
+			wantFiles: map[string]string{
+				"foo.go": "package foo\n",
+			},
+		},
+		{
+			name: "apply skips files with ignore directive",
+			files: map[string]string{
+				"foo.go": "// license:ignore\npackage foo\n",
+				"bar.go": "// Code generated by foo. DO NOT EDIT.\n\n/* license:ignore */\npackage bar\n",
+				"baz.go": "package baz\n\n// see license:ignore\n",
+			},
+			wantFiles: map[string]string{
+				"foo.go": "// license:ignore\npackage foo\n",
+				"bar.go": "// Code generated by foo. DO NOT EDIT.\n\n/* license:ignore */\npackage bar\n",
+				"baz.go": testHeader + "\npackage baz\n\n// see license:ignore\n",
+			},
+		},
+		{
+			name: "remove skips files with ignore directive",
+			files: map[string]string{
+				"foo.go": testHeader + "\n// license:ignore\npackage foo\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Remove: true,
+			},
+			wantFiles: map[string]string{
+				"foo.go": testHeader + "\n// license:ignore\npackage foo\n",
+			},
+		},
+		{
+			name: "verify skips files with ignore directive",
+			files: map[string]string{
+				"foo.go": "// license:ignore\npackage foo\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Verify: true,
+			},
+		},
+		{
+			name: "apply collapses duplicate headers",
+			files: map[string]string{
+				"foo.go": testHeader + "\n" + testHeader + "\n" + testHeader + "\npackage foo\n",
+			},
+			wantFiles: map[string]string{
+				"foo.go": testHeader + "\npackage foo\n",
+			},
+		},
+		{
+			name: "apply skips binary files",
+			files: map[string]string{
+				"foo.go": "package foo\x00\x01\x02\n",
+			},
+			wantFiles: map[string]string{
+				"foo.go": "package foo\x00\x01\x02\n",
+			},
+		},
+		{
+			name: "apply preserves blank lines after header",
+			files: map[string]string{
+				"foo.go": testHeader + "\n\n \npackage foo\n",
+			},
+			wantFiles: map[string]string{
+				"foo.go": testHeader + "\n\n \npackage foo\n",
+			},
+		},
+		{
+			name: "strict apply removes blank lines after header",
+			files: map[string]string{
+				"foo.go": testHeader + "\n\n \npackage foo\n",
+				"bar.go": "\npackage bar\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Strict: true,
+			},
+			wantFiles: map[string]string{
+				"foo.go": testHeader + "\npackage foo\n",
+				"bar.go": testHeader + "\npackage bar\n",
+			},
+		},
+		{
+			name: "apply with changes within max changes modifies files",
+			files: map[string]string{
+				"foo.go": "package foo\n",
+				"bar.go": "package bar\n",
+			},
+			runParam: licenseplugin.RunParam{
+				MaxChanges: 2,
+			},
+			wantFiles: map[string]string{
+				"foo.go": testHeader + "\npackage foo\n",
+				"bar.go": testHeader + "\npackage bar\n",
+			},
+		},
+		{
+			name: "apply with changes exceeding max changes fails without modifying files",
+			files: map[string]string{
+				"foo.go": "package foo\n",
+				"bar.go": "package bar\n",
+				"baz.go": testHeader + "\npackage baz\n",
+			},
+			runParam: licenseplugin.RunParam{
+				MaxChanges: 1,
+			},
+			wantErr: "2 files would be modified, which exceeds the maximum of 1: no files were modified",
+			wantFiles: map[string]string{
+				"foo.go": "package foo\n",
+				"bar.go": "package bar\n",
+				"baz.go": testHeader + "\npackage baz\n",
+			},
+		},
+		{
+			name: "remove with changes exceeding max changes fails without modifying files",
+			files: map[string]string{
+				"foo.go": testHeader + "\npackage foo\n",
+				"bar.go": testHeader + "\npackage bar\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Remove:     true,
+				MaxChanges: 1,
+			},
+			wantErr: "2 files would be modified, which exceeds the maximum of 1: no files were modified",
+			wantFiles: map[string]string{
+				"foo.go": testHeader + "\npackage foo\n",
+				"bar.go": testHeader + "\npackage bar\n",
+			},
+		},
+	} {
+		projectDir := t.TempDir()
+		files := writeFiles(t, projectDir, tc.files)
+
+		err := licenseplugin.RunLicense(files, licenseplugin.ProjectParam{
+			Licenser: golicense.NewLicenser(testHeader),
+		}, tc.runParam, &bytes.Buffer{})
+		if tc.wantErr == "" {
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+		} else {
+			require.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+		}
+
+		for k, want := range tc.wantFiles {
+			got, err := os.ReadFile(filepath.Join(projectDir, k))
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			assert.Equal(t, want, string(got), "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
+func TestRunLicenseHeaderEnd(t *testing.T) {
+	const header = "// Copyright 2018 Palantir Technologies, Inc.\n// ----"
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"exact.go":     header + "\npackage foo\n",
+		"modified.go":  "// Copyright 2015 Palantir Technologies\n// All rights reserved.\n// ----\npackage foo\n",
+		"separated.go": "// Package foo does things.\n\n// ----\npackage foo\n",
+		"none.go":      "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:  golicense.NewLicenser(header),
+		HeaderEnd: regexp.MustCompile(`^// -+$`),
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{"exact.go", "package foo\n"},
+		{"modified.go", "package foo\n"},
+		{"separated.go", "// Package foo does things.\n\n// ----\npackage foo\n"},
+		{"none.go", "package foo\n"},
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, tc.file))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+}
+
+func TestRunLicenseKeepGeneratedNotice(t *testing.T) {
+	const header = "// Copyright 2018 Palantir Technologies, Inc.\n// ----"
+	content := map[string]string{
+		"exact.go":     header + "\n// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+		"modified.go":  "// Copyright 2015 Palantir Technologies\n// Code generated by stringer; DO NOT EDIT.\n// All rights reserved.\n// ----\npackage foo\n",
+		"generated.go": "// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+	}
+	for i, tc := range []struct {
+		name       string
+		keepNotice bool
+		want       map[string]string
+	}{
+		{
+			name:       "generated notices in the header are kept",
+			keepNotice: true,
+			want: map[string]string{
+				"exact.go":     "// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+				"modified.go":  "// Code generated by stringer; DO NOT EDIT.\npackage foo\n",
+				"generated.go": "// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+			},
+		},
+		{
+			name: "generated notices in the header are removed by default",
+			want: map[string]string{
+				"exact.go":     "// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+				"modified.go":  "package foo\n",
+				"generated.go": "// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+			},
+		},
+	} {
+		projectDir := t.TempDir()
+		files := writeFiles(t, projectDir, content)
+		projectParam := licenseplugin.ProjectParam{
+			Licenser:            golicense.NewLicenser(header),
+			HeaderEnd:           regexp.MustCompile(`^// -+$`),
+			KeepGeneratedNotice: tc.keepNotice,
+		}
+
+		err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true}, &bytes.Buffer{})
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		for k, want := range tc.want {
+			got, err := os.ReadFile(filepath.Join(projectDir, k))
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			assert.Equal(t, want, string(got), "Case %d: %s: %s", i, tc.name, k)
+		}
+	}
+}
+
+func TestRunLicenseMergeCopyright(t *testing.T) {
+	const header = "// Copyright 2018 Palantir Technologies, Inc. All rights reserved.\n// Use of this source code is governed by the Apache License."
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go": "// Copyright 2015 The Foo Authors.\n// Use of this source code is governed by a BSD-style license.\n\npackage a\n",
+		"b.go": "/*\n * Copyright 2015 The Foo Authors.\n * Copyright 2016 The Bar Authors.\n *\n * MIT License\n */\n\npackage b\n",
+		"c.go": "// Copyright 2015 The Foo Authors.\n// Copyright 2017 Palantir Technologies, Inc.\n\npackage c\n",
+		"d.go": "package d\n",
+		"e.go": header + "\n\npackage e\n",
+		"f.go": "/* Copyright 2015 The Foo Authors. */\n\npackage f\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:       golicense.NewLicenser(header),
+		MergeCopyright: true,
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, "4 files do not have the correct license header:\n\t"+files[0]+"\n\t"+files[1]+"\n\t"+files[3]+"\n\t"+files[5]+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		"// Copyright 2015 The Foo Authors.\n// Copyright 2018 Palantir Technologies, Inc. All rights reserved.\n// Use of this source code is governed by a BSD-style license.\n\npackage a\n",
+		"/*\n * Copyright 2015 The Foo Authors.\n * Copyright 2016 The Bar Authors.\n * Copyright 2018 Palantir Technologies, Inc. All rights reserved.\n *\n * MIT License\n */\n\npackage b\n",
+		"// Copyright 2015 The Foo Authors.\n// Copyright 2017 Palantir Technologies, Inc.\n\npackage c\n",
+		header + "\npackage d\n",
+		header + "\n\npackage e\n",
+		header + "\n/* Copyright 2015 The Foo Authors. */\n\npackage f\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d", i)
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestRunLicenseThirdPartyMarker(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"ours.go":   "package foo\n",
+		"vendor.go": "// Imported from github.com/other/project.\n\npackage foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:         golicense.NewLicenser(testHeader),
+		ThirdPartyMarker: regexp.MustCompile(`(?m)^// Imported from `),
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{"ours.go", testHeader + "\npackage foo\n"},
+		{"vendor.go", "// Imported from github.com/other/project.\n\npackage foo\n"},
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, tc.file))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+
+	projectParam.ThirdPartyMarker = nil
+	buf := &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, buf)
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "vendor.go")
+}
+
+func TestRunLicenseModule(t *testing.T) {
+	const moduleHeader = "// Copyright 2018 Palantir Technologies, Inc. ({{MODULE}})"
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a/go.mod":      "module example.com/a\n",
+		"a/foo.go":      "package foo\n",
+		"b/go.mod":      "// comment\nmodule \"example.com/b\"\n",
+		"b/sub/bar.go":  "package bar\n",
+		"orphan/baz.go": "package baz\n",
+	})
+	var modules []string
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(moduleHeader),
+		ForModule: func(module string) (licenseplugin.ProjectParam, error) {
+			modules = append(modules, module)
+			return licenseplugin.ProjectParam{
+				Licenser: golicense.NewLicenser(strings.Replace(moduleHeader, licenseplugin.ModulePlaceholder, module, -1)),
+			}, nil
+		},
+	}
+
+	err := licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{"a/foo.go", "// Copyright 2018 Palantir Technologies, Inc. (example.com/a)\npackage foo\n"},
+		{"b/sub/bar.go", "// Copyright 2018 Palantir Technologies, Inc. (example.com/b)\npackage bar\n"},
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, tc.file))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+	sort.Strings(modules)
+	assert.Equal(t, []string{"example.com/a", "example.com/b"}, modules)
+
+	err = licenseplugin.RunLicense(files[4:], projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	assert.EqualError(t, err, "failed to determine module of "+files[4]+": no go.mod file in its directory or any parent directory")
+}
+
+func TestRunLicenseCustomHeaderTieBreak(t *testing.T) {
+	for i, tc := range []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"first declared wins", []string{"first", "second"}, "// first\npackage bar\n"},
+		{"reversed order", []string{"second", "first"}, "// second\npackage bar\n"},
+	} {
+		projectDir := t.TempDir()
+		files := writeFiles(t, projectDir, map[string]string{
+			"custom/bar.go": "package bar\n",
+		})
+		projectParam := licenseplugin.ProjectParam{
+			Licenser: golicense.NewLicenser(testHeader),
+		}
+		for _, name := range tc.names {
+			projectParam.CustomHeaders = append(projectParam.CustomHeaders, golicense.CustomHeaderParam{
+				Name:         name,
+				Licenser:     golicense.NewLicenser("// " + name),
+				IncludePaths: []string{filepath.Dir(files[0])},
+			})
+		}
+
+		err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		got, err := os.ReadFile(files[0])
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestRunLicenseTestHeader(t *testing.T) {
+	const testFileHeader = "// Test code of Palantir Technologies, Inc."
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go":             "package foo\n",
+		"foo_test.go":        "package foo\n",
+		"foo_ext_test.go":    "package foo_test\n",
+		"custom/bar_test.go": "package bar\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:     golicense.NewLicenser(testHeader),
+		TestLicenser: golicense.NewLicenser(testFileHeader),
+		CustomHeaders: []golicense.CustomHeaderParam{
+			{
+				Name:         "custom",
+				Licenser:     golicense.NewLicenser("// Custom"),
+				IncludePaths: []string{filepath.Dir(files[0])},
+			},
+		},
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{"foo.go", testHeader + "\npackage foo\n"},
+		{"foo_test.go", testFileHeader + "\npackage foo\n"},
+		{"foo_ext_test.go", testFileHeader + "\npackage foo_test\n"},
+		{"custom/bar_test.go", "// Custom\npackage bar\n"},
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, tc.file))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+}
+
+func TestRunLicensePackageDoc(t *testing.T) {
+	const docHeader = testHeader + "\n\n// Package documentation follows."
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a/a.go":      "package a\n",
+		"a/doc.go":    "package a\n",
+		"a/a_test.go": "package a\n",
+		"b/c.go":      "package b\n",
+		"b/b.go":      "package b\n",
+		"b/b_test.go": "package b\n",
+		"custom/c.go": "package c\n",
+		"excluded.go": "package foo\n",
+		"foo.go":      "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:           golicense.NewLicenser(testHeader),
+		PackageDocLicenser: golicense.NewLicenser(docHeader),
+		CustomHeaders: []golicense.CustomHeaderParam{
+			{
+				Name:         "custom",
+				Licenser:     golicense.NewLicenser("// Custom"),
+				IncludePaths: []string{filepath.Dir(files[6])},
+			},
+		},
+		Exclude: matcher.Name(`excluded\.go`),
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{"a/a.go", testHeader + "\npackage a\n"},
+		{"a/doc.go", docHeader + "\npackage a\n"},
+		{"a/a_test.go", testHeader + "\npackage a\n"},
+		{"b/b.go", docHeader + "\npackage b\n"},
+		{"b/c.go", testHeader + "\npackage b\n"},
+		{"b/b_test.go", testHeader + "\npackage b\n"},
+		{"custom/c.go", "// Custom\npackage c\n"},
+		{"excluded.go", "package foo\n"},
+		{"foo.go", docHeader + "\npackage foo\n"},
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, tc.file))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	// the package doc file of a package must have the package doc header
+	require.NoError(t, os.WriteFile(files[2], []byte(testHeader+"\npackage a\n"), 0644))
+	outputBuf := &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[2]+"\n", outputBuf.String())
+}
+
+func TestRunLicenseDir(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go":          "package foo\n",
+		"bar/bar.go":      testHeader + "\npackage bar\n",
+		"baz/baz.go":      "package baz\n",
+		"excluded/qux.go": "package qux\n",
+		"README.md":       "readme\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		Exclude:  matcher.Name("excluded"),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\t"+files[2]+"\n\t"+files[4]+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{Verify: true, Output: licenseplugin.OutputNDJSON}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, `{"path":"`+files[2]+`","ruleId":"missing-license-header"}
+{"path":"`+files[4]+`","ruleId":"missing-license-header"}
+`, outputBuf.String())
+
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{"foo.go", testHeader + "\npackage foo\n"},
+		{"baz/baz.go", testHeader + "\npackage baz\n"},
+		{"excluded/qux.go", "package qux\n"},
+		{"README.md", "readme\n"},
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, tc.file))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{Verify: true, CheckCommitYear: true}, &bytes.Buffer{})
+	assert.EqualError(t, err, "commit years cannot be checked when files are streamed")
+}
+
+func TestRunLicenseContentPattern(t *testing.T) {
+	const textHeader = "Copyright 2018 Palantir Technologies, Inc."
+	const hashHeader = "# Copyright 2018 Palantir Technologies, Inc."
+	original := map[string]string{
+		"notes.txt":  "notes\n",
+		"script.txt": "#!/bin/sh\necho script\n",
+	}
+	files := writeFiles(t, t.TempDir(), original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:     "text",
+				Matcher:  matcher.Name(`.*\.txt`),
+				Licenser: golicense.NewLicenser(textHeader),
+			},
+			{
+				Name:           "shell",
+				Matcher:        matcher.Name(`.*\.sh`),
+				Licenser:       golicense.NewLicenser(hashHeader),
+				InsertAfter:    []*regexp.Regexp{regexp.MustCompile(`^#!`)},
+				ContentPattern: regexp.MustCompile(`\A#!/bin/sh\n`),
+			},
+		},
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		textHeader + "\nnotes\n",
+		"#!/bin/sh\n" + hashHeader + "\necho script\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{original["notes.txt"], original["script.txt"]} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+}
+
+func TestRunLicenseEncoding(t *testing.T) {
+	const header = "# Copyright 2018 J\u00fcrgen M\u00fcller"
+	// header encoded in ISO-8859-1
+	const latin1Header = "# Copyright 2018 J\xfcrgen M\xfcller"
+	original := map[string]string{
+		"a.properties": latin1Header + "\nname=Andr\xe9\n",
+		"b.properties": "name=Andr\xe9\n",
+	}
+	files := writeFiles(t, t.TempDir(), original)
+	latin1, err := licenseplugin.LookupEncoding("latin-1")
+	require.NoError(t, err)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:     "properties",
+				Matcher:  matcher.Name(`.*\.properties`),
+				Licenser: golicense.NewLicenser(header),
+				Encoding: latin1,
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		original["a.properties"],
+		latin1Header + "\nname=Andr\xe9\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, file := range files {
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "name=Andr\xe9\n", string(got), "Case %d: %s", i, file)
+	}
+}
+
+func TestRunLicenseInsertAfter(t *testing.T) {
+	const hashHeader = "# Copyright 2018 Palantir Technologies, Inc."
+	original := map[string]string{
+		"a.py": "#!/usr/bin/env python\n# -*- coding: utf-8 -*-\nprint('a')\n",
+		"b.py": "print('b')\n",
+		"c.py": "#!/usr/bin/env python\n" + hashHeader + "\nprint('c')\n",
+	}
+	files := writeFiles(t, t.TempDir(), original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:        "python",
+				Matcher:     matcher.Name(`.*\.py`),
+				Licenser:    golicense.NewLicenser(hashHeader),
+				InsertAfter: []*regexp.Regexp{regexp.MustCompile(`^#!`), regexp.MustCompile(`^# -\*- coding`)},
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\t"+strings.Join(files[:2], "\n\t")+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		"#!/usr/bin/env python\n# -*- coding: utf-8 -*-\n" + hashHeader + "\nprint('a')\n",
+		hashHeader + "\nprint('b')\n",
+		original["c.py"],
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		original["a.py"],
+		original["b.py"],
+		"#!/usr/bin/env python\nprint('c')\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+}
+
+func TestRunLicenseAcceptedHeaders(t *testing.T) {
+	const oldHeader = "// Copyright 2018 Palantir Technologies"
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"new.go":        testHeader + "\npackage foo\n",
+		"old.go":        oldHeader + "\npackage foo\n",
+		"unlicensed.go": "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: licenseplugin.NewAcceptingLicenser(golicense.NewLicenser(testHeader), golicense.NewLicenser(oldHeader)),
+	}
+
+	// verify accepts both headers
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[2]+"\n", outputBuf.String())
+
+	// apply converges on the canonical header
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, file := range files {
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, testHeader+"\npackage foo\n", string(got), "Case %d: %s", i, file)
+	}
+
+	require.NoError(t, os.WriteFile(files[1], []byte(oldHeader+"\npackage foo\n"), 0644))
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, file := range files {
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "package foo\n", string(got), "Case %d: %s", i, file)
+	}
+}
+
+func TestRunLicenseFileTypes(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:      "php",
+				Matcher:   matcher.Name(`.*\.php`),
+				FirstLine: regexp.MustCompile(`^<\?php`),
+			},
+		},
+	}
+
+	for i, tc := range []struct {
+		name      string
+		files     map[string]string
+		runParam  licenseplugin.RunParam
+		wantFiles map[string]string
+	}{
+		{
+			name: "apply adds header after matching first line",
+			files: map[string]string{
+				"foo.php": "<?php\necho 'foo';\n",
+				"bar.php": "echo 'bar';\n",
+				"baz.txt": "baz\n",
+			},
+			wantFiles: map[string]string{
+				"foo.php": "<?php\n" + testHeader + "\necho 'foo';\n",
+				"bar.php": testHeader + "\necho 'bar';\n",
+				"baz.txt": "baz\n",
+			},
+		},
+		{
+			name: "strict apply removes blank lines after header that follows first line",
+			files: map[string]string{
+				"foo.php": "<?php\n" + testHeader + "\n\n\necho 'foo';\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Strict: true,
+			},
+			wantFiles: map[string]string{
+				"foo.php": "<?php\n" + testHeader + "\necho 'foo';\n",
+			},
+		},
+		{
+			name: "apply only processes files of specified types",
+			files: map[string]string{
+				"foo.php": "<?php\necho 'foo';\n",
+				"foo.go":  "package foo\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Types: []string{"php"},
+			},
+			wantFiles: map[string]string{
+				"foo.php": "<?php\n" + testHeader + "\necho 'foo';\n",
+				"foo.go":  "package foo\n",
+			},
+		},
+		{
+			name: "apply only processes Go files if go type is specified",
+			files: map[string]string{
+				"foo.php": "<?php\necho 'foo';\n",
+				"foo.go":  "package foo\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Types: []string{licenseplugin.GoFileType},
+			},
+			wantFiles: map[string]string{
+				"foo.php": "<?php\necho 'foo';\n",
+				"foo.go":  testHeader + "\npackage foo\n",
+			},
+		},
+		{
+			name: "remove removes header after matching first line",
+			files: map[string]string{
+				"foo.php": "<?php\n" + testHeader + "\necho 'foo';\n",
+			},
+			runParam: licenseplugin.RunParam{
+				Remove: true,
+			},
+			wantFiles: map[string]string{
+				"foo.php": "<?php\necho 'foo';\n",
+			},
+		},
+	} {
+		projectDir := t.TempDir()
+		files := writeFiles(t, projectDir, tc.files)
+
+		err := licenseplugin.RunLicense(files, projectParam, tc.runParam, &bytes.Buffer{})
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+
+		for k, want := range tc.wantFiles {
+			got, err := os.ReadFile(filepath.Join(projectDir, k))
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			assert.Equal(t, want, string(got), "Case %d: %s", i, tc.name)
+		}
+	}
+
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.php": "<?php\n" + testHeader + "\necho 'foo';\n",
+	})
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Types: []string{"java"}}, &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown file type "java": must be one of [go php]`)
+}
+
+func TestRunLicenseFileTypeCommentStyle(t *testing.T) {
+	const blockHeader = "/*\n * Copyright 2018 Palantir Technologies, Inc.\n */"
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:     "c",
+				Matcher:  matcher.Name(`.*\.c`),
+				Licenser: licenseplugin.NewReplacingLicenser(golicense.NewLicenser(blockHeader), golicense.NewLicenser(testHeader)),
+			},
+		},
+	}
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.c": testHeader + "\nint foo;\n",
+	})
+
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.False(t, ok, "header with correct text in the wrong comment style should fail verification")
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(filepath.Join(projectDir, "foo.c"))
+	require.NoError(t, err)
+	assert.Equal(t, blockHeader+"\nint foo;\n", string(got))
+
+	ok, err = licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRunLicenseSidecar(t *testing.T) {
+	const sidecarHeader = "// Copyright 2015 Special Inc.\n// All rights reserved."
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"special.go":         "package foo\n",
+		"special.go.license": sidecarHeader + "\n",
+		"none.go":            "package foo\n",
+		"none.go.license":    "",
+		"regular.go":         "package foo\n",
+	})
+
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{"special.go", sidecarHeader + "\npackage foo\n"},
+		{"none.go", "package foo\n"},
+		{"regular.go", testHeader + "\npackage foo\n"},
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, tc.file))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+
+	ok, err = licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRunLicenseFileTypePrecedence(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:      "any-template",
+				Matcher:   matcher.Name(`.*\.tmpl`),
+				FirstLine: regexp.MustCompile(`^any`),
+			},
+			{
+				Name:       "template",
+				Matcher:    matcher.Name(`.+\.tmpl`),
+				Extensions: []string{".tmpl"},
+				FirstLine:  regexp.MustCompile(`^template`),
+			},
+			{
+				Name:       "go-template",
+				Matcher:    matcher.Name(`.+\.go\.tmpl`),
+				Extensions: []string{".go.tmpl"},
+				FirstLine:  regexp.MustCompile(`^go-template`),
+			},
+			{
+				Name:       "other-template",
+				Matcher:    matcher.Name(`.+\.tmpl`),
+				Extensions: []string{".tmp", ".tmpl"},
+				FirstLine:  regexp.MustCompile(`^other`),
+			},
+			{
+				Name:      "named-template",
+				Matcher:   matcher.Name(`.+\.tmpl`, `base\.tmpl`),
+				Filenames: []string{"base.tmpl"},
+				FirstLine: regexp.MustCompile(`^named`),
+			},
+			{
+				Name:     "hash-script",
+				Matcher:  matcher.Name(`.+\.sh`),
+				Licenser: golicense.NewLicenser("# Hash"),
+			},
+			{
+				Name:     "slash-script",
+				Matcher:  matcher.Name(`run\..+`),
+				Licenser: golicense.NewLicenser("// Slash"),
+			},
+		},
+	}
+
+	for i, tc := range []struct {
+		name    string
+		path    string
+		content string
+		want    string
+	}{
+		{
+			name:    "longest extension match is used",
+			path:    "foo.go.tmpl",
+			content: "go-template\nfoo\n",
+			want:    "go-template\n" + testHeader + "\nfoo\n",
+		},
+		{
+			name:    "extension match takes precedence over name match and first declared is used for equal matches",
+			path:    "foo.tmpl",
+			content: "template\nfoo\n",
+			want:    "template\n" + testHeader + "\nfoo\n",
+		},
+		{
+			name:    "file name match takes precedence over extension match",
+			path:    "dir/base.tmpl",
+			content: "named\nfoo\n",
+			want:    "named\n" + testHeader + "\nfoo\n",
+		},
+		{
+			name:    "first declared is used for name matches even if the comment styles differ",
+			path:    "run.sh",
+			content: "foo\n",
+			want:    "# Hash\nfoo\n",
+		},
+	} {
+		outputBuf := &bytes.Buffer{}
+		err := licenseplugin.RunLicenseContent(tc.path, bytes.NewBufferString(tc.content), projectParam, licenseplugin.RunParam{}, outputBuf)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, outputBuf.String(), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestRenderHeader(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser("// Copyright {{YEAR}} Acme"),
+		CustomHeaders: []golicense.CustomHeaderParam{
+			{
+				Name:         "custom",
+				Licenser:     golicense.NewLicenser("// Custom"),
+				IncludePaths: []string{"custom"},
+			},
+		},
+		Exclude: matcher.Name(`excluded\.go`),
+	}
+
+	for i, tc := range []struct {
+		name   string
+		file   string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "header with the year expanded",
+			file:   "foo.go",
+			want:   "// Copyright " + strconv.Itoa(time.Now().Year()) + " Acme\n",
+			wantOK: true,
+		},
+		{
+			name:   "custom header",
+			file:   filepath.Join("custom", "foo.go"),
+			want:   "// Custom\n",
+			wantOK: true,
+		},
+		{
+			name: "excluded file",
+			file: "excluded.go",
+		},
+		{
+			name: "file that is not a Go file",
+			file: "foo.txt",
+		},
+	} {
+		got, ok, err := licenseplugin.RenderHeader(tc.file, projectParam)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantOK, ok, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, got, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestRunLicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]string{
+		"custom/foo.go": "package foo\n",
+		"other.go":      "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		CustomHeaders: []golicense.CustomHeaderParam{
+			{
+				Name:         "custom",
+				Licenser:     golicense.NewLicenser("// Custom"),
+				IncludePaths: []string{filepath.Dir(files[0])},
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicenseFile(files[0], projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[0]+"\n", outputBuf.String())
+
+	// only the provided file is modified and it uses the header that applies to it
+	err = licenseplugin.RunLicenseFile(files[0], projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		"// Custom\npackage foo\n",
+		"package foo\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+
+	// an absolute path is processed in the same manner as the relative path
+	absFile, err := filepath.Abs(files[1])
+	require.NoError(t, err)
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicenseFile(absFile, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+	err = licenseplugin.RunLicenseFile(absFile, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, testHeader+"\npackage foo\n", string(got))
+
+	err = licenseplugin.RunLicenseFile(filepath.Join(dir, "missing.go"), projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to stat")
+
+	err = licenseplugin.RunLicenseFile(dir, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.EqualError(t, err, dir+" is a directory")
+}
+
+func TestRunLicenseErrVerifyFailed(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, Output: licenseplugin.OutputNDJSON}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+
+	outputBuf := &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, Output: licenseplugin.OutputJSON}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.JSONEq(t, `[{"path":"`+filepath.ToSlash(files[0])+`","ruleId":"missing-license-header"}]`, outputBuf.String())
+
+	err = licenseplugin.RunLicenseContent(files[0], strings.NewReader("package foo\n"), projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+
+	// errors other than verification failures are not ErrVerifyFailed
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, NewFilesSince: "HEAD", ProjectDir: t.TempDir()}, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+}
+
+func TestRunLicenseErrFilesChanged(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+	runParam := licenseplugin.RunParam{ErrOnChange: true}
+
+	err := licenseplugin.RunLicense(files, projectParam, runParam, &bytes.Buffer{})
+	assert.ErrorIs(t, err, licenseplugin.ErrFilesChanged)
+	assert.NotErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	got, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, testHeader+"\npackage foo\n", string(got))
+
+	// no files are changed
+	err = licenseplugin.RunLicense(files, projectParam, runParam, &bytes.Buffer{})
+	assert.NoError(t, err)
+
+	// verify is not affected
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, ErrOnChange: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestRunLicenseRequirePackageClause(t *testing.T) {
+	original := map[string]string{
+		"a.go":      testHeader + "\npackage a\n",
+		"b.go":      testHeader + "\n\n// Package b does things.\n//go:build linux\n\n/*\nMore docs.\n*/\npackage b // import \"example.com/b\"\n",
+		"c.go":      testHeader + "\nfunc c() {}\n\npackage c\n",
+		"d.go":      testHeader + "\n/* comment */ package d\n",
+		"e.go":      "package e\n",
+		"notes.txt": testHeader + "\nnotes\n",
+	}
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:             golicense.NewLicenser(testHeader),
+		RequirePackageClause: true,
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:    "text",
+				Matcher: matcher.Name(`.*\.txt`),
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[4]+"\n"+
+		"1 file does not have a package clause after the license header:\n\t"+files[2]+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{Verify: true, Output: licenseplugin.OutputNDJSON}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, `{"path":"`+filepath.ToSlash(files[4])+`","ruleId":"missing-license-header"}`+"\n"+
+		`{"path":"`+filepath.ToSlash(files[2])+`","ruleId":"missing-package-clause"}`+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{Verify: true, Output: licenseplugin.OutputNDJSON}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, `{"path":"`+filepath.ToSlash(files[2])+`","ruleId":"missing-package-clause"}`+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicenseContent(files[2], strings.NewReader(original["c.go"]), projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, "1 file does not have a package clause after the license header:\n\t"+files[2]+"\n", outputBuf.String())
+
+	// apply does not modify files whose header is not followed by a package clause
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{ErrOnChange: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+
+	// the check is not performed unless it is required
+	projectParam.RequirePackageClause = false
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestRunLicenseRejectPlaceholders(t *testing.T) {
+	header := "// Copyright 2024 {{HOLDR}}. All rights reserved."
+	original := map[string]string{
+		"a.go": header + "\n\npackage a\n",
+		"b.go": "package b\n",
+	}
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:           golicense.NewLicenser(header),
+		RejectPlaceholders: true,
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n"+
+		"1 file has the license header with unexpanded placeholders:\n\t"+files[0]+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{Verify: true, Output: licenseplugin.OutputNDJSON}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, `{"path":"`+filepath.ToSlash(files[1])+`","ruleId":"missing-license-header"}`+"\n"+
+		`{"path":"`+filepath.ToSlash(files[0])+`","ruleId":"unexpanded-placeholder"}`+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicenseContent(files[0], strings.NewReader(original["a.go"]), projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, "1 file has the license header with unexpanded placeholders:\n\t"+files[0]+"\n", outputBuf.String())
+
+	// apply fails rather than writing a header that contains a placeholder
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	assert.EqualError(t, err, "license header of "+files[1]+" would contain unexpanded placeholder {{HOLDR}}: no files were modified")
+	content, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, original["b.go"], string(content))
+
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	assert.EqualError(t, err, "license header of "+files[1]+" would contain unexpanded placeholder {{HOLDR}}: no files were modified")
+
+	err = licenseplugin.RunLicenseContent(files[1], strings.NewReader(original["b.go"]), projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	assert.EqualError(t, err, "license header of "+files[1]+" would contain unexpanded placeholder {{HOLDR}}: no files were modified")
+
+	// the check is not performed unless placeholders are rejected
+	projectParam.RejectPlaceholders = false
+	err = licenseplugin.RunLicense(files[:1], projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestRunLicenseManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := writeFiles(t, tmpDir, map[string]string{
+		"a.go":         testHeader + "\npackage a\n",
+		"b.go":         "package b\n",
+		"c.go":         testHeader + "\npackage c\n",
+		"d.go":         "package d\n",
+		"manifest.txt": "# files that must have the license header\na.go\n\nb.go\n",
+		"missing.txt":  "a.go\nmissing.go\n",
+		"excluded.txt": "manifest.txt\n",
+	})
+	projectDir := filepath.Dir(files[0])
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	manifest, err := licenseplugin.ReadManifest(filepath.Join(projectDir, "manifest.txt"), projectDir)
+	require.NoError(t, err)
+	assert.Equal(t, files[:2], manifest)
+
+	_, err = licenseplugin.ReadManifest(filepath.Join(projectDir, "missing.txt"), projectDir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "file missing.go listed in manifest "+filepath.Join(projectDir, "missing.txt")+" does not exist")
+
+	outputBuf := &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{Verify: true, Manifest: manifest}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{Verify: true, Manifest: manifest, ManifestClosed: true}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n"+
+		"1 file has the license header but is not listed in the manifest:\n\t"+files[2]+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{Verify: true, Manifest: manifest, ManifestClosed: true, Output: licenseplugin.OutputNDJSON}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, `{"path":"`+filepath.ToSlash(files[1])+`","ruleId":"missing-license-header"}`+"\n"+
+		`{"path":"`+filepath.ToSlash(files[2])+`","ruleId":"not-in-manifest"}`+"\n", outputBuf.String())
+
+	// files that are listed in the manifest are verified even if they are not among the files of the project
+	err = licenseplugin.RunLicense(files[2:4], projectParam, licenseplugin.RunParam{Verify: true, Manifest: manifest[:1], ManifestClosed: true}, &bytes.Buffer{})
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	err = licenseplugin.RunLicense(files[3:4], projectParam, licenseplugin.RunParam{Verify: true, Manifest: manifest[:1], ManifestClosed: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+
+	excluded, err := licenseplugin.ReadManifest(filepath.Join(projectDir, "excluded.txt"), projectDir)
+	require.NoError(t, err)
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{Verify: true, Manifest: excluded}, &bytes.Buffer{})
+	assert.EqualError(t, err, "file "+excluded[0]+" listed in the manifest is excluded or is not a Go file or a file of a configured file type")
+
+	err = licenseplugin.RunLicense(files[:4], projectParam, licenseplugin.RunParam{Manifest: manifest}, &bytes.Buffer{})
+	assert.EqualError(t, err, "manifests can only be verified")
+}
+
+func TestRunLicenseFixAfter(t *testing.T) {
+	projectDir := t.TempDir()
+	original := map[string]string{
+		"a.go": "package a\n",
+		"b.go": testHeader + "\npackage b\n",
+		"c.go": "package c\n",
+	}
+	files := writeFiles(t, projectDir, original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+	runParam := licenseplugin.RunParam{Verify: true, FixAfter: true}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\t"+files[0]+"\n\t"+files[2]+"\n"+
+		"2 files were fixed:\n\t"+files[0]+"\n\t"+files[2]+"\n", outputBuf.String())
+	for i, file := range files {
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, testHeader+"\npackage "+strings.TrimSuffix(filepath.Base(file), ".go")+"\n", string(got), "Case %d: %s", i, file)
+	}
+
+	// nothing is reported or fixed if all files pass verification
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.Equal(t, "", outputBuf.String())
+
+	require.NoError(t, os.WriteFile(files[1], []byte(original["a.go"]), 0644))
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{Verify: true, FixAfter: true, ErrOnChange: true}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrFilesChanged)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n"+
+		"1 file was fixed:\n\t"+files[1]+"\n", outputBuf.String())
+
+	// the files that fail verification are fixed by adding the header even if Remove is true
+	require.NoError(t, os.WriteFile(files[1], []byte("package b\n"), 0644))
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, Remove: true, FixAfter: true}, outputBuf)
+	require.NoError(t, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n"+
+		"1 file was fixed:\n\t"+files[1]+"\n", outputBuf.String())
+	for i, file := range files {
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, testHeader+"\npackage "+strings.TrimSuffix(filepath.Base(file), ".go")+"\n", string(got), "Case %d: %s", i, file)
+	}
+}
+
+func TestRunLicenseTimings(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go": "package a\n",
+		"b.go": testHeader + "\npackage b\n",
+		"c.go": "package c\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	for i, run := range []func(runParam licenseplugin.RunParam) error{
+		func(runParam licenseplugin.RunParam) error {
+			return licenseplugin.RunLicense(files, projectParam, runParam, io.Discard)
+		},
+		func(runParam licenseplugin.RunParam) error {
+			return licenseplugin.RunLicenseDir(projectDir, projectParam, runParam, io.Discard)
+		},
+	} {
+		timingsBuf := &bytes.Buffer{}
+		err := run(licenseplugin.RunParam{Verify: true, Timings: timingsBuf})
+		require.Equal(t, licenseplugin.ErrVerifyFailed, err, "Case %d", i)
+
+		lines := strings.Split(strings.TrimSuffix(timingsBuf.String(), "\n"), "\n")
+		require.Len(t, lines, len(files), "Case %d", i)
+		var gotFiles []string
+		var prev time.Duration
+		for j, line := range lines {
+			parts := strings.Split(line, "\t")
+			require.Len(t, parts, 2, "Case %d: %s", i, line)
+			d, err := time.ParseDuration(parts[0])
+			require.NoError(t, err, "Case %d: %s", i, line)
+			if j > 0 {
+				assert.True(t, d <= prev, "Case %d: timings are not sorted by duration: %s", i, timingsBuf.String())
+			}
+			prev = d
+			gotFiles = append(gotFiles, parts[1])
+		}
+		sort.Strings(gotFiles)
+		assert.Equal(t, files, gotFiles, "Case %d", i)
+	}
+}
+
+func TestRunLicenseWritesFilesAtomically(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go": "package a\n",
+		"b.go": "package b\n",
+	})
+	require.NoError(t, os.Chmod(files[1], 0755))
+	link := filepath.Join(filepath.Dir(files[0]), "link.go")
+	require.NoError(t, os.Symlink(filepath.Base(files[0]), link))
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	err := licenseplugin.RunLicense([]string{link, files[1]}, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	// the file that the link refers to is written rather than the link being replaced
+	target, err := os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Base(files[0]), target)
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err, "Case %d: %s", i, file)
+		assert.Equal(t, testHeader+"\npackage "+strings.TrimSuffix(filepath.Base(file), ".go")+"\n", string(content), "Case %d: %s", i, file)
+	}
+	fi, err := os.Stat(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+
+	// no temporary files remain
+	entries, err := os.ReadDir(projectDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"a.go", "b.go", "link.go"}, names)
+}
+
+func TestRunLicenseStagingFailureModifiesNoFiles(t *testing.T) {
+	// the name of the temporary file of the second file exceeds the maximum length of a file name (while the name of its
+	// sidecar file does not), so staging it fails
+	original := map[string]string{
+		"a.go":                                 "package a\n",
+		"b" + strings.Repeat("x", 243) + ".go": "package b\n",
+	}
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, original)
+	// temporary files left behind by an interrupted operation are removed
+	stale := filepath.Join(projectDir, ".a.go.tmp-123")
+	require.NoError(t, os.WriteFile(stale, []byte("package a\n"), 0644))
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write file "+files[1])
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err, "Case %d: %s", i, file)
+		assert.Equal(t, original[filepath.Base(file)], string(content), "Case %d: %s", i, file)
+	}
+	entries, err := os.ReadDir(projectDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files remain")
+}
+
+func TestRunLicenseBackup(t *testing.T) {
+	projectDir := t.TempDir()
+	original := map[string]string{
+		"a.go": "package a\n",
+		"b.go": testHeader + "\npackage b\n",
+		"c.go": "package c\n",
+	}
+	files := writeFiles(t, projectDir, original)
+	require.NoError(t, os.Chmod(files[2], 0600))
+	// existing backups are overwritten
+	require.NoError(t, os.WriteFile(files[0]+licenseplugin.BackupSuffix, []byte("stale\n"), 0644))
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Backup: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file     string
+		wantMode os.FileMode
+	}{
+		{"a.go", 0644},
+		{"c.go", 0600},
+	} {
+		backup := filepath.Join(projectDir, tc.file) + licenseplugin.BackupSuffix
+		got, err := os.ReadFile(backup)
+		require.NoError(t, err, "Case %d: %s", i, tc.file)
+		assert.Equal(t, original[tc.file], string(got), "Case %d: %s", i, tc.file)
+		fi, err := os.Stat(backup)
+		require.NoError(t, err, "Case %d: %s", i, tc.file)
+		assert.Equal(t, tc.wantMode, fi.Mode().Perm(), "Case %d: %s", i, tc.file)
+	}
+	// files that are not modified are not backed up
+	_, err = os.Stat(files[1] + licenseplugin.BackupSuffix)
+	assert.True(t, os.IsNotExist(err))
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true, Backup: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(files[1] + licenseplugin.BackupSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, original["b.go"], string(got))
+}
+
+func TestRunLicenseContinueOnFileErrors(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go": "package a\n",
+		"c.go": "package c\n",
+	})
+	// a directory whose name matches a Go file cannot be read
+	dirFile := filepath.Join(filepath.Dir(files[0]), "b.go")
+	require.NoError(t, os.Mkdir(dirFile, 0755))
+	files = []string{files[0], dirFile, files[1]}
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read "+dirFile)
+
+	outputBuf := &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, ContinueOnFileErrors: true}, outputBuf)
+	require.Error(t, err)
+	assert.Regexp(t, `^failed to process 1 file:\n\t`+regexp.QuoteMeta(dirFile+": failed to read "+dirFile+": "), err.Error())
+	assert.Equal(t, "2 files do not have the correct license header:\n\t"+files[0]+"\n\t"+files[2]+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{ContinueOnFileErrors: true}, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Regexp(t, `^failed to process 1 file:`, err.Error())
+	for _, file := range []string{files[0], files[2]} {
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(got), testHeader+"\n"), "header was not applied to %s", file)
+	}
+}
+
+func TestRunLicenseFailFast(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go": testHeader + "\npackage a\n",
+		"b.go": "package b\n",
+		"c.go": "package c\n",
+		"d.go": testHeader + "\n// Package d does things.\n",
+		"e.go": testHeader + "\n// Package e does things.\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:             golicense.NewLicenser(testHeader),
+		RequirePackageClause: true,
+	}
+
+	for i, tc := range []struct {
+		name       string
+		run        func(runParam licenseplugin.RunParam, stdout io.Writer) error
+		output     licenseplugin.OutputFormat
+		wantOutput string
+	}{
+		{
+			name: "files",
+			run: func(runParam licenseplugin.RunParam, stdout io.Writer) error {
+				return licenseplugin.RunLicense(files, projectParam, runParam, stdout)
+			},
+			wantOutput: "1 file does not have the correct license header:\n\t" + files[1] + "\n",
+		},
+		{
+			name: "streamed files",
+			run: func(runParam licenseplugin.RunParam, stdout io.Writer) error {
+				return licenseplugin.RunLicenseDir(projectDir, projectParam, runParam, stdout)
+			},
+			wantOutput: "1 file does not have the correct license header:\n\t" + files[1] + "\n",
+		},
+		{
+			name: "ndjson",
+			run: func(runParam licenseplugin.RunParam, stdout io.Writer) error {
+				return licenseplugin.RunLicense(files, projectParam, runParam, stdout)
+			},
+			output:     licenseplugin.OutputNDJSON,
+			wantOutput: `{"path":"` + files[1] + `","ruleId":"missing-license-header"}` + "\n",
+		},
+		{
+			name: "package clause",
+			run: func(runParam licenseplugin.RunParam, stdout io.Writer) error {
+				return licenseplugin.RunLicense([]string{files[0], files[3], files[4]}, projectParam, runParam, stdout)
+			},
+			wantOutput: "1 file does not have a package clause after the license header:\n\t" + files[3] + "\n",
+		},
+	} {
+		outputBuf := &bytes.Buffer{}
+		err := tc.run(licenseplugin.RunParam{Verify: true, FailFast: true, Output: tc.output}, outputBuf)
+		assert.Equal(t, licenseplugin.ErrVerifyFailed, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantOutput, outputBuf.String(), "Case %d: %s", i, tc.name)
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\t"+files[1]+"\n\t"+files[2]+"\n"+
+		"2 files do not have a package clause after the license header:\n\t"+files[3]+"\n\t"+files[4]+"\n", outputBuf.String())
+}
+
+func TestRunLicenseModifiedSince(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"new.go": "package foo\n",
+		"old.go": "package foo\n",
+	})
+	since := time.Now().Add(-time.Hour)
+	old := since.Add(-time.Hour)
+	require.NoError(t, os.Chtimes(files[1], old, old))
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, ModifiedSince: since}, outputBuf)
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[0]+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{ModifiedSince: since}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{files[0], testHeader + "\npackage foo\n"},
+		{files[1], "package foo\n"},
+	} {
+		got, err := os.ReadFile(tc.file)
+		require.NoError(t, err, "Case %d: %s", i, tc.file)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+}
+
+func TestRunLicenseMaxDepth(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go":         "package a\n",
+		"pkg/b.go":     "package b\n",
+		"pkg/sub/c.go": "package c\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, ProjectDir: projectDir, MaxDepth: 2}, outputBuf)
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\t"+files[0]+"\n\t"+files[1]+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicenseDir(projectDir, projectParam, licenseplugin.RunParam{MaxDepth: 1}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, tc := range []struct {
+		file string
+		want string
+	}{
+		{files[0], testHeader + "\npackage a\n"},
+		{files[1], "package b\n"},
+		{files[2], "package c\n"},
+	} {
+		got, err := os.ReadFile(tc.file)
+		require.NoError(t, err, "Case %d: %s", i, tc.file)
+		assert.Equal(t, tc.want, string(got), "Case %d: %s", i, tc.file)
+	}
+}
+
+func TestRunLicenseRequired(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"a.go":          "package a\n",
+		"api/b.go":      "package b\n",
+		"api/c.go":      testHeader + "\npackage c\n",
+		"internal/d.go": "package d\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		Required: matcher.Path(filepath.Join(filepath.Dir(files[0]), "api")),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+
+	// content of files that are not required is always considered valid
+	err = licenseplugin.RunLicenseContent(files[3], strings.NewReader("package d\n"), projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.NoError(t, err)
+	err = licenseplugin.RunLicenseContent(files[1], strings.NewReader("package b\n"), projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+
+	// applying licenses is not affected
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err, "Case %d: %s", i, file)
+		assert.True(t, strings.HasPrefix(string(content), testHeader), "Case %d: %s", i, file)
+	}
+}
+
+func TestRunLicenseDryRun(t *testing.T) {
+	original := map[string]string{
+		"a.go": "package a\n",
+		"b.go": testHeader + "\npackage b\n",
+		"c.go": "// Copyright 2018 Acme Inc.\npackage c\n",
+	}
+	files := writeFiles(t, t.TempDir(), original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: licenseplugin.NewCopyrightLineLicenser(golicense.NewLicenser(testHeader)),
+	}
+
+	for i, tc := range []struct {
+		name       string
+		runParam   licenseplugin.RunParam
+		wantOutput string
+	}{
+		{
+			name:     "apply text",
+			runParam: licenseplugin.RunParam{DryRun: true},
+			wantOutput: "add " + files[0] + "\n" +
+				"update " + files[2] + "\n",
+		},
+		{
+			name:     "remove ndjson",
+			runParam: licenseplugin.RunParam{DryRun: true, Remove: true, Output: licenseplugin.OutputNDJSON},
+			wantOutput: `{"path":"` + filepath.ToSlash(files[0]) + `","action":"none"}` + "\n" +
+				`{"path":"` + filepath.ToSlash(files[1]) + `","action":"remove"}` + "\n" +
+				`{"path":"` + filepath.ToSlash(files[2]) + `","action":"none"}` + "\n",
+		},
+	} {
+		outputBuf := &bytes.Buffer{}
+		err := licenseplugin.RunLicense(files, projectParam, tc.runParam, outputBuf)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantOutput, outputBuf.String(), "Case %d: %s", i, tc.name)
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{DryRun: true, DryRunHeaders: true, ErrOnChange: true, Output: licenseplugin.OutputJSON}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrFilesChanged)
+	var got []map[string]string
+	require.NoError(t, json.Unmarshal(outputBuf.Bytes(), &got))
+	assert.Equal(t, []map[string]string{
+		{"path": filepath.ToSlash(files[0]), "action": "add", "header": testHeader + "\n"},
+		{"path": filepath.ToSlash(files[1]), "action": "none"},
+		{"path": filepath.ToSlash(files[2]), "action": "update", "header": testHeader + "\n"},
+	}, got)
+
+	// files are not modified
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, original[filepath.Base(file)], string(content), "Case %d: %s", i, file)
+	}
+}
+
+func TestRunLicenseDryRunPatchFile(t *testing.T) {
+	original := map[string]string{
+		"a.go": "package a\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n",
+		"b.go": testHeader + "\npackage b\n",
+		"c.go": "// Copyright 2018 Acme Inc.\npackage c",
+	}
+	files := writeFiles(t, t.TempDir(), original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: licenseplugin.NewCopyrightLineLicenser(golicense.NewLicenser(testHeader)),
+	}
+	patchFile := filepath.Join(t.TempDir(), "changes.patch")
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{DryRun: true, PatchFile: patchFile}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(patchFile)
+	require.NoError(t, err)
+	assert.Equal(t, "--- a/"+filepath.ToSlash(files[0])+"\n"+
+		"+++ b/"+filepath.ToSlash(files[0])+"\n"+
+		"@@ -1,3 +1,4 @@\n"+
+		"+"+testHeader+"\n"+
+		" package a\n"+
+		" \n"+
+		" func a() {}\n"+
+		"--- a/"+filepath.ToSlash(files[2])+"\n"+
+		"+++ b/"+filepath.ToSlash(files[2])+"\n"+
+		"@@ -1,2 +1,2 @@\n"+
+		"-// Copyright 2018 Acme Inc.\n"+
+		"+"+testHeader+"\n"+
+		" package c\n"+
+		"\\ No newline at end of file\n", string(got))
+
+	// files are not modified
+	for i, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, original[filepath.Base(file)], string(content), "Case %d: %s", i, file)
+	}
+}
+
+func TestRunLicenseContent(t *testing.T) {
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		name       string
+		path       string
+		content    string
+		runParam   licenseplugin.RunParam
+		wantFail   bool
+		wantOutput string
+	}{
+		{
+			name:       "apply writes content with header",
+			path:       "foo.go",
+			content:    "package foo\n",
+			wantOutput: testHeader + "\npackage foo\n",
+		},
+		{
+			name:    "remove writes content without header",
+			path:    "foo.go",
+			content: testHeader + "\npackage foo\n",
+			runParam: licenseplugin.RunParam{
+				Remove: true,
+			},
+			wantOutput: "package foo\n",
+		},
+		{
+			name:       "apply writes content of non-matching file unmodified",
+			path:       "foo.txt",
+			content:    "foo\n",
+			wantOutput: "foo\n",
+		},
+		{
+			name:    "verify of valid content writes nothing",
+			path:    "foo.go",
+			content: testHeader + "\npackage foo\n",
+			runParam: licenseplugin.RunParam{
+				Verify: true,
+			},
+		},
+		{
+			name:    "verify of invalid content fails",
+			path:    "foo.go",
+			content: "package foo\n",
+			runParam: licenseplugin.RunParam{
+				Verify: true,
+			},
+			wantFail:   true,
+			wantOutput: "1 file does not have the correct license header:\n\tfoo.go\n",
+		},
+		{
+			name:    "verify of invalid content with absolute path fails",
+			path:    filepath.Join(wd, "foo.go"),
+			content: "package foo\n",
+			runParam: licenseplugin.RunParam{
+				Verify: true,
+			},
+			wantFail:   true,
+			wantOutput: "1 file does not have the correct license header:\n\tfoo.go\n",
+		},
+	} {
+		outputBuf := &bytes.Buffer{}
+		err := licenseplugin.RunLicenseContent(tc.path, bytes.NewBufferString(tc.content), projectParam, tc.runParam, outputBuf)
+		if tc.wantFail {
+			require.EqualError(t, err, "", "Case %d: %s", i, tc.name)
+		} else {
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+		}
+		assert.Equal(t, tc.wantOutput, outputBuf.String(), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestVerifyFiles(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go": "package foo\n",
+		"bar.go": testHeader + "\npackage bar\n",
+	})
+
+	for i, tc := range []struct {
+		name     string
+		runParam licenseplugin.RunParam
+		want     string
+	}{
+		{
+			name: "output is not colored by default when not writing to a terminal",
+			want: "1 file does not have the correct license header:\n\t" + files[1] + "\n",
+		},
+		{
+			name: "output is colored if color is always",
+			runParam: licenseplugin.RunParam{
+				Color: licenseplugin.ColorAlways,
+			},
+			want: "\x1b[1m1\x1b[0m file does not have the correct license header:\n\t\x1b[31m" + files[1] + "\x1b[0m\n",
+		},
+		{
+			name: "output is not colored if color is never",
+			runParam: licenseplugin.RunParam{
+				Color: licenseplugin.ColorNever,
+			},
+			want: "1 file does not have the correct license header:\n\t" + files[1] + "\n",
+		},
+		{
+			name: "paths are relative to project directory if path base is project",
+			runParam: licenseplugin.RunParam{
+				ProjectDir: projectDir,
+				PathBase:   licenseplugin.PathBaseProject,
+			},
+			want: "1 file does not have the correct license header:\n\tfoo.go\n",
+		},
+		{
+			name: "paths are relative to working directory if path base is cwd",
+			runParam: licenseplugin.RunParam{
+				ProjectDir: projectDir,
+				PathBase:   licenseplugin.PathBaseCWD,
+			},
+			want: "1 file does not have the correct license header:\n\t" + files[1] + "\n",
+		},
+	} {
+		outputBuf := &bytes.Buffer{}
+		ok, err := licenseplugin.VerifyFiles(files, licenseplugin.ProjectParam{
+			Licenser: golicense.NewLicenser(testHeader),
+		}, tc.runParam, outputBuf)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.False(t, ok, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, outputBuf.String(), "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestVerifyFilesDuplicateHeaders(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": testHeader + "\n" + testHeader + "\npackage foo\n",
+		"bar.go": testHeader + "\npackage bar\n\n// " + testHeader + "\n",
+	})
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}, licenseplugin.RunParam{}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+}
+
+func TestVerifyFilesStrict(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": testHeader + "\n\npackage foo\n",
+		"bar.go": testHeader + "\npackage bar\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	outputBuf := &bytes.Buffer{}
+	ok, err = licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{Strict: true}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+}
+
+func TestRunLicensePreserveLeadingBlankLines(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": "\n\npackage foo\n",
+		"bar.go": testHeader + "\n\npackage bar\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:                  golicense.NewLicenser(testHeader),
+		PreserveLeadingBlankLines: true,
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Strict: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	want := map[string]string{
+		files[0]: testHeader + "\n\npackage bar\n",
+		files[1]: testHeader + "\n\n\npackage foo\n",
+	}
+	for file, wantContent := range want {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, wantContent, string(content), "Unexpected content for %s", file)
+	}
+
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{Strict: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestVerifyFilesForeignLicense(t *testing.T) {
+	const mitHeader = "// Copyright 2016 Foo\n//\n// Permission is hereby granted, free of charge, to any person obtaining a copy"
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": mitHeader + "\npackage foo\n",
+		"bar.go": "package bar\n",
+		"baz.go": testHeader + "\npackage baz\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		ForeignLicenses: []licenseplugin.ForeignLicenseParam{
+			{
+				Name:      "MIT",
+				Signature: regexp.MustCompile(`Permission is hereby granted, free of charge`),
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[0]+"\n"+
+		"1 file has the license header of a different license:\n\t"+files[2]+" (MIT)\n", outputBuf.String())
+}
+
+func TestVerifyFilesMissingCopyright(t *testing.T) {
+	const header = "// Copyright {{YEAR}} Palantir Technologies, Inc.\n//\n// Licensed under the Apache License, Version 2.0."
+	const body = "//\n// Licensed under the Apache License, Version 2.0.\n"
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"correct.go":    "// Copyright 2016 Palantir Technologies, Inc.\n" + body + "package foo\n",
+		"invalid.go":    "// Copyright Palantir Technologies\n" + body + "package foo\n",
+		"missing.go":    body + "package foo\n",
+		"unlicensed.go": "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(header),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[3]+"\n"+
+		"2 files have the license header without a valid copyright line:\n\t"+files[1]+"\n\t"+files[2]+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	ok, err = licenseplugin.VerifyFiles(files[2:3], projectParam, licenseplugin.RunParam{Output: licenseplugin.OutputNDJSON}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, `{"path":"`+files[2]+`","ruleId":"missing-copyright-line"}`+"\n", outputBuf.String())
+}
+
+func TestRunLicenseWrongDelimiters(t *testing.T) {
+	const header = "/*\n * Copyright 2018 Palantir Technologies, Inc.\n */"
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"correct.go":    header + "\npackage foo\n",
+		"javadoc.go":    "/**\n * Copyright 2018 Palantir Technologies, Inc.\n */\npackage foo\n",
+		"unlicensed.go": "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(header),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[2]+"\n"+
+		"1 file has the license header with different comment delimiters:\n\t"+files[1]+"\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	ok, err = licenseplugin.VerifyFiles(files[1:2], projectParam, licenseplugin.RunParam{Output: licenseplugin.OutputNDJSON}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, `{"path":"`+files[1]+`","ruleId":"wrong-comment-delimiters"}`+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, header+"\npackage foo\n", string(content), "Unexpected content for %s", file)
+	}
+}
+
+func TestVerifyFilesCountOnly(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": "package foo\n",
+		"bar.go": "package bar\n",
+		"baz.go": testHeader + "\npackage baz\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+	runParam := licenseplugin.RunParam{
+		CountOnly: true,
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "2\n", outputBuf.String())
+
+	outputBuf.Reset()
+	ok, err = licenseplugin.VerifyFiles(files[1:2], projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "0\n", outputBuf.String())
+}
+
+func TestVerifyFilesGroupByDir(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go":        "package foo\n",
+		"a/b/c.go":      "package b\n",
+		"a/b.go":        "package a\n",
+		"a/ba.go":       "package a\n",
+		"a/licensed.go": testHeader + "\npackage a\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{
+		GroupByDir: true,
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+	}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, `4 files do not have the correct license header:
+	./
+		foo.go
+	a/
+		a/b.go
+		a/ba.go
+	a/b/
+		a/b/c.go
+`, outputBuf.String())
+}
+
+func TestVerifyFilesSARIF(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go": "// MIT License\npackage foo\n",
+		"bar.go": "package bar\n",
+		"baz.go": testHeader + "\npackage baz\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		ForeignLicenses: []licenseplugin.ForeignLicenseParam{
+			{
+				Name:      "MIT",
+				Signature: regexp.MustCompile(`MIT License`),
+			},
+		},
+	}
+	runParam := licenseplugin.RunParam{
+		Output:     licenseplugin.OutputSARIF,
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(outputBuf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	var got []string
+	for _, result := range log.Runs[0].Results {
+		require.Len(t, result.Locations, 1)
+		got = append(got, result.RuleID+" "+result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	assert.Equal(t, []string{"missing-license-header bar.go", "foreign-license-header foo.go"}, got)
+
+	outputBuf.Reset()
+	ok, err = licenseplugin.VerifyFiles(files[1:2], projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, json.Unmarshal(outputBuf.Bytes(), &log))
+	assert.Empty(t, log.Runs[0].Results)
+}
+
+func TestRunLicenseNDJSON(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go":     "package a\n",
+		"b.go":     testHeader + "\npackage b\n",
+		"c/c.go":   "// MIT License\npackage c\n",
+		"d.go":     "package d\n",
+		"e/f/g.go": "package g\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		ForeignLicenses: []licenseplugin.ForeignLicenseParam{
+			{
+				Name:      "MIT",
+				Signature: regexp.MustCompile(`MIT License`),
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:     true,
+		Output:     licenseplugin.OutputNDJSON,
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+	}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, `{"path":"a.go","ruleId":"missing-license-header"}
+{"path":"c/c.go","ruleId":"foreign-license-header","foreignLicense":"MIT"}
+{"path":"d.go","ruleId":"missing-license-header"}
+{"path":"e/f/g.go","ruleId":"missing-license-header"}
+`, outputBuf.String())
+
+	outputBuf.Reset()
+	err = licenseplugin.RunLicense(files[1:2], projectParam, licenseplugin.RunParam{
+		Verify: true,
+		Output: licenseplugin.OutputNDJSON,
+	}, outputBuf)
+	require.NoError(t, err)
+	assert.Equal(t, "", outputBuf.String())
+}
+
+func TestRunLicenseTextLayoutTable(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"a.go":     "package a\n",
+		"b.go":     testHeader + "\npackage b\n",
+		"c/c.go":   "// MIT License\npackage c\n",
+		"e/f/g.go": "package g\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		ForeignLicenses: []licenseplugin.ForeignLicenseParam{
+			{
+				Name:      "MIT",
+				Signature: regexp.MustCompile(`MIT License`),
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:     true,
+		TextLayout: licenseplugin.TextLayoutTable,
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+		Color:      licenseplugin.ColorAlways,
+	}, outputBuf)
+	assert.ErrorIs(t, err, licenseplugin.ErrVerifyFailed)
+	assert.Equal(t, `PATH      STATUS
+a.go      missing-license-header
+c/c.go    foreign-license-header (MIT)
+e/f/g.go  missing-license-header
+`, outputBuf.String())
+
+	outputBuf.Reset()
+	err = licenseplugin.RunLicense(files[1:2], projectParam, licenseplugin.RunParam{
+		Verify:     true,
+		TextLayout: licenseplugin.TextLayoutTable,
+	}, outputBuf)
+	require.NoError(t, err)
+	assert.Equal(t, "", outputBuf.String())
+}
+
+func TestVerifyFilesGitHub(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go":   "// MIT License\npackage foo\n",
+		"a,b/c.go": "package c\n",
+		"baz.go":   testHeader + "\npackage baz\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		ForeignLicenses: []licenseplugin.ForeignLicenseParam{
+			{
+				Name:      "MIT",
+				Signature: regexp.MustCompile(`MIT License`),
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, licenseplugin.RunParam{
+		Output:     licenseplugin.OutputGitHub,
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+	}, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "::error file=a%2Cb/c.go,line=1,title=missing-license-header::File does not have the correct license header\n"+
+		"::error file=foo.go,line=1,title=foreign-license-header::File has the license header of a different license (MIT)\n", outputBuf.String())
+}
+
+func TestVerifyFilesCheckstyle(t *testing.T) {
+	projectDir := t.TempDir()
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go":   "// MIT License\npackage foo\n",
+		"a&b/c.go": "package c\n",
+		"baz.go":   testHeader + "\npackage baz\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+		ForeignLicenses: []licenseplugin.ForeignLicenseParam{
+			{
+				Name:      "MIT",
+				Signature: regexp.MustCompile(`MIT License`),
+			},
+		},
+	}
+	runParam := licenseplugin.RunParam{
+		Output:     licenseplugin.OutputCheckstyle,
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+	}
+
+	outputBuf := &bytes.Buffer{}
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="a&amp;b/c.go">
+    <error line="1" severity="error" message="File does not have the correct license header" source="license-plugin.missing-license-header"></error>
+  </file>
+  <file name="foo.go">
+    <error line="1" severity="error" message="File has the license header of a different license (MIT)" source="license-plugin.foreign-license-header"></error>
+  </file>
+</checkstyle>
+`, outputBuf.String())
+
+	outputBuf.Reset()
+	ok, err = licenseplugin.VerifyFiles(files[1:2], projectParam, runParam, outputBuf)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3"></checkstyle>
+`, outputBuf.String())
+}
+
+func TestVerifyFilesFailuresFile(t *testing.T) {
+	projectDir := t.TempDir()
+	failuresFile := filepath.Join(t.TempDir(), "failures.txt")
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+	runParam := licenseplugin.RunParam{
+		FailuresFile: failuresFile,
+		ProjectDir:   projectDir,
+		PathBase:     licenseplugin.PathBaseProject,
+	}
+
+	files := writeFiles(t, projectDir, map[string]string{
+		"foo.go": "package foo\n",
+		"bar.go": "package bar\n",
+		"baz.go": testHeader + "\npackage baz\n",
+	})
+	ok, err := licenseplugin.VerifyFiles(files, projectParam, runParam, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	got, err := os.ReadFile(failuresFile)
+	require.NoError(t, err)
+	assert.Equal(t, "bar.go\nfoo.go\n", string(got))
+
+	ok, err = licenseplugin.VerifyFiles(files[1:2], projectParam, runParam, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	got, err = os.ReadFile(failuresFile)
+	require.NoError(t, err)
+	assert.Equal(t, "", string(got))
+}
+
+func TestRunLicenseProjects(t *testing.T) {
+	const otherHeader = `// Copyright 2018 Other Inc.`
+
+	projectDir := t.TempDir()
+	rootFiles := writeFiles(t, projectDir, map[string]string{
+		"foo.go": "package foo\n",
+		"bar.go": testHeader + "\npackage bar\n",
+	})
+	subFiles := writeFiles(t, projectDir, map[string]string{
+		"sub/foo.go": testHeader + "\npackage foo\n",
+		"sub/bar.go": otherHeader + "\npackage bar\n",
+	})
+	projects := []licenseplugin.Project{
+		{
+			Files: rootFiles,
+			Param: licenseplugin.ProjectParam{
+				Licenser: golicense.NewLicenser(testHeader),
+			},
+		},
+		{
+			Files: subFiles,
+			Param: licenseplugin.ProjectParam{
+				Licenser: golicense.NewLicenser(otherHeader),
+			},
+		},
+	}
+	runParam := licenseplugin.RunParam{
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+	}
+
+	verifyParam := runParam
+	verifyParam.Verify = true
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicenseProjects(projects, verifyParam, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\tfoo.go\n\tsub/foo.go\n", outputBuf.String())
+
+	maxChangesParam := runParam
+	maxChangesParam.MaxChanges = 1
+	err = licenseplugin.RunLicenseProjects(projects, maxChangesParam, &bytes.Buffer{})
+	assert.EqualError(t, err, "2 files would be modified, which exceeds the maximum of 1: no files were modified")
+
+	err = licenseplugin.RunLicenseProjects(projects, runParam, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(filepath.Join(projectDir, "sub", "foo.go"))
+	require.NoError(t, err)
+	assert.Equal(t, otherHeader+"\n"+testHeader+"\npackage foo\n", string(got))
+}
+
+func TestRunLicenseProjectsSubProjectInDir(t *testing.T) {
+	projectDir := t.TempDir()
+	rootFiles := writeFiles(t, projectDir, map[string]string{
+		"foo.go": "package foo\n",
+	})
+	subFiles := writeFiles(t, projectDir, map[string]string{
+		"sub/foo.go":          "package foo\n",
+		"sub/special/foo.go":  "package foo\n",
+		"sub/excluded/foo.go": "package foo\n",
+		"sub/gen/foo.go":      "package foo\n",
+	})
+	// the parameters of the sub-project match paths relative to the sub-project directory
+	subProjectParam, err := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser("// Sub"),
+		CustomHeaders: []golicense.CustomHeaderParam{
+			{
+				Name:         "special",
+				Licenser:     golicense.NewLicenser("// Special"),
+				IncludePaths: []string{"special"},
+			},
+		},
+		Exclude:  matcher.Path("excluded"),
+		Required: matcher.Not(matcher.Path("gen")),
+	}.InDir(filepath.Join(projectDir, "sub"))
+	require.NoError(t, err)
+	projects := []licenseplugin.Project{
+		{
+			Files: rootFiles,
+			Param: licenseplugin.ProjectParam{
+				Licenser: golicense.NewLicenser(testHeader),
+			},
+		},
+		{
+			Files: subFiles,
+			Param: subProjectParam,
+		},
+	}
+	runParam := licenseplugin.RunParam{
+		ProjectDir: projectDir,
+		PathBase:   licenseplugin.PathBaseProject,
+	}
+
+	verifyParam := runParam
+	verifyParam.Verify = true
+	outputBuf := &bytes.Buffer{}
+	err = licenseplugin.RunLicenseProjects(projects, verifyParam, outputBuf)
+	assert.Equal(t, licenseplugin.ErrVerifyFailed, err)
+	assert.Equal(t, "3 files do not have the correct license header:\n\tfoo.go\n\tsub/foo.go\n\tsub/special/foo.go\n", outputBuf.String())
+
+	err = licenseplugin.RunLicenseProjects(projects, runParam, &bytes.Buffer{})
+	require.NoError(t, err)
+	for file, want := range map[string]string{
+		"foo.go":              testHeader + "\npackage foo\n",
+		"sub/foo.go":          "// Sub\npackage foo\n",
+		"sub/special/foo.go":  "// Special\npackage foo\n",
+		"sub/excluded/foo.go": "package foo\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(projectDir, file))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %s", file)
+	}
+}
+
+func TestRunLicensePostModifyCommand(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"foo.go": "package foo\n",
+		"bar.go": testHeader + "\npackage bar\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:          golicense.NewLicenser(testHeader),
+		PostModifyCommand: []string{"sh", "-c", `echo "// modified" >> "$0"`, licenseplugin.FilePlaceholder},
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		testHeader + "\npackage bar\n",
+		testHeader + "\npackage foo\n// modified\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+
+	projectParam.PostModifyCommand = []string{"sh", "-c", "echo failed; exit 1"}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true}, &bytes.Buffer{})
+	assert.EqualError(t, err, "post-modify-command failed for 2 file(s):\n\t"+
+		files[0]+": exit status 1\n\t\tfailed\n\t"+
+		files[1]+": exit status 1\n\t\tfailed")
+}
+
+func TestRunLicenseNewFilesSince(t *testing.T) {
+	projectDir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = projectDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+	}
+
+	gitCmd("init")
+	writeFiles(t, projectDir, map[string]string{
+		"old.go": "package foo\n",
+	})
+	gitCmd("add", ".")
+	gitCmd("commit", "-m", "base")
+	gitCmd("tag", "base")
+	writeFiles(t, projectDir, map[string]string{
+		"committed.go": "package foo\n",
+	})
+	gitCmd("add", ".")
+	gitCmd("commit", "-m", "second")
+	files := writeFiles(t, projectDir, map[string]string{
+		"old.go":       "package foo\n",
+		"committed.go": "package foo\n",
+		"licensed.go":  testHeader + "\npackage foo\n",
+		"untracked.go": "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:        true,
+		NewFilesSince: "base",
+		ProjectDir:    projectDir,
+		PathBase:      licenseplugin.PathBaseProject,
+	}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\tcommitted.go\n\tuntracked.go\n", outputBuf.String())
+
+	// running one git process at a time produces the same result
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:         true,
+		NewFilesSince:  "base",
+		GitParallelism: 1,
+		ProjectDir:     projectDir,
+		PathBase:       licenseplugin.PathBaseProject,
+	}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "2 files do not have the correct license header:\n\tcommitted.go\n\tuntracked.go\n", outputBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:        true,
+		NewFilesSince: "HEAD",
+		ProjectDir:    projectDir,
+	}, &bytes.Buffer{})
+	require.Error(t, err)
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:        true,
+		NewFilesSince: "unknown",
+		ProjectDir:    projectDir,
+	}, &bytes.Buffer{})
+	assert.EqualError(t, err, `invalid git ref "unknown"`)
+
+	// apply modifies all files regardless of when they were added
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		NewFilesSince: "base",
+		ProjectDir:    projectDir,
+	}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(filepath.Join(projectDir, "old.go"))
+	require.NoError(t, err)
+	assert.Equal(t, testHeader+"\npackage foo\n", string(got))
+}
+
+func TestRunLicenseCheckCommitYear(t *testing.T) {
+	const yearHeader = "// Copyright {{YEAR}} Palantir Technologies, Inc."
+	projectDir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = projectDir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2020-06-01T12:00:00Z", "GIT_COMMITTER_DATE=2020-06-01T12:00:00Z")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+	}
+
+	gitCmd("init")
+	writeFiles(t, projectDir, map[string]string{
+		"current.go":  "// Copyright 2020 Palantir Technologies, Inc.\npackage foo\n",
+		"modified.go": "// Copyright 2020 Palantir Technologies, Inc.\npackage foo\n",
+		"range.go":    "// Copyright 2018-2020 Palantir Technologies, Inc.\npackage foo\n",
+		"stale.go":    "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+	})
+	gitCmd("add", ".")
+	gitCmd("commit", "-m", "base")
+	files := writeFiles(t, projectDir, map[string]string{
+		"current.go":    "// Copyright 2020 Palantir Technologies, Inc.\npackage foo\n",
+		"modified.go":   "// Copyright 2020 Palantir Technologies, Inc.\npackage foo\n\nconst Foo = 1\n",
+		"range.go":      "// Copyright 2018-2020 Palantir Technologies, Inc.\npackage foo\n",
+		"stale.go":      "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+		"unlicensed.go": "package foo\n",
+		"untracked.go":  "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: licenseplugin.NewYearRangeLicenser(yearHeader),
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:          true,
+		CheckCommitYear: true,
+		ProjectDir:      projectDir,
+		PathBase:        licenseplugin.PathBaseProject,
+	}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "3 files do not have the correct license header:\n\tmodified.go\n\tstale.go\n\tunlicensed.go\n", outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{
+		Verify:          true,
+		CheckCommitYear: true,
+		Output:          licenseplugin.OutputNDJSON,
+		ProjectDir:      projectDir,
+		PathBase:        licenseplugin.PathBaseProject,
+	}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, `{"path":"unlicensed.go","ruleId":"missing-license-header"}
+{"path":"modified.go","ruleId":"missing-license-header"}
+{"path":"stale.go","ruleId":"missing-license-header"}
+`, outputBuf.String())
+}
+
+func TestRunLicenseVerifyDoesNotModify(t *testing.T) {
+	const yearHeader = "// Copyright {{YEAR}} Palantir Technologies, Inc."
+	projectDir := t.TempDir()
+	contents := map[string]string{
+		"blank.go":   "// Copyright 2019 Palantir Technologies, Inc.\n\n\npackage foo\n",
+		"missing.go": "package foo",
+		"year.go":    "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+	}
+	files := writeFiles(t, projectDir, contents)
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, file := range files {
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+	marker := filepath.Join(projectDir, "post-modify-command-ran")
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:           licenseplugin.NewYearRangeLicenser(yearHeader),
+		UpdateYear:         true,
+		RequireCurrentYear: true,
+		EnsureFinalNewline: true,
+		PostModifyCommand:  []string{"touch", marker},
+	}
+
+	for i, runParam := range []licenseplugin.RunParam{
+		{Verify: true, Strict: true},
+		{Verify: true, Remove: true},
+	} {
+		err := licenseplugin.RunLicense(files, projectParam, runParam, &bytes.Buffer{})
+		require.Error(t, err, "Case %d", i)
+		for _, file := range files {
+			got, err := os.ReadFile(file)
+			require.NoError(t, err)
+			assert.Equal(t, contents[filepath.Base(file)], string(got), "Case %d: %s", i, file)
+			fi, err := os.Stat(file)
+			require.NoError(t, err)
+			assert.True(t, fi.ModTime().Equal(modTime), "Case %d: %s", i, file)
+		}
+		assert.NoFileExists(t, marker, "Case %d", i)
+	}
+}
+
+func TestRunLicenseUpdateYear(t *testing.T) {
+	const yearHeader = "// Copyright {{YEAR}} Palantir Technologies, Inc."
+	currentYear := strconv.Itoa(time.Now().Year())
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"changed.go":   "// Copyright 2019 Palantir Technologies, Inc.\n\n\npackage foo\n",
+		"unchanged.go": "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:   licenseplugin.NewYearRangeLicenser(yearHeader),
+		UpdateYear: true,
+	}
+
+	// years are not required to be current for verification
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	// years are only updated for files that are modified
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Strict: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		"// Copyright 2019-" + currentYear + " Palantir Technologies, Inc.\npackage foo\n",
+		"// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+
+	projectParam.RequireCurrentYear = true
+	outputBuf := &bytes.Buffer{}
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, "// Copyright 2019-"+currentYear+" Palantir Technologies, Inc.\npackage foo\n", string(got))
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+}
+
+func TestRunLicenseEnsureFinalNewline(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"empty.go":   "",
+		"extra.go":   testHeader + "\npackage foo\n\n\n",
+		"missing.go": testHeader + "\npackage foo",
+		"ok.go":      testHeader + "\npackage foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:           golicense.NewLicenser(testHeader),
+		EnsureFinalNewline: true,
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "3 files do not have the correct license header:\n\t"+strings.Join(files[:3], "\n\t")+"\n", outputBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	for i, want := range []string{
+		testHeader + "\n",
+		testHeader + "\npackage foo\n",
+		testHeader + "\npackage foo\n",
+		testHeader + "\npackage foo\n",
+	} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+}
+
+func TestRunLicenseAddOnly(t *testing.T) {
+	const yearHeader = "// Copyright {{YEAR}} Palantir Technologies, Inc."
+	original := map[string]string{
+		"build.go":      "//go:build linux\n\npackage foo\n",
+		"duplicate.go":  "// Copyright 2019 Palantir Technologies, Inc.\n// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+		"other.go":      "// Copyright (c) 2019 Palantir Technologies, Inc. All rights reserved.\npackage foo\n",
+		"spdx.go":       "/*\n * SPDX-License-Identifier: MIT\n */\n\npackage foo\n",
+		"stale.go":      "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+		"unlicensed.go": "package foo\n",
+	}
+	files := writeFiles(t, t.TempDir(), original)
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:           licenseplugin.NewYearRangeLicenser(yearHeader),
+		RequireCurrentYear: true,
+	}
+
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{AddOnly: true}, &bytes.Buffer{})
+	require.NoError(t, err)
+	currentYear := strconv.Itoa(time.Now().Year())
+	for i, file := range files {
+		want := original[filepath.Base(file)]
+		switch filepath.Base(file) {
+		case "build.go", "unlicensed.go":
+			want = "// Copyright " + currentYear + " Palantir Technologies, Inc.\n" + want
+		}
+		got, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, file)
+	}
+}
+
+func TestRunLicenseSkipFilesOver(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"large.go": "package foo\n\n// " + strings.Repeat("x", 100) + "\n",
+		"small.go": "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:      golicense.NewLicenser(testHeader),
+		SkipFilesOver: 100,
+	}
+
+	outputBuf := &bytes.Buffer{}
+	warningsBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, Warnings: warningsBuf}, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, "1 file does not have the correct license header:\n\t"+files[1]+"\n", outputBuf.String())
+	assert.Equal(t, "skipped "+files[0]+": file is larger than 100 bytes\n", warningsBuf.String())
+
+	// apply still processes large files
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(got), testHeader+"\n"))
+}
+
+func TestRunLicenseFailOnSkipped(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"binary.go": "package foo\n\x00\n",
+		"large.go":  "package foo\n\n// " + strings.Repeat("x", 100) + "\n",
+		"small.go":  "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser:      golicense.NewLicenser(testHeader),
+		SkipFilesOver: 100,
+	}
+
+	warningsBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Warnings: warningsBuf}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "skipped "+files[0]+": file is binary\n", warningsBuf.String())
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, FailOnSkipped: true}, &bytes.Buffer{})
+	assert.EqualError(t, err, "2 files were skipped:\n\t"+files[0]+": file is binary\n\t"+files[1]+": file is larger than 100 bytes")
+
+	require.NoError(t, os.WriteFile(files[2], []byte("package foo\n"), 0644))
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{FailOnSkipped: true}, &bytes.Buffer{})
+	assert.EqualError(t, err, "1 file was skipped:\n\t"+files[0]+": file is binary")
+	got, err := os.ReadFile(files[2])
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n", string(got))
+}
+
+func TestRunLicenseSkipInvalidGo(t *testing.T) {
+	files := writeFiles(t, t.TempDir(), map[string]string{
+		"invalid.go": "func foo() {\n",
+		"valid.go":   "package foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: golicense.NewLicenser(testHeader),
+	}
+
+	warningsBuf := &bytes.Buffer{}
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{SkipInvalidGo: true, Warnings: warningsBuf}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "skipped "+files[0]+": file is not valid Go\n", warningsBuf.String())
+	for i, want := range []string{"func foo() {\n", testHeader + "\npackage foo\n"} {
+		got, err := os.ReadFile(files[i])
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got), "Case %d: %s", i, files[i])
+	}
+}
+
+func TestRunLicenseNoticeFile(t *testing.T) {
+	const yearHeader = "// Copyright {{YEAR}} Palantir Technologies, Inc."
+	currentYear := strconv.Itoa(time.Now().Year())
+	dir := t.TempDir()
+	files := writeFiles(t, dir, map[string]string{
+		"a.go":          "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+		"b.go":          "// Copyright 2020 Palantir Technologies, Inc.\npackage foo\n",
+		"duplicate.go":  "// Copyright 2019 Palantir Technologies, Inc.\npackage foo\n",
+		"script.sh":     "# Copyright 2019 Acme, Inc.\necho foo\n",
+		"unlicensed.go": "// Copyright 2017 Other, Inc.\npackage foo\n",
+	})
+	projectParam := licenseplugin.ProjectParam{
+		Licenser: licenseplugin.NewYearRangeLicenser(yearHeader),
+		FileTypes: []licenseplugin.FileTypeParam{
+			{
+				Name:     "sh",
+				Matcher:  matcher.Name(`.*\.sh`),
+				Licenser: licenseplugin.NewYearRangeLicenser("# Copyright {{YEAR}} Acme, Inc."),
+			},
+		},
+	}
+	noticeFile := filepath.Join(dir, "NOTICE.txt")
+
+	// notice file is written even if verification fails and only has the lines of files that have the correct header
+	err := licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Verify: true, NoticeFile: noticeFile}, &bytes.Buffer{})
+	require.Error(t, err)
+	got, err := os.ReadFile(noticeFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Copyright 2019 Acme, Inc.\nCopyright 2019 Palantir Technologies, Inc.\nCopyright 2020 Palantir Technologies, Inc.\n", string(got))
+
+	// lines of apply are those of the headers after files are modified
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{NoticeFile: noticeFile}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err = os.ReadFile(noticeFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Copyright 2019 Acme, Inc.\nCopyright 2019 Palantir Technologies, Inc.\nCopyright 2020 Palantir Technologies, Inc.\nCopyright "+currentYear+" Palantir Technologies, Inc.\n", string(got))
+
+	err = licenseplugin.RunLicense(files, projectParam, licenseplugin.RunParam{Remove: true, NoticeFile: noticeFile}, &bytes.Buffer{})
+	require.NoError(t, err)
+	got, err = os.ReadFile(noticeFile)
+	require.NoError(t, err)
+	assert.Equal(t, "", string(got))
+}
+
+func TestWriteCapabilities(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, licenseplugin.WriteCapabilities(licenseplugin.NewCapabilities("1.2.3"), buf))
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "1.2.3", got["version"])
+	assert.Equal(t, []interface{}{"go"}, got["fileTypes"])
+	assert.Contains(t, got["commentStyles"], "hash")
+	assert.Contains(t, got["templateTokens"], licenseplugin.ModulePlaceholder)
+	assert.Equal(t, []interface{}{"text", "sarif", "ndjson", "github", "json", "checkstyle"}, got["outputFormats"])
+}
+
+func TestParseColorMode(t *testing.T) {
+	got, err := licenseplugin.ParseColorMode("always")
+	require.NoError(t, err)
+	assert.Equal(t, licenseplugin.ColorAlways, got)
+
+	_, err = licenseplugin.ParseColorMode("sometimes")
+	assert.EqualError(t, err, `invalid color mode "sometimes": must be one of "auto", "always" or "never"`)
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	got, err := licenseplugin.ParseOutputFormat("sarif")
+	require.NoError(t, err)
+	assert.Equal(t, licenseplugin.OutputSARIF, got)
+
+	_, err = licenseplugin.ParseOutputFormat("xml")
+	assert.EqualError(t, err, `invalid output format "xml": must be one of "text", "sarif", "ndjson", "github", "json" or "checkstyle"`)
+}
+
+func TestParseTextLayout(t *testing.T) {
+	got, err := licenseplugin.ParseTextLayout("table")
+	require.NoError(t, err)
+	assert.Equal(t, licenseplugin.TextLayoutTable, got)
+
+	_, err = licenseplugin.ParseTextLayout("grid")
+	assert.EqualError(t, err, `invalid text layout "grid": must be one of "list" or "table"`)
+}
+
+func TestParseModifiedSince(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	for i, tc := range []struct {
+		value   string
+		want    time.Time
+		wantErr string
+	}{
+		{value: "36h", want: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-01-02T15:04:05+01:00", want: time.Date(2024, 1, 2, 14, 4, 5, 0, time.UTC)},
+		{value: "2024-01-02", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{value: "-1h", wantErr: `invalid modified-since "-1h": duration cannot be negative`},
+		{value: "yesterday", wantErr: `invalid modified-since "yesterday": must be a duration (such as "24h"), an RFC 3339 timestamp (such as "2024-01-02T15:04:05Z") or a date (such as "2024-01-02")`},
+	} {
+		got, err := licenseplugin.ParseModifiedSince(tc.value, now)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.value)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.value)
+		assert.True(t, tc.want.Equal(got), "Case %d: %s: got %v", i, tc.value, got)
+	}
+}
+
+// writeFiles writes the provided files in the provided directory and returns the sorted paths of the written files
+// relative to the working directory.
+func writeFiles(t *testing.T, dir string, files map[string]string) []string {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	var paths []string
+	for relPath, content := range files {
+		filePath := filepath.Join(dir, relPath)
+		err := os.MkdirAll(filepath.Dir(filePath), 0755)
+		require.NoError(t, err)
+		err = os.WriteFile(filePath, []byte(content), 0644)
+		require.NoError(t, err)
+
+		wdRelPath, err := filepath.Rel(wd, filePath)
+		require.NoError(t, err)
+		paths = append(paths, wdRelPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,594 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/pkg/errors"
+)
+
+// NewLicenser returns a Licenser for the provided license header. The returned Licenser behaves in the same manner as
+// the one returned by golicense.NewLicenser, but determines whether content matches the header by comparing the
+// leading bytes of the content against the literal portions of the header (treating each {{YEAR}} as any 4 digits)
+// rather than evaluating a regular expression, which is considerably faster for the common case of content that
+// already has the correct header.
+func NewLicenser(license string) golicense.Licenser {
+	return &prefixLicenser{
+		Licenser: golicense.NewLicenser(license),
+		parts:    strings.Split(license+"\n", "{{YEAR}}"),
+	}
+}
+
+// NewYearRangeLicenser returns a Licenser that behaves in the same manner as the one returned by NewLicenser except
+// that each {{YEAR}} in the header also matches a range of years such as "2019-2024". Headers are added with the
+// current year.
+func NewYearRangeLicenser(license string) golicense.Licenser {
+	return &prefixLicenser{
+		Licenser:   golicense.NewLicenser(license),
+		parts:      strings.Split(license+"\n", "{{YEAR}}"),
+		yearRanges: true,
+	}
+}
+
+// prefixLicenser is a Licenser that checks whether content starts with the license header using literal prefix
+// comparisons before falling back to the matching performed by the wrapped Licenser.
+type prefixLicenser struct {
+	golicense.Licenser
+	// literal parts of the header (followed by a newline) that are separated by a 4-digit year
+	parts []string
+	// if true, years may also be ranges of two 4-digit years separated by "-", which the wrapped Licenser does not
+	// match
+	yearRanges bool
+}
+
+func (l *prefixLicenser) Matches(content string) bool {
+	if _, ok := l.prefixLen(content); ok {
+		return true
+	}
+	if l.yearRanges {
+		return false
+	}
+	return l.Licenser.Matches(content)
+}
+
+func (l *prefixLicenser) Remove(content string) string {
+	if n, ok := l.prefixLen(content); ok {
+		return content[n:]
+	}
+	return l.Licenser.Remove(content)
+}
+
+// prefixLen returns the length of the header at the start of the provided content, which consists of the literal
+// parts of the header separated by years. Returns false if the content does not start with the header.
+func (l *prefixLicenser) prefixLen(content string) (int, bool) {
+	if l.Licenser.Empty() {
+		return 0, false
+	}
+	return l.matchParts(content, 0, 0)
+}
+
+// matchParts returns the end of the match of the parts of the header starting at the part with the provided index at
+// the provided offset of the content.
+func (l *prefixLicenser) matchParts(content string, partIdx, offset int) (int, bool) {
+	part := l.parts[partIdx]
+	if !strings.HasPrefix(content[offset:], part) {
+		return 0, false
+	}
+	offset += len(part)
+	if partIdx == len(l.parts)-1 {
+		return offset, true
+	}
+	if len(content) < offset+4 || !isDigits(content[offset:offset+4]) {
+		return 0, false
+	}
+	if l.yearRanges && len(content) >= offset+9 && content[offset+4] == '-' && isDigits(content[offset+5:offset+9]) {
+		if end, ok := l.matchParts(content, partIdx+1, offset+9); ok {
+			return end, true
+		}
+	}
+	return l.matchParts(content, partIdx+1, offset+4)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUnorderedHoldersLicenser returns a Licenser for the provided header in which the first line that contains
+// HolderPlaceholder is repeated once for each of the provided holders. The holder lines are added in the order of the
+// provided holders, but content whose holder lines are in any order is considered to match the header (and has the
+// header removed). If yearRanges is true, years in the header also match ranges of years as described for
+// NewYearRangeLicenser.
+func NewUnorderedHoldersLicenser(header string, holders []string, yearRanges bool) golicense.Licenser {
+	return newUnorderedHoldersLicenser(header, holders, yearRanges)
+}
+
+// NewReorderingHoldersLicenser returns a Licenser for the provided header in which the first line that contains
+// HolderPlaceholder is repeated once for each of the provided holders. Only content whose holder lines are in the order
+// of the provided holders is considered to match the header, but when the header is added to content whose holder
+// lines are in any other order, the lines are put into the order of the holders (keeping their years) rather than
+// having the header added before them. If yearRanges is true, years in the header also match ranges of years as
+// described for NewYearRangeLicenser.
+func NewReorderingHoldersLicenser(header string, holders []string, yearRanges bool) golicense.Licenser {
+	return &reorderingHoldersLicenser{
+		unorderedHoldersLicenser: newUnorderedHoldersLicenser(header, holders, yearRanges),
+	}
+}
+
+func newUnorderedHoldersLicenser(header string, holders []string, yearRanges bool) *unorderedHoldersLicenser {
+	headerLines := strings.Split(header, "\n")
+	holderLineIdx := 0
+	for i, headerLine := range headerLines {
+		if strings.Contains(headerLine, HolderPlaceholder) {
+			holderLineIdx = i
+			break
+		}
+	}
+	yearPattern := `\d\d\d\d`
+	if yearRanges {
+		yearPattern = `\d\d\d\d(?:-\d\d\d\d)?`
+	}
+	holderLines := make([]*regexp.Regexp, len(holders))
+	for i, holder := range holders {
+		line := strings.Replace(headerLines[holderLineIdx], HolderPlaceholder, holder, -1)
+		parts := strings.Split(line, "{{YEAR}}")
+		for j, part := range parts {
+			parts[j] = regexp.QuoteMeta(part)
+		}
+		holderLines[i] = regexp.MustCompile(`^` + strings.Join(parts, yearPattern) + `$`)
+	}
+	licenser := NewLicenser(ExpandHolders(header, holders))
+	if yearRanges {
+		licenser = NewYearRangeLicenser(ExpandHolders(header, holders))
+	}
+	return &unorderedHoldersLicenser{
+		Licenser:      licenser,
+		holderLineIdx: holderLineIdx,
+		holderLines:   holderLines,
+	}
+}
+
+// unorderedHoldersLicenser is a Licenser that puts the copyright holder lines of content into the order of the
+// header before matching or removing the header.
+type unorderedHoldersLicenser struct {
+	golicense.Licenser
+	// index of the first holder line in the header
+	holderLineIdx int
+	// matchers for the holder lines in the order in which they appear in the header
+	holderLines []*regexp.Regexp
+}
+
+func (l *unorderedHoldersLicenser) Matches(content string) bool {
+	return l.Licenser.Matches(l.reorder(content))
+}
+
+func (l *unorderedHoldersLicenser) Remove(content string) string {
+	return l.Licenser.Remove(l.reorder(content))
+}
+
+// reorder returns the provided content with the lines at the position of the holder lines of the header reordered to
+// match the order of the header. Returns the content unmodified if the lines are not a permutation of the holder
+// lines.
+func (l *unorderedHoldersLicenser) reorder(content string) string {
+	blockEnd := l.holderLineIdx + len(l.holderLines)
+	lines := strings.SplitAfterN(content, "\n", blockEnd+1)
+	if len(lines) <= blockEnd {
+		return content
+	}
+	ordered := make([]string, len(l.holderLines))
+	for _, line := range lines[l.holderLineIdx:blockEnd] {
+		matched := false
+		for i, holderLine := range l.holderLines {
+			if ordered[i] == "" && holderLine.MatchString(strings.TrimSuffix(line, "\n")) {
+				ordered[i] = line
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return content
+		}
+	}
+	return strings.Join(lines[:l.holderLineIdx], "") + strings.Join(ordered, "") + strings.Join(lines[blockEnd:], "")
+}
+
+// reorderingHoldersLicenser is a Licenser that only matches content whose copyright holder lines are in the order of
+// the header and that reorders the holder lines of content whose lines are in any other order when adding the header.
+type reorderingHoldersLicenser struct {
+	*unorderedHoldersLicenser
+}
+
+func (l *reorderingHoldersLicenser) Add(content string) string {
+	if reordered := l.reorder(content); reordered != content && l.Licenser.Matches(reordered) {
+		return reordered
+	}
+	return l.Licenser.Add(content)
+}
+
+func (l *reorderingHoldersLicenser) Matches(content string) bool {
+	return l.Licenser.Matches(content)
+}
+
+// NewReplacingLicenser returns a Licenser that behaves in the same manner as the provided Licenser except that, when
+// the header is added to content that starts with a header matched by any of the provided replaced Licensers, the
+// matched header is removed first. This allows headers that are no longer correct (for example, the same header in a
+// different comment style) to be replaced rather than having the correct header added before them.
+func NewReplacingLicenser(licenser golicense.Licenser, replaced ...golicense.Licenser) golicense.Licenser {
+	return &replacingLicenser{
+		Licenser: licenser,
+		replaced: replaced,
+	}
+}
+
+type replacingLicenser struct {
+	golicense.Licenser
+	replaced []golicense.Licenser
+}
+
+func (l *replacingLicenser) Add(content string) string {
+	for _, replaced := range l.replaced {
+		if !replaced.Empty() && replaced.Matches(content) {
+			content = replaced.Remove(content)
+			break
+		}
+	}
+	return l.Licenser.Add(content)
+}
+
+// NewPatternLicenser returns a Licenser that behaves in the same manner as the provided Licenser except that content
+// that starts with a match of the provided regular expression followed by a newline is also considered to match the
+// header (and has the match removed). Each {{YEAR}} in the pattern matches any 4 digits. The header of the provided
+// Licenser is still the one that is added, so this allows variations of a header to be accepted without files that
+// have them being rewritten. Returns an error if the pattern is not a valid regular expression.
+func NewPatternLicenser(licenser golicense.Licenser, pattern string) (golicense.Licenser, error) {
+	patternRegexp, err := regexp.Compile(`\A(?:` + strings.Replace(pattern, "{{YEAR}}", `\d\d\d\d`, -1) + `)\n`)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid header pattern")
+	}
+	return &patternLicenser{
+		Licenser: licenser,
+		pattern:  patternRegexp,
+	}, nil
+}
+
+// NewCommitLicenser returns a Licenser that behaves in the same manner as the provided Licenser, whose header is the
+// provided header with each CommitPlaceholder replaced with the hash of the current commit, except that content that
+// starts with the provided header with any commit hash (7 to 40 lowercase hexadecimal digits) in place of each
+// placeholder is also considered to match the header (and has the header removed). Each {{YEAR}} in the header also
+// matches a range of years.
+func NewCommitLicenser(licenser golicense.Licenser, header string) golicense.Licenser {
+	pattern := regexp.QuoteMeta(header)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta("{{YEAR}}"), `\d\d\d\d(?:-\d\d\d\d)?`, -1)
+	pattern = strings.Replace(pattern, regexp.QuoteMeta(CommitPlaceholder), `[0-9a-f]{7,40}`, -1)
+	return &patternLicenser{
+		Licenser: licenser,
+		pattern:  regexp.MustCompile(`\A` + pattern + `\n`),
+	}
+}
+
+// NewTrailingWhitespaceLicenser returns a Licenser that behaves in the same manner as the one returned by NewLicenser
+// except that content that starts with the header with trailing whitespace on any of its lines is also considered to
+// match the header (and has the header and its trailing whitespace removed). Each {{YEAR}} in the header also matches
+// a range of years. Intended to be used as a replaced Licenser of NewReplacingLicenser so that headers that have
+// trailing whitespace are replaced.
+func NewTrailingWhitespaceLicenser(header string) golicense.Licenser {
+	lines := strings.Split(header, "\n")
+	for i, line := range lines {
+		lines[i] = headerLinePattern(line)
+	}
+	return &patternLicenser{
+		Licenser: NewLicenser(header),
+		pattern:  regexp.MustCompile(`\A` + strings.Join(lines, "\n") + `\n`),
+	}
+}
+
+// NewParagraphSpacingLicenser returns a Licenser that behaves in the same manner as the one returned by
+// NewTrailingWhitespaceLicenser except that each run of blank lines between the paragraphs of the header also matches
+// any number of such lines greater than 0 (so a header whose paragraphs are separated by two blank lines matches a
+// header whose paragraphs are separated by one). A blank line is a line that is empty or consists of a comment marker
+// such as "//" or " *". Intended to be used as a replaced Licenser of NewReplacingLicenser so that headers whose
+// paragraphs are spaced differently are replaced.
+func NewParagraphSpacingLicenser(header string) golicense.Licenser {
+	lines := strings.Split(header, "\n")
+	first, last := -1, -1
+	for i, line := range lines {
+		if !isBlankHeaderLine(line) {
+			if first == -1 {
+				first = i
+			}
+			last = i
+		}
+	}
+	var parts []string
+	for i := 0; i < len(lines); i++ {
+		if i <= first || i >= last || !isBlankHeaderLine(lines[i]) {
+			parts = append(parts, headerLinePattern(lines[i])+`\n`)
+			continue
+		}
+		var alternatives []string
+		for ; i < last && isBlankHeaderLine(lines[i]); i++ {
+			alternatives = append(alternatives, regexp.QuoteMeta(strings.TrimRight(lines[i], " \t")))
+		}
+		i--
+		parts = append(parts, `(?:(?:`+strings.Join(alternatives, "|")+`)[ \t]*\n)+`)
+	}
+	return &patternLicenser{
+		Licenser: NewLicenser(header),
+		pattern:  regexp.MustCompile(`\A` + strings.Join(parts, "")),
+	}
+}
+
+// headerLinePattern returns a regular expression that matches the provided line of a header with any trailing
+// whitespace. Each {{YEAR}} in the line matches any 4 digits or a range of years.
+func headerLinePattern(line string) string {
+	parts := strings.Split(strings.TrimRight(line, " \t"), "{{YEAR}}")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return strings.Join(parts, `\d\d\d\d(?:-\d\d\d\d)?`) + `[ \t]*`
+}
+
+// isBlankHeaderLine returns true if the provided line of a header is empty or consists of a comment marker.
+func isBlankHeaderLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed == "*" {
+		return true
+	}
+	for _, marker := range lineCommentMarkers {
+		if trimmed == marker {
+			return true
+		}
+	}
+	return false
+}
+
+type patternLicenser struct {
+	golicense.Licenser
+	pattern *regexp.Regexp
+}
+
+func (l *patternLicenser) Matches(content string) bool {
+	return l.Licenser.Matches(content) || l.pattern.MatchString(content)
+}
+
+func (l *patternLicenser) Remove(content string) string {
+	if l.Licenser.Matches(content) {
+		return l.Licenser.Remove(content)
+	}
+	if loc := l.pattern.FindStringIndex(content); loc != nil {
+		return content[loc[1]:]
+	}
+	return content
+}
+
+// NewAcceptingLicenser returns a Licenser that behaves in the same manner as the provided Licenser except that
+// verification also accepts content that starts with a header matched by any of the provided accepted Licensers (for
+// example, the previous header of a project that is migrating to a new header). Such content does not match the
+// returned Licenser, so applying the header replaces the accepted header with the header of the provided Licenser, and
+// removing the header also removes accepted headers.
+func NewAcceptingLicenser(licenser golicense.Licenser, accepted ...golicense.Licenser) golicense.Licenser {
+	return &acceptingLicenser{
+		replacingLicenser: &replacingLicenser{
+			Licenser: licenser,
+			replaced: accepted,
+		},
+	}
+}
+
+type acceptingLicenser struct {
+	*replacingLicenser
+}
+
+func (l *acceptingLicenser) Remove(content string) string {
+	if l.Licenser.Matches(content) {
+		return l.Licenser.Remove(content)
+	}
+	for _, accepted := range l.replaced {
+		if !accepted.Empty() && accepted.Matches(content) {
+			return accepted.Remove(content)
+		}
+	}
+	return content
+}
+
+func (l *acceptingLicenser) accepts(content string) bool {
+	if l.Licenser.Matches(content) {
+		return true
+	}
+	for _, accepted := range l.replaced {
+		if !accepted.Empty() && accepted.Matches(content) {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts returns true if verification accepts the header of the provided content for the provided licenser: the
+// content matches the licenser or starts with a header accepted by a Licenser returned by NewAcceptingLicenser that
+// the licenser is or wraps.
+func accepts(content string, licenser golicense.Licenser) bool {
+	if l, ok := licenser.(interface{ accepts(string) bool }); ok {
+		return l.accepts(content)
+	}
+	return licenser.Matches(content)
+}
+
+// NewCopyrightLineLicenser returns a Licenser that behaves in the same manner as the provided Licenser except that,
+// when the header is added to content that starts with a bare copyright line (for example, a "// Copyright" line added
+// by a different tool), the line is replaced with the header rather than having the header added before it. A bare
+// copyright line is a line comment that mentions a copyright and is not followed by another line of the same comment,
+// or a block comment on a single line that mentions a copyright.
+func NewCopyrightLineLicenser(licenser golicense.Licenser) golicense.Licenser {
+	return NewReplacingLicenser(licenser, copyrightLineLicenser{})
+}
+
+// copyrightLineLicenser is a Licenser that matches content that starts with a bare copyright line. It is only used as a
+// replaced Licenser (see NewReplacingLicenser), so it never adds a header.
+type copyrightLineLicenser struct{}
+
+func (copyrightLineLicenser) Add(content string) string {
+	return content
+}
+
+func (copyrightLineLicenser) Remove(content string) string {
+	return content[bareCopyrightLineLen(content):]
+}
+
+func (copyrightLineLicenser) Matches(content string) bool {
+	return bareCopyrightLineLen(content) > 0
+}
+
+func (copyrightLineLicenser) Empty() bool {
+	return false
+}
+
+// NewSeparatedLicenser returns a Licenser that behaves in the same manner as the provided Licenser except that the
+// header is separated from the content that follows it by exactly the provided number of blank lines: the header is
+// added followed by the blank lines (replacing any blank lines at the start of the content or that follow an existing
+// header), only content whose header is followed by exactly that many blank lines (or by no content at all) matches
+// and removing the header also removes the blank lines that follow it.
+func NewSeparatedLicenser(licenser golicense.Licenser, blankLines int) golicense.Licenser {
+	return &separatedLicenser{
+		Licenser:   licenser,
+		blankLines: blankLines,
+	}
+}
+
+type separatedLicenser struct {
+	golicense.Licenser
+	blankLines int
+}
+
+func (l *separatedLicenser) Add(content string) string {
+	if !l.Licenser.Empty() && l.Licenser.Matches(content) {
+		content = l.Licenser.Remove(content)
+	}
+	return l.Licenser.Add(strings.Repeat("\n", l.blankLines) + trimLeadingBlankLines(content))
+}
+
+func (l *separatedLicenser) Remove(content string) string {
+	if !accepts(content, l.Licenser) {
+		return content
+	}
+	return trimLeadingBlankLines(l.Licenser.Remove(content))
+}
+
+func (l *separatedLicenser) accepts(content string) bool {
+	// content that has the header must have the blank lines that follow it
+	return l.Matches(content) || !l.Licenser.Matches(content) && accepts(content, l.Licenser)
+}
+
+func (l *separatedLicenser) Matches(content string) bool {
+	if !l.Licenser.Matches(content) {
+		return false
+	}
+	rest := l.Licenser.Remove(content)
+	trimmed := trimLeadingBlankLines(rest)
+	return trimmed == "" || strings.Count(rest[:len(rest)-len(trimmed)], "\n") == l.blankLines
+}
+
+// splitHeader splits the provided content, which must match the provided licenser, into the content up to and
+// including the license header and the content that follows the header.
+func splitHeader(content string, licenser golicense.Licenser) (string, string) {
+	_, end := headerBounds(content, licenser)
+	return content[:end], content[end:]
+}
+
+// headerBounds returns the start and end offsets of the license header in the provided content, which must match the
+// provided licenser.
+func headerBounds(content string, licenser golicense.Licenser) (int, int) {
+	var leadingLines string
+	if l, ok := licenser.(*leadingLinesLicenser); ok {
+		leadingLines, _ = l.split(content)
+	}
+	return len(leadingLines), len(leadingLines) + len(content) - len(licenser.Remove(content))
+}
+
+// leadingLinesLicenser is a Licenser that places the license header after the leading lines of the content that match
+// any of a set of regular expressions (for example, a shebang line or an XML declaration). If the first line does not
+// match, the content is processed in its entirety.
+type leadingLinesLicenser struct {
+	golicense.Licenser
+	patterns []*regexp.Regexp
+	// maxLines is the maximum number of leading lines that precede the header. If 0, there is no maximum.
+	maxLines int
+}
+
+// newLeadingLinesLicenser returns a Licenser that places the license header of the provided Licenser after the leading
+// lines that the provided file type requires to precede the header (see FileTypeParam.FirstLine and
+// FileTypeParam.InsertAfter). Returns the provided Licenser if the file type does not require any such lines.
+func newLeadingLinesLicenser(licenser golicense.Licenser, fileType FileTypeParam) golicense.Licenser {
+	switch {
+	case fileType.FirstLine != nil:
+		return &leadingLinesLicenser{
+			Licenser: licenser,
+			patterns: []*regexp.Regexp{fileType.FirstLine},
+			maxLines: 1,
+		}
+	case len(fileType.InsertAfter) > 0:
+		return &leadingLinesLicenser{
+			Licenser: licenser,
+			patterns: fileType.InsertAfter,
+		}
+	default:
+		return licenser
+	}
+}
+
+func (l *leadingLinesLicenser) Add(content string) string {
+	leadingLines, rest := l.split(content)
+	return leadingLines + l.Licenser.Add(rest)
+}
+
+func (l *leadingLinesLicenser) Remove(content string) string {
+	leadingLines, rest := l.split(content)
+	return leadingLines + l.Licenser.Remove(rest)
+}
+
+func (l *leadingLinesLicenser) Matches(content string) bool {
+	_, rest := l.split(content)
+	return l.Licenser.Matches(rest)
+}
+
+func (l *leadingLinesLicenser) accepts(content string) bool {
+	_, rest := l.split(content)
+	return accepts(rest, l.Licenser)
+}
+
+// split splits the provided content into its leading lines that match any of the regular expressions (including their
+// trailing newlines) and the rest of the content. If the first line does not match, the returned leading lines are
+// empty.
+func (l *leadingLinesLicenser) split(content string) (string, string) {
+	end := 0
+	for lines := 0; l.maxLines == 0 || lines < l.maxLines; lines++ {
+		lineEnd := strings.IndexByte(content[end:], '\n')
+		if lineEnd == -1 {
+			// content without a trailing newline cannot be split
+			break
+		}
+		if !l.matches(strings.TrimSuffix(content[end:end+lineEnd], "\r")) {
+			break
+		}
+		end += lineEnd + 1
+	}
+	return content[:end], content[end:]
+}
+
+// matches returns true if the provided line matches any of the regular expressions.
+func (l *leadingLinesLicenser) matches(line string) bool {
+	for _, pattern := range l.patterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
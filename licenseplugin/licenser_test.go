@@ -0,0 +1,197 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/godel-license-plugin/licenseplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const benchmarkHeader = `// Copyright {{YEAR}} Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+`
+
+func TestNewLicenserMatchesSameAsGoLicense(t *testing.T) {
+	for i, tc := range []struct {
+		header  string
+		content string
+	}{
+		{header: "// Header", content: "// Header\npackage foo"},
+		{header: "// Header", content: "// Header package foo"},
+		{header: "// Header", content: "package foo"},
+		{header: "// Copyright {{YEAR}}", content: "// Copyright 2016\npackage foo"},
+		{header: "// Copyright {{YEAR}}", content: "// Copyright 201\npackage foo"},
+		{header: "// Copyright {{YEAR}}", content: "// Copyright 20a6\npackage foo"},
+		{header: "// Copyright {{YEAR}}", content: "// Copyright 2016"},
+		{header: "// {{YEAR}}-{{YEAR}} Copyright", content: "// 2016-2018 Copyright\npackage foo"},
+		{header: "// {{YEAR}}-{{YEAR}} Copyright", content: "// 2016-18 Copyright\npackage foo"},
+		{header: "", content: "package foo"},
+		{header: "", content: "\npackage foo"},
+	} {
+		want := golicense.NewLicenser(tc.header).Matches(tc.content)
+		got := licenseplugin.NewLicenser(tc.header).Matches(tc.content)
+		assert.Equal(t, want, got, "Case %d: header %q, content %q", i, tc.header, tc.content)
+	}
+}
+
+func TestNewYearRangeLicenser(t *testing.T) {
+	for i, tc := range []struct {
+		name        string
+		content     string
+		wantMatches bool
+		wantRemoved string
+	}{
+		{"single year", "// Copyright 2016 Foo\npackage foo", true, "package foo"},
+		{"range of years", "// Copyright 2016-2024 Foo\npackage foo", true, "package foo"},
+		{"incomplete range", "// Copyright 2016-24 Foo\npackage foo", false, ""},
+		{"no year", "// Copyright Foo\npackage foo", false, ""},
+	} {
+		licenser := licenseplugin.NewYearRangeLicenser("// Copyright {{YEAR}} Foo")
+		assert.Equal(t, tc.wantMatches, licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		if tc.wantMatches {
+			assert.Equal(t, tc.wantRemoved, licenser.Remove(tc.content), "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
+func TestNewPatternLicenser(t *testing.T) {
+	const header = "// Copyright {{YEAR}} Palantir Technologies, Inc."
+	licenser, err := licenseplugin.NewPatternLicenser(licenseplugin.NewLicenser(header), `// Copyright {{YEAR}} Palantir Technologies(,)? Inc\.?`)
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		name        string
+		content     string
+		wantMatches bool
+		wantRemoved string
+	}{
+		{"header", "// Copyright 2016 Palantir Technologies, Inc.\npackage foo", true, "package foo"},
+		{"variation", "// Copyright 2016 Palantir Technologies Inc\npackage foo", true, "package foo"},
+		{"pattern must match entire line", "// Copyright 2016 Palantir Technologies Inc. All rights reserved.\npackage foo", false, ""},
+		{"no header", "package foo", false, ""},
+	} {
+		assert.Equal(t, tc.wantMatches, licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		if tc.wantMatches {
+			assert.Equal(t, tc.wantRemoved, licenser.Remove(tc.content), "Case %d: %s", i, tc.name)
+		}
+	}
+	assert.Equal(t, licenseplugin.NewLicenser(header).Add("package foo"), licenser.Add("package foo"))
+}
+
+func TestNewCommitLicenser(t *testing.T) {
+	const header = "// Built from commit {{COMMIT}} in {{YEAR}}"
+	licenser := licenseplugin.NewCommitLicenser(licenseplugin.NewLicenser(strings.Replace(header, licenseplugin.CommitPlaceholder, "abc1234", -1)), header)
+
+	for i, tc := range []struct {
+		name        string
+		content     string
+		wantMatches bool
+		wantRemoved string
+	}{
+		{"current commit", "// Built from commit abc1234 in 2016\npackage foo", true, "package foo"},
+		{"other commit", "// Built from commit 0123456789abcdef in 2016\npackage foo", true, "package foo"},
+		{"full commit and range of years", "// Built from commit " + strings.Repeat("f", 40) + " in 2016-2024\npackage foo", true, "package foo"},
+		{"short hash", "// Built from commit abc12 in 2016\npackage foo", false, ""},
+		{"not a hash", "// Built from commit main in 2016\npackage foo", false, ""},
+	} {
+		assert.Equal(t, tc.wantMatches, licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		if tc.wantMatches {
+			assert.Equal(t, tc.wantRemoved, licenser.Remove(tc.content), "Case %d: %s", i, tc.name)
+		}
+	}
+	assert.True(t, strings.HasPrefix(licenser.Add("package foo"), "// Built from commit abc1234 in "))
+}
+
+func TestNewSeparatedLicenser(t *testing.T) {
+	const header = "# Copyright 2016 Palantir Technologies, Inc."
+	licenser := licenseplugin.NewSeparatedLicenser(licenseplugin.NewLicenser(header), 1)
+
+	for i, tc := range []struct {
+		name        string
+		content     string
+		wantMatches bool
+		wantRemoved string
+		wantAdded   string
+	}{
+		{"separated header", header + "\n\nfoo\n", true, "foo\n", ""},
+		{"header only", header + "\n", true, "", ""},
+		{"no blank line", header + "\nfoo\n", false, "", header + "\n\nfoo\n"},
+		{"too many blank lines", header + "\n\n \n\nfoo\n", false, "", header + "\n\nfoo\n"},
+		{"no header", "\n\nfoo\n", false, "", header + "\n\nfoo\n"},
+	} {
+		assert.Equal(t, tc.wantMatches, licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		if tc.wantMatches {
+			assert.Equal(t, tc.wantRemoved, licenser.Remove(tc.content), "Case %d: %s", i, tc.name)
+		} else {
+			assert.Equal(t, tc.wantAdded, licenser.Add(tc.content), "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
+func TestNewCopyrightLineLicenser(t *testing.T) {
+	const header = "// Copyright 2016 Palantir Technologies, Inc.\n// Use of this source code is governed by the Apache License."
+	licenser := licenseplugin.NewCopyrightLineLicenser(licenseplugin.NewLicenser(header))
+
+	for i, tc := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"bare line comment", "// Copyright 2015 Foo\npackage foo\n", header + "\npackage foo\n"},
+		{"bare block comment", "/* Copyright (c) Foo */\npackage foo\n", header + "\npackage foo\n"},
+		{"bare hash comment", "# copyright Foo\n\nfoo\n", header + "\n\nfoo\n"},
+		{"multi-line comment", "// Copyright 2015 Foo\n// All rights reserved.\npackage foo\n", header + "\n// Copyright 2015 Foo\n// All rights reserved.\npackage foo\n"},
+		{"comment without copyright", "// Package foo does things.\npackage foo\n", header + "\n// Package foo does things.\npackage foo\n"},
+		{"no comment", "package foo\n", header + "\npackage foo\n"},
+	} {
+		assert.False(t, licenser.Matches(tc.content), "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, licenser.Add(tc.content), "Case %d: %s", i, tc.name)
+	}
+	assert.True(t, licenser.Matches(header+"\npackage foo\n"))
+}
+
+func BenchmarkLicenserMatches(b *testing.B) {
+	// a large file set in which every file already has the correct header
+	body := strings.Repeat("func foo() {\n\tfmt.Println(\"foo\")\n}\n\n", 500)
+	contents := make([]string, 1000)
+	for i := range contents {
+		contents[i] = strings.Replace(benchmarkHeader, "{{YEAR}}", fmt.Sprint(2000+i%25), -1) + "\npackage foo\n\n" + body
+	}
+
+	for _, bc := range []struct {
+		name     string
+		licenser golicense.Licenser
+	}{
+		{name: "golicense", licenser: golicense.NewLicenser(benchmarkHeader)},
+		{name: "prefix", licenser: licenseplugin.NewLicenser(benchmarkHeader)},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, content := range contents {
+					if !bc.licenser.Matches(content) {
+						b.Fatal("content does not match")
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build !windows
+
+package licenseplugin
+
+// longPath returns the provided path. Paths only require an extended-length form on Windows.
+func longPath(path string) string {
+	return path
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build windows
+
+package licenseplugin
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// longPath returns the extended-length form of the provided path ("\\?\" followed by the absolute path), which is not
+// subject to the maximum path length and can refer to files with reserved names such as "CON" or "NUL". Returns the
+// provided path if it is already in that form or if its absolute path cannot be determined.
+func longPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(absPath, `\\`) {
+		// UNC path of the form \\server\share\...
+		return `\\?\UNC\` + absPath[len(`\\`):]
+	}
+	return `\\?\` + absPath
+}
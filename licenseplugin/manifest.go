@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/pkg/errors"
+)
+
+// ReadManifest returns the paths of the files listed in the manifest at the provided path. The manifest lists the path
+// of one file relative to the provided project directory per line (blank lines and lines that start with "#" are
+// ignored) and the returned paths are the listed paths joined with the project directory. Returns an error if any of
+// the listed files does not exist.
+func ReadManifest(manifestFile, projectDir string) ([]string, error) {
+	f, err := os.Open(manifestFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open manifest")
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	files := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		file := filepath.Join(projectDir, filepath.FromSlash(line))
+		if _, err := os.Stat(file); err != nil {
+			return nil, errors.Wrapf(err, "file %s listed in manifest %s does not exist", line, manifestFile)
+		}
+		files = append(files, file)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read manifest")
+	}
+	return files, nil
+}
+
+// splitManifest returns the files that are verified and the files that are not listed in the manifest given the
+// provided files of a project. If runParam.Manifest is nil, all of the files are verified. Otherwise, the files listed
+// in the manifest are verified (whether or not they are among the provided files) and the provided files that are not
+// listed are returned separately. Returns an error if a file listed in the manifest is not a Go file or a file of a
+// configured file type or is excluded.
+func splitManifest(files []string, projectParam ProjectParam, runParam RunParam) ([]string, []string, error) {
+	if runParam.Manifest == nil {
+		return files, nil, nil
+	}
+	listed := make(map[string]struct{})
+	for _, file := range runParam.Manifest {
+		if _, ok := fileLicenser(file, projectParam); !ok {
+			return nil, nil, errors.Errorf("file %s listed in the manifest is excluded or is not a Go file or a file of a configured file type", file)
+		}
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to determine absolute path of %s", file)
+		}
+		listed[absFile] = struct{}{}
+	}
+	var unlisted []string
+	for _, file := range files {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to determine absolute path of %s", file)
+		}
+		if _, ok := listed[absFile]; !ok {
+			unlisted = append(unlisted, file)
+		}
+	}
+	return runParam.Manifest, unlisted, nil
+}
+
+// unlistedHeaderChanges returns a change for each of the provided files, which are not listed in the manifest, that
+// has the license header. The returned changes do not modify the files, have NotInManifest set and are sorted by path.
+// Returns no changes if runParam.ManifestClosed is false.
+func unlistedHeaderChanges(unlisted []string, projectParam ProjectParam, runParam RunParam) ([]Change, error) {
+	if !runParam.ManifestClosed {
+		return nil, nil
+	}
+	changes, err := processFiles(unlisted, projectParam, unlistedHeaderVisitor)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		changes[i].NotInManifest = true
+	}
+	return changes, nil
+}
+
+// unlistedHeaderVisitor is a visitor that considers content to be changed if it has the license header. The content
+// itself is never modified.
+func unlistedHeaderVisitor(content string, licenser golicense.Licenser) (string, bool) {
+	if skipContent(content) || licenser.Empty() {
+		return content, false
+	}
+	return content, licenser.Matches(content)
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// filterDepth returns the provided files whose depth relative to the provided project directory is at most the
+// provided maximum depth (see RunParam.MaxDepth). Returns all of the files if the maximum depth is 0. Files that are
+// not in the project directory and files whose depth cannot be determined are kept.
+func filterDepth(files []string, projectDir string, maxDepth int) []string {
+	if maxDepth == 0 {
+		return files
+	}
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return files
+	}
+	var out []string
+	for _, file := range files {
+		absFile, err := filepath.Abs(file)
+		if err != nil {
+			out = append(out, file)
+			continue
+		}
+		if depth, ok := pathDepth(absProjectDir, absFile); !ok || depth <= maxDepth {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// pathDepth returns the depth of the provided path relative to the provided directory: paths in the directory have a
+// depth of 1, paths in its subdirectories have a depth of 2 and so on. Returns false if the path is not in the
+// directory.
+func pathDepth(dir, path string) (int, bool) {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return 0, false
+	}
+	return strings.Count(relPath, string(filepath.Separator)) + 1, true
+}
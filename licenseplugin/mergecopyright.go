@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/palantir/go-license/golicense"
+)
+
+var (
+	// copyrightYearRegexp matches a year or a range of years of a copyright line.
+	copyrightYearRegexp = regexp.MustCompile(`\d{4}(?:\s*-\s*\d{4})?`)
+	// allRightsReservedRegexp matches the "All rights reserved." statement at the end of a copyright line.
+	allRightsReservedRegexp = regexp.MustCompile(`(?i)\s*all rights reserved\.?$`)
+)
+
+// withCopyrightMerge returns a visitor that, if projectParam.MergeCopyright is true, merges the copyright lines of the
+// header of the licenser into the existing header of content that does not have the license header but starts with a
+// comment that has a copyright line (see mergeCopyright). Content whose existing header already has the copyright
+// lines of all of the holders is not changed. Other content is visited by the provided visitor.
+func withCopyrightMerge(visitor func(content string, licenser golicense.Licenser) (string, bool), projectParam ProjectParam) func(content string, licenser golicense.Licenser) (string, bool) {
+	if !projectParam.MergeCopyright {
+		return visitor
+	}
+	return func(content string, licenser golicense.Licenser) (string, bool) {
+		if skipContent(content) || licenser.Empty() || licenser.Matches(content) {
+			return visitor(content, licenser)
+		}
+		if merged, ok := mergeCopyright(content, licenser); ok {
+			return merged, merged != content
+		}
+		return visitor(content, licenser)
+	}
+}
+
+// mergeCopyright returns the provided content with the copyright lines of the header of the provided licenser added
+// to its existing header after the last copyright line of the existing header. The existing header is the leading
+// block of non-blank lines of the content (after the leading lines that the licenser keeps) if it starts with a line
+// that does not start with a letter or digit (such as a comment marker) and has a copyright line. The added lines
+// start with the text that precedes "copyright" on the last copyright line of the existing header (such as " * " in a
+// block comment) rather than with the comment markers of the license header. Copyright lines whose holder already has
+// a copyright line in the existing header are not added. Returns false if the content does not have an existing
+// header with a copyright line, if the last copyright line ends a block comment or if the license header does not
+// have a copyright line.
+func mergeCopyright(content string, licenser golicense.Licenser) (string, bool) {
+	var leadingLines string
+	rest := content
+	if l, ok := licenser.(*leadingLinesLicenser); ok {
+		leadingLines, rest = l.split(content)
+	}
+	blockEnd := strings.Index(rest, "\n\n")
+	if blockEnd == -1 {
+		blockEnd = len(rest)
+	} else {
+		blockEnd++
+	}
+	first, _ := utf8.DecodeRuneInString(rest)
+	if first == utf8.RuneError || unicode.IsSpace(first) || unicode.IsLetter(first) || unicode.IsDigit(first) {
+		return "", false
+	}
+	lines := strings.SplitAfter(rest[:blockEnd], "\n")
+	holders := make(map[string]struct{})
+	last := -1
+	for i, line := range lines {
+		if loc := copyrightLineRegexp.FindStringIndex(line); loc != nil {
+			holders[copyrightHolder(line[loc[0]:])] = struct{}{}
+			last = i
+		}
+	}
+	if last == -1 || strings.Contains(lines[last], "*/") || strings.Contains(lines[last], "-->") {
+		return "", false
+	}
+	prefix := lines[last][:copyrightLineRegexp.FindStringIndex(lines[last])[0]]
+
+	var added []string
+	hasCopyright := false
+	for _, line := range strings.Split(strings.TrimRight(licenser.Add(""), "\n"), "\n") {
+		loc := copyrightLineRegexp.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		hasCopyright = true
+		text := strings.TrimRight(line[loc[0]:], " \t\r")
+		if _, ok := holders[copyrightHolder(text)]; ok {
+			continue
+		}
+		holders[copyrightHolder(text)] = struct{}{}
+		added = append(added, prefix+text+"\n")
+	}
+	if !hasCopyright {
+		return "", false
+	}
+	if strings.HasSuffix(lines[last], "\r\n") {
+		for i := range added {
+			added[i] = strings.TrimSuffix(added[i], "\n") + "\r\n"
+		}
+	}
+	merged := append(append(append([]string{}, lines[:last+1]...), added...), lines[last+1:]...)
+	return leadingLines + strings.Join(merged, "") + rest[blockEnd:], true
+}
+
+// copyrightHolder returns the holder of the provided copyright line, which starts with "copyright": the text that
+// follows the last year of the line without any trailing "All rights reserved." statement, in lower case. If the line
+// does not have a year, the entire line is the holder.
+func copyrightHolder(line string) string {
+	holder := strings.TrimSpace(line)
+	if locs := copyrightYearRegexp.FindAllStringIndex(holder, -1); len(locs) > 0 {
+		holder = holder[locs[len(locs)-1][1]:]
+	}
+	holder = allRightsReservedRegexp.ReplaceAllString(strings.TrimSpace(holder), "")
+	return strings.ToLower(strings.Trim(holder, " \t.,"))
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParseModifiedSince returns the time specified by the provided value, which is either a duration (such as "24h"),
+// which specifies the time that long before the provided current time, or a timestamp in RFC 3339 format (such as
+// "2024-01-02T15:04:05Z") or a date (such as "2024-01-02", which specifies midnight UTC of the date). Returns an error
+// if the value is none of these or is a negative duration.
+func ParseModifiedSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, errors.Errorf("invalid modified-since %q: duration cannot be negative", value)
+		}
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf(`invalid modified-since %q: must be a duration (such as "24h"), an RFC 3339 timestamp (such as "2024-01-02T15:04:05Z") or a date (such as "2024-01-02")`, value)
+}
+
+// filterModified returns the provided files that were modified after the provided time. Returns all of the files if
+// the time is zero. Files that cannot be stat'ed are kept so that the error is reported when they are processed.
+func filterModified(files []string, since time.Time) []string {
+	if since.IsZero() {
+		return files
+	}
+	var out []string
+	for _, file := range files {
+		if modifiedSince(file, since) {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// modifiedSince returns true if the provided file was modified after the provided time, if the time is zero or if the
+// file cannot be stat'ed.
+func modifiedSince(file string, since time.Time) bool {
+	if since.IsZero() {
+		return true
+	}
+	fi, err := os.Stat(longPath(file))
+	return err != nil || fi.ModTime().After(since)
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// moduleResolver determines the parameters used to process files based on the Go module that contains them. The
+// module of each directory and the parameters for each module are cached so that the file system is walked and the
+// parameters are created at most once per directory and module. The package doc file of each directory is also cached
+// so that the files of each package are grouped at most once. Safe for concurrent use.
+type moduleResolver struct {
+	projectParam ProjectParam
+
+	// fsys is the file system whose go.mod files determine the modules of files. If nil, the modules are determined
+	// using the go.mod files of the OS file system.
+	fsys fs.FS
+
+	mu          sync.Mutex
+	dirModules  map[string]string
+	params      map[string]ProjectParam
+	dirDocFiles map[string]string
+}
+
+func newModuleResolver(projectParam ProjectParam) *moduleResolver {
+	return &moduleResolver{
+		projectParam: projectParam,
+		dirModules:   make(map[string]string),
+		params:       make(map[string]ProjectParam),
+		dirDocFiles:  make(map[string]string),
+	}
+}
+
+// newFSModuleResolver returns a moduleResolver that determines the modules of the files at the provided paths within
+// the provided file system using the go.mod files of the file system.
+func newFSModuleResolver(fsys fs.FS, projectParam ProjectParam) *moduleResolver {
+	r := newModuleResolver(projectParam)
+	r.fsys = fsys
+	return r
+}
+
+// param returns the parameters used to process the file at the provided path. If projectParam.ForModule is nil, the
+// parameters of the resolver are returned. Otherwise, the parameters are those returned by ForModule for the path of
+// the module defined by the go.mod file in the directory of the file or its closest ancestor directory that has one.
+// Returns an error if no such go.mod file exists.
+func (r *moduleResolver) param(file string) (ProjectParam, error) {
+	if r.projectParam.ForModule == nil {
+		return r.projectParam, nil
+	}
+	dir := path.Dir(file)
+	if r.fsys == nil {
+		var err error
+		if dir, err = filepath.Abs(filepath.Dir(file)); err != nil {
+			return ProjectParam{}, errors.Wrapf(err, "failed to determine absolute path of %s", file)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	module, err := r.module(dir)
+	if err != nil {
+		return ProjectParam{}, err
+	}
+	if module == "" {
+		return ProjectParam{}, errors.Errorf("failed to determine module of %s: no go.mod file in its directory or any parent directory", file)
+	}
+	if param, ok := r.params[module]; ok {
+		return param, nil
+	}
+	param, err := r.projectParam.ForModule(module)
+	if err != nil {
+		return ProjectParam{}, errors.Wrapf(err, "failed to create parameters for module %s", module)
+	}
+	r.params[module] = param
+	return param, nil
+}
+
+// module returns the path of the module defined by the go.mod file in the provided directory (absolute, or a path
+// within r.fsys if it is non-nil) or its closest ancestor directory that has one. Returns an empty string if no such
+// go.mod file exists. Must be called with r.mu held.
+func (r *moduleResolver) module(dir string) (string, error) {
+	if module, ok := r.dirModules[dir]; ok {
+		return module, nil
+	}
+	var module string
+	goModFile, parent := filepath.Join(dir, "go.mod"), filepath.Dir(dir)
+	readFile := os.ReadFile
+	if r.fsys != nil {
+		goModFile, parent = path.Join(dir, "go.mod"), path.Dir(dir)
+		readFile = func(name string) ([]byte, error) {
+			return fs.ReadFile(r.fsys, name)
+		}
+	}
+	if bytes, err := readFile(goModFile); err == nil {
+		if module = modfile.ModulePath(bytes); module == "" {
+			return "", errors.Errorf("%s does not specify a module path", goModFile)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", errors.Wrapf(err, "failed to read %s", goModFile)
+	} else if parent != dir {
+		if module, err = r.module(parent); err != nil {
+			return "", err
+		}
+	}
+	r.dirModules[dir] = module
+	return module, nil
+}
+
+// fileLicenser returns the Licenser that applies to the file at the provided path in the manner described for the
+// fileLicenser function using the parameters for the module of the file, except that the package doc Licenser applies
+// to the package doc file of each package (see ProjectParam.PackageDocFile). Returns false if the file is not a Go file
+// or a file of a configured file type or if it is excluded, in which case its module is not determined.
+func (r *moduleResolver) fileLicenser(file string) (golicense.Licenser, bool, error) {
+	licenser, ok := fileLicenser(file, r.projectParam)
+	if !ok {
+		return nil, false, nil
+	}
+	param := r.projectParam
+	if param.ForModule != nil {
+		var err error
+		if param, err = r.param(file); err != nil {
+			return nil, false, err
+		}
+		licenser, ok = fileLicenser(file, param)
+	}
+	if !usesPackageDocLicenser(file, param) {
+		return licenser, ok, nil
+	}
+	docFile, err := r.packageDocFile(filepath.Dir(file))
+	if err != nil {
+		return nil, false, err
+	}
+	if docFile == filepath.Clean(file) {
+		licenser = param.PackageDocLicenser
+	}
+	return licenser, ok, nil
+}
+
+// packageDocFile returns the package doc file of the Go package in the provided directory in the manner described for
+// the packageDocFile function.
+func (r *moduleResolver) packageDocFile(dir string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if docFile, ok := r.dirDocFiles[dir]; ok {
+		return docFile, nil
+	}
+	docFile, err := packageDocFile(dir, r.projectParam)
+	if err != nil {
+		return "", err
+	}
+	r.dirDocFiles[dir] = docFile
+	return docFile, nil
+}
+
+// contentFileLicenser returns the Licenser that applies to the file at the provided path, which has the provided
+// content, in the manner described for the contentFileLicenser function using the parameters for the module of the
+// file.
+func (r *moduleResolver) contentFileLicenser(file, content string) (golicense.Licenser, bool, error) {
+	param := r.projectParam
+	if param.ForModule != nil {
+		if _, ok := fileLicenser(file, param); !ok {
+			return nil, false, nil
+		}
+		var err error
+		if param, err = r.param(file); err != nil {
+			return nil, false, err
+		}
+	}
+	licenser, ok := contentFileLicenser(file, content, param)
+	return licenser, ok, nil
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+)
+
+// withFinalNewline returns a visitor that, if projectParam.EnsureFinalNewline is true, ensures that the content
+// returned by the provided visitor ends with exactly one newline. The content is considered to be changed if the
+// newlines at its end were changed, even if the visitor does not change it. Empty content is left empty.
+func withFinalNewline(visitor func(content string, licenser golicense.Licenser) (string, bool), projectParam ProjectParam) func(content string, licenser golicense.Licenser) (string, bool) {
+	if !projectParam.EnsureFinalNewline {
+		return visitor
+	}
+	return func(content string, licenser golicense.Licenser) (string, bool) {
+		newContent, changed := visitor(content, licenser)
+		if newContent == "" || skipContent(newContent) {
+			return newContent, changed
+		}
+		normalized := ensureFinalNewline(newContent)
+		return normalized, changed || normalized != newContent
+	}
+}
+
+// ensureFinalNewline returns the provided content with the newlines at its end replaced with a single newline. The
+// newline is "\r\n" if the content uses "\r\n" line endings and "\n" otherwise.
+func ensureFinalNewline(content string) string {
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+	return strings.TrimRight(content, "\r\n") + newline
+}
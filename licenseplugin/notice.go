@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/godel-license-plugin/licenseplugin/commentstyle"
+)
+
+// withNotice writes the notice file specified by runParam.NoticeFile (if any) for the provided projects after a license
+// operation on them that returned the provided error and returns the error. The notice file is written even if
+// verification failed or files were changed (see ErrFilesChanged), but not if an apply or remove operation failed.
+func withNotice(runErr error, projects []Project, runParam RunParam) error {
+	if runParam.NoticeFile == "" || (runErr != nil && runErr != ErrFilesChanged && !runParam.Verify) {
+		return runErr
+	}
+	if err := writeNoticeFile(runParam.NoticeFile, projects, runParam.Types); err != nil {
+		return err
+	}
+	return runErr
+}
+
+// writeNoticeFile writes the distinct copyright lines of the license headers of the files of the provided projects
+// that are of the provided file types to the file at the provided path in sorted order, one per line. The files are
+// read from disk, so the lines are those of the headers that the files have when the function is called.
+func writeNoticeFile(path string, projects []Project, types []string) error {
+	var mu sync.Mutex
+	lines := make(map[string]struct{})
+	visitor := func(content string, licenser golicense.Licenser) (string, bool) {
+		fileLines := copyrightLines(content, licenser)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, line := range fileLines {
+			lines[line] = struct{}{}
+		}
+		return content, false
+	}
+	for _, project := range projects {
+		// the visitor does not modify any content, so the changes are only those that the project parameters make
+		// regardless of the visitor (such as adding a final newline) and are discarded
+		if _, err := processFiles(filterTypes(project.Files, project.Param, types), project.Param, visitor); err != nil {
+			return err
+		}
+	}
+
+	var sorted []string
+	for line := range lines {
+		sorted = append(sorted, line)
+	}
+	sort.Strings(sorted)
+	return writeLinesFile(path, "notice file", sorted)
+}
+
+// copyrightLines returns the lines of the license header of the provided content that contain "copyright" without the
+// comment syntax of the header and surrounding whitespace. Returns nil if the content does not match the provided
+// licenser.
+func copyrightLines(content string, licenser golicense.Licenser) []string {
+	if !licenser.Matches(content) {
+		return nil
+	}
+	start, end := headerBounds(content, licenser)
+	header := strings.TrimRight(strings.ReplaceAll(content[start:end], "\r\n", "\n"), "\n")
+	if text, ok := commentstyle.Uncomment(header); ok {
+		header = text
+	}
+	var lines []string
+	for _, line := range strings.Split(header, "\n") {
+		if copyrightLineRegexp.MatchString(line) {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// OutputFormat specifies the format in which the results of verify are written.
+type OutputFormat string
+
+const (
+	// OutputText writes the files that fail verification as human-readable text.
+	OutputText OutputFormat = "text"
+	// OutputSARIF writes a SARIF 2.1.0 document in which every file that fails verification is a result.
+	OutputSARIF OutputFormat = "sarif"
+	// OutputNDJSON writes a JSON object on its own line for every file that fails verification. When the files of
+	// projects are verified, each object is written as soon as the file has been verified, in the order of the files.
+	OutputNDJSON OutputFormat = "ndjson"
+	// OutputGitHub writes a GitHub Actions error annotation for every file that fails verification.
+	OutputGitHub OutputFormat = "github"
+	// OutputJSON writes a JSON array that contains the same object for every file that fails verification as
+	// OutputNDJSON.
+	OutputJSON OutputFormat = "json"
+	// OutputCheckstyle writes a Checkstyle XML report in which every file that fails verification has an error.
+	OutputCheckstyle OutputFormat = "checkstyle"
+)
+
+const (
+	// missingHeaderRuleID is the ID of the rule violated by files that do not have the correct license header.
+	missingHeaderRuleID = "missing-license-header"
+	// foreignHeaderRuleID is the ID of the rule violated by files that have the license header of a foreign license.
+	foreignHeaderRuleID = "foreign-license-header"
+	// missingCopyrightRuleID is the ID of the rule violated by files that have the license header except for a valid
+	// copyright line.
+	missingCopyrightRuleID = "missing-copyright-line"
+	// wrongDelimitersRuleID is the ID of the rule violated by files that have the license header with different comment
+	// delimiters.
+	wrongDelimitersRuleID = "wrong-comment-delimiters"
+	// missingPackageClauseRuleID is the ID of the rule violated by Go files whose license header is not followed by a
+	// package clause.
+	missingPackageClauseRuleID = "missing-package-clause"
+	// notInManifestRuleID is the ID of the rule violated by files that have the license header but are not listed in
+	// the manifest.
+	notInManifestRuleID = "not-in-manifest"
+	// unexpandedPlaceholderRuleID is the ID of the rule violated by files whose license header contains a placeholder
+	// that was not expanded.
+	unexpandedPlaceholderRuleID = "unexpanded-placeholder"
+)
+
+// failure describes how a file that failed verification differs from having the correct license header. The zero
+// value describes a file that does not have the header.
+type failure struct {
+	// foreignLicense is the name of the foreign license whose header the file has. Empty if the file does not have
+	// the header of a foreign license.
+	foreignLicense string
+	// missingCopyright specifies that the file has the license header except for a valid copyright line.
+	missingCopyright bool
+	// wrongDelimiters specifies that the file has the license header with different comment delimiters.
+	wrongDelimiters bool
+	// missingPackageClause specifies that the file is a Go file whose license header is not followed by a package
+	// clause.
+	missingPackageClause bool
+	// notInManifest specifies that the file has the license header but is not listed in the manifest.
+	notInManifest bool
+	// unexpandedPlaceholder specifies that the file has the license header but that its header contains a
+	// placeholder that was not expanded.
+	unexpandedPlaceholder bool
+}
+
+// failureRule returns the ID of the rule violated by a file that failed verification in the manner described by the
+// provided failure and the message that describes the failure.
+func failureRule(f failure) (string, string) {
+	switch {
+	case f.foreignLicense != "":
+		return foreignHeaderRuleID, fmt.Sprintf("File has the license header of a different license (%s)", f.foreignLicense)
+	case f.missingCopyright:
+		return missingCopyrightRuleID, "File has the license header without a valid copyright line"
+	case f.wrongDelimiters:
+		return wrongDelimitersRuleID, "File has the license header with different comment delimiters"
+	case f.missingPackageClause:
+		return missingPackageClauseRuleID, "File does not have a package clause after the license header"
+	case f.notInManifest:
+		return notInManifestRuleID, "File has the license header but is not listed in the manifest"
+	case f.unexpandedPlaceholder:
+		return unexpandedPlaceholderRuleID, "File has the license header with unexpanded placeholders"
+	default:
+		return missingHeaderRuleID, "File does not have the correct license header"
+	}
+}
+
+// ParseOutputFormat returns the OutputFormat for the provided string. Returns an error if the string is not a valid
+// format.
+func ParseOutputFormat(format string) (OutputFormat, error) {
+	switch outputFormat := OutputFormat(format); outputFormat {
+	case OutputText, OutputSARIF, OutputNDJSON, OutputGitHub, OutputJSON, OutputCheckstyle:
+		return outputFormat, nil
+	default:
+		return "", errors.Errorf("invalid output format %q: must be one of %q, %q, %q, %q, %q or %q", format, OutputText, OutputSARIF, OutputNDJSON, OutputGitHub, OutputJSON, OutputCheckstyle)
+	}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// writeSARIF writes a SARIF 2.1.0 document in which each of the provided paths of the files that failed verification
+// is a result. Files that have the header of a foreign license or that are missing the copyright line of the header
+// (as described by the provided map) violate different rules than files that do not have a license header.
+func writeSARIF(paths []string, failures map[string]failure, stdout io.Writer) error {
+	results := make([]sarifResult, 0, len(paths))
+	for _, path := range paths {
+		ruleID, message := failureRule(failures[path])
+		result := sarifResult{
+			RuleID:  ruleID,
+			Level:   "error",
+			Message: sarifMessage{Text: message},
+		}
+		result.Locations = []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(path)},
+			},
+		}}
+		results = append(results, result)
+	}
+	out, err := json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name: "license-plugin",
+					Rules: []sarifRule{
+						{ID: missingHeaderRuleID, ShortDescription: sarifMessage{Text: "Files must have the correct license header"}},
+						{ID: foreignHeaderRuleID, ShortDescription: sarifMessage{Text: "Files must not have the license header of a different license"}},
+						{ID: missingCopyrightRuleID, ShortDescription: sarifMessage{Text: "License headers must have a valid copyright line"}},
+						{ID: wrongDelimitersRuleID, ShortDescription: sarifMessage{Text: "License headers must have the correct comment delimiters"}},
+						{ID: missingPackageClauseRuleID, ShortDescription: sarifMessage{Text: "License headers of Go files must be followed by the package clause"}},
+						{ID: notInManifestRuleID, ShortDescription: sarifMessage{Text: "Files that have the license header must be listed in the manifest"}},
+						{ID: unexpandedPlaceholderRuleID, ShortDescription: sarifMessage{Text: "License headers must not contain unexpanded placeholders"}},
+					},
+				},
+			},
+			Results: results,
+		}},
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal SARIF output")
+	}
+	if _, err := fmt.Fprintln(stdout, string(out)); err != nil {
+		return errors.Wrapf(err, "failed to write SARIF output")
+	}
+	return nil
+}
+
+// ndjsonRecord is the JSON object written for a file that fails verification when the output format is OutputNDJSON
+// or OutputJSON.
+type ndjsonRecord struct {
+	// Path is the path to the file.
+	Path string `json:"path"`
+	// RuleID is the ID of the rule that the file violates (the same IDs as those of the SARIF output).
+	RuleID string `json:"ruleId"`
+	// ForeignLicense is the name of the foreign license whose header the file has, if any.
+	ForeignLicense string `json:"foreignLicense,omitempty"`
+}
+
+// newNDJSONRecord returns the JSON object for the file at the provided path that failed verification in the manner
+// described by the provided failure.
+func newNDJSONRecord(path string, f failure) ndjsonRecord {
+	ruleID, _ := failureRule(f)
+	return ndjsonRecord{
+		Path:           filepath.ToSlash(path),
+		RuleID:         ruleID,
+		ForeignLicense: f.foreignLicense,
+	}
+}
+
+// writeNDJSON writes the JSON object for the file at the provided path that failed verification in the manner
+// described by the provided failure on its own line.
+func writeNDJSON(path string, f failure, stdout io.Writer) error {
+	out, err := json.Marshal(newNDJSONRecord(path, f))
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal output")
+	}
+	if _, err := fmt.Fprintln(stdout, string(out)); err != nil {
+		return errors.Wrapf(err, "failed to write output")
+	}
+	return nil
+}
+
+// writeJSON writes a JSON array that contains the JSON object of each of the provided paths of the files that failed
+// verification as described by the provided map.
+func writeJSON(paths []string, failures map[string]failure, stdout io.Writer) error {
+	records := make([]ndjsonRecord, 0, len(paths))
+	for _, path := range paths {
+		records = append(records, newNDJSONRecord(path, failures[path]))
+	}
+	out, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal output")
+	}
+	if _, err := fmt.Fprintln(stdout, string(out)); err != nil {
+		return errors.Wrapf(err, "failed to write output")
+	}
+	return nil
+}
+
+// githubPropertyEscaper escapes the value of a property of a GitHub Actions workflow command.
+var githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// githubMessageEscaper escapes the message of a GitHub Actions workflow command.
+var githubMessageEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// writeGitHubAnnotation writes the GitHub Actions error annotation for the file at the provided path that failed
+// verification in the manner described by the provided failure. The annotation is on the first line of the file,
+// which is where the header belongs.
+func writeGitHubAnnotation(path string, f failure, stdout io.Writer) error {
+	ruleID, message := failureRule(f)
+	if _, err := fmt.Fprintf(stdout, "::error file=%s,line=1,title=%s::%s\n",
+		githubPropertyEscaper.Replace(filepath.ToSlash(path)),
+		githubPropertyEscaper.Replace(ruleID),
+		githubMessageEscaper.Replace(message),
+	); err != nil {
+		return errors.Wrapf(err, "failed to write output")
+	}
+	return nil
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// writeCheckstyle writes a Checkstyle XML report in which each of the provided paths of the files that failed
+// verification has an error on its first line, which is where the header belongs. The source of each error identifies
+// the rule that the file violated as described by the provided map.
+func writeCheckstyle(paths []string, failures map[string]failure, stdout io.Writer) error {
+	report := checkstyleReport{
+		Version: "4.3",
+	}
+	for _, path := range paths {
+		ruleID, message := failureRule(failures[path])
+		report.Files = append(report.Files, checkstyleFile{
+			Name: filepath.ToSlash(path),
+			Errors: []checkstyleError{{
+				Line:     1,
+				Severity: "error",
+				Message:  message,
+				Source:   "license-plugin." + ruleID,
+			}},
+		})
+	}
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal output")
+	}
+	if _, err := fmt.Fprintln(stdout, xml.Header+string(out)); err != nil {
+		return errors.Wrapf(err, "failed to write output")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+)
+
+// packageClauseRegexp matches a line that is a Go package clause.
+var packageClauseRegexp = regexp.MustCompile(`^package\s+[\p{L}_][\p{L}\p{Nd}_]*\s*(//.*|/\*.*)?$`)
+
+// packageClauseChanges returns a change for each of the provided files that is a Go file (a file of GoFileType) and
+// has the correct license header but is missing the package clause after the header (see packageClauseFollows). The
+// returned changes do not modify the files, have MissingPackageClause set and are sorted by path. Returns no changes if
+// projectParam.RequirePackageClause is false.
+func packageClauseChanges(files []string, projectParam ProjectParam) ([]Change, error) {
+	if !projectParam.RequirePackageClause {
+		return nil, nil
+	}
+	var goFiles []string
+	for _, file := range files {
+		if fileType, ok := fileTypeName(file, projectParam); ok && fileType == GoFileType {
+			goFiles = append(goFiles, file)
+		}
+	}
+	changes, err := processFiles(goFiles, projectParam, packageClauseVisitor)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		changes[i].MissingPackageClause = true
+	}
+	return changes, nil
+}
+
+// packageClauseVisitor is a visitor that considers content that has the license header to be changed if the header is
+// not followed by a package clause. The content itself is never modified.
+func packageClauseVisitor(content string, licenser golicense.Licenser) (string, bool) {
+	if skipContent(content) || licenser.Empty() || !licenser.Matches(content) {
+		return content, false
+	}
+	_, rest := splitHeader(content, licenser)
+	return content, !packageClauseFollows(rest)
+}
+
+// packageClauseFollows returns true if the first line of the provided content that is not blank and is not part of a
+// comment (such as a package comment or a build constraint) is a package clause.
+func packageClauseFollows(content string) bool {
+	inBlockComment := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if inBlockComment {
+			end := strings.Index(line, "*/")
+			if end == -1 {
+				continue
+			}
+			inBlockComment = false
+			line = strings.TrimSpace(line[end+len("*/"):])
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "//"):
+			continue
+		case strings.HasPrefix(line, "/*"):
+			end := strings.Index(line[len("/*"):], "*/")
+			if end == -1 {
+				inBlockComment = true
+				continue
+			}
+			if line = strings.TrimSpace(line[len("/*")+end+len("*/"):]); line == "" {
+				continue
+			}
+		}
+		return packageClauseRegexp.MatchString(line)
+	}
+	return false
+}
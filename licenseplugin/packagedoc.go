@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/pkg/errors"
+)
+
+// DefaultPackageDocFile is the name of the package doc file of each Go package if ProjectParam.PackageDocFile is empty.
+const DefaultPackageDocFile = "doc.go"
+
+// packageDocFileName returns the name of the package doc file of each Go package.
+func (p ProjectParam) packageDocFileName() string {
+	if p.PackageDocFile == "" {
+		return DefaultPackageDocFile
+	}
+	return p.PackageDocFile
+}
+
+// packageDocCandidate returns true if the provided file can be the package doc file of its package: it is a Go file
+// (a file of GoFileType) that is not a test file and is not excluded.
+func packageDocCandidate(file string, projectParam ProjectParam) bool {
+	if projectParam.Exclude != nil && projectParam.Exclude.Match(file) {
+		return false
+	}
+	fileType, ok := fileTypeName(file, projectParam)
+	return ok && fileType == GoFileType && !goTestFileMatcher.Match(file)
+}
+
+// usesPackageDocLicenser returns true if the package doc Licenser of the provided parameters applies to the provided
+// file if it is the package doc file of its package: the parameters have a package doc Licenser, the file can be a
+// package doc file and no custom header applies to it.
+func usesPackageDocLicenser(file string, projectParam ProjectParam) bool {
+	if projectParam.PackageDocLicenser == nil || !packageDocCandidate(file, projectParam) {
+		return false
+	}
+	_, ok := customHeaderFor(file, projectParam)
+	return !ok
+}
+
+// archivePackageDocLicenser returns the Licenser that applies to the archive entry at the provided path, whose
+// Licenser is otherwise the provided Licenser. The entries of an archive are not grouped by package, so the package
+// doc Licenser applies to the entries whose name is the name of the package doc file.
+func archivePackageDocLicenser(path string, licenser golicense.Licenser, projectParam ProjectParam) golicense.Licenser {
+	if usesPackageDocLicenser(path, projectParam) && filepath.Base(path) == projectParam.packageDocFileName() {
+		return projectParam.PackageDocLicenser
+	}
+	return licenser
+}
+
+// packageDocFile returns the path of the package doc file of the Go package in the provided directory given the
+// provided parameters (see ProjectParam.PackageDocFile). The path is the directory joined with the name of the file.
+// Returns an empty path if the directory has no Go files that can be package doc files. If the directory does not
+// exist, the path of the file with the name of the package doc file is returned so that content that is not read from
+// the file system can be processed.
+func packageDocFile(dir string, projectParam ProjectParam) (string, error) {
+	name := filepath.Join(dir, projectParam.packageDocFileName())
+	if fi, err := os.Stat(longPath(name)); err == nil && !fi.IsDir() && packageDocCandidate(name, projectParam) {
+		return name, nil
+	}
+	entries, err := os.ReadDir(longPath(dir))
+	if os.IsNotExist(err) {
+		return name, nil
+	} else if err != nil {
+		return "", errors.Wrapf(err, "failed to read directory %s", dir)
+	}
+	// entries are sorted by name
+	for _, entry := range entries {
+		if file := filepath.Join(dir, entry.Name()); !entry.IsDir() && packageDocCandidate(file, projectParam) {
+			return file, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,414 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/pkg/matcher"
+)
+
+type ProjectParam struct {
+	// The default Licenser.
+	Licenser golicense.Licenser
+
+	// TestLicenser is the Licenser for Go test files (files whose name ends in "_test.go", which includes all of the
+	// files of external test packages) to which no custom header applies. If nil, Licenser is used for such files.
+	TestLicenser golicense.Licenser
+
+	// PackageDocLicenser is the Licenser for the package doc file of each Go package (see PackageDocFile) to which no
+	// custom header applies, which typically adds a package doc comment after the license header. If nil, Licenser is
+	// used for such files.
+	PackageDocLicenser golicense.Licenser
+
+	// PackageDocFile is the name of the package doc file of each Go package. The package doc file of a directory is
+	// the Go file with this name if the directory has one and, if it does not, the first Go file of the directory in
+	// lexical order. Test files, excluded files and files of configured file types are never package doc files. When
+	// archives are verified, only the files with this name are package doc files. If empty, DefaultPackageDocFile is
+	// used.
+	PackageDocFile string
+
+	// ForModule returns the parameters used to process the files of the Go module with the provided path. If non-nil,
+	// the headers of these parameters contain ModulePlaceholder and the files are processed using the parameters
+	// returned for the module that contains them, which must differ from these parameters only in their Licensers.
+	ForModule func(module string) (ProjectParam, error)
+
+	// CustomHeaders specifies the custom header parameters. Custom header parameters can be used to specify that
+	// certain directories or files in the project should use a header that is different from "Header". If multiple
+	// parameters match a file, the one with the longest matching path is used. If multiple parameters match a file
+	// with paths of the same length, the one that appears first is used.
+	CustomHeaders []golicense.CustomHeaderParam
+
+	// FileTypes specifies the file types other than Go files that should have license headers.
+	FileTypes []FileTypeParam
+
+	// ForeignLicenses specifies the licenses other than the license of the project whose headers are detected in
+	// files that do not have the correct header.
+	ForeignLicenses []ForeignLicenseParam
+
+	// Exclude matches the files and directories that should be excluded from consideration for verifying or applying
+	// licenses.
+	Exclude matcher.Matcher
+
+	// Required matches the files that must have license headers. If non-nil, verify only verifies the files that it
+	// matches and ignores all other files, which allows verification to be limited to the files that a policy requires
+	// to have license headers. Applying and removing licenses are not affected.
+	Required matcher.Matcher
+
+	// ThirdPartyMarker matches the leading content of third-party files (for example, "Imported from" or the copyright
+	// notice of an upstream project). If non-nil, files whose leading content matches are not modified or verified.
+	ThirdPartyMarker *regexp.Regexp
+
+	// HeaderEnd matches the last line of license headers. If non-nil, remove also removes headers that do not match
+	// the Licensers: the content up to and including the first line that matches (which must be in the leading block of
+	// non-blank lines of the content) is removed.
+	HeaderEnd *regexp.Regexp
+
+	// KeepGeneratedNotice specifies that the lines that are generated-code notices (see isGeneratedNotice) are kept
+	// when remove removes a header up to the line that matches HeaderEnd. The other lines of the header are removed.
+	KeepGeneratedNotice bool
+
+	// MergeCopyright specifies that applying licenses to files that do not have the license header but start with a
+	// header that has a copyright line (for example, the header of a file with a third-party copyright) adds the
+	// copyright lines of the license header to the existing header rather than adding the license header before it.
+	// Copyright lines whose holder already has a copyright line in the existing header are not added, and verify does
+	// not fail for files whose existing header has the copyright lines of all of the holders (see mergeCopyright).
+	MergeCopyright bool
+
+	// PostModifyCommand is the command (the executable followed by its arguments) that is run for each file that is
+	// modified by apply or remove after the file is written. Each FilePlaceholder in the arguments is replaced with the
+	// path of the file (if no argument contains the placeholder, the path is appended). If empty, no command is run.
+	PostModifyCommand []string
+
+	// UpdateYear specifies that, whenever a file is modified, the years of its license header that do not end with the
+	// current year are replaced with a range that ends with the current year (for example, "2019" becomes
+	// "2019-2024"). The Licensers must match such ranges (see NewYearRangeLicenser).
+	UpdateYear bool
+
+	// RequireCurrentYear specifies that the years of license headers must end with the current year. Headers whose
+	// years do not are considered incorrect and are updated in the manner described for UpdateYear.
+	RequireCurrentYear bool
+
+	// SkipFilesOver is the size in bytes above which files are not verified (for example, to skip large generated
+	// files). Apply and remove still process such files. A value <= 0 means that files of any size are verified.
+	SkipFilesOver int64
+
+	// PreserveLeadingBlankLines specifies that the blank lines at the start of files are preserved: apply places the
+	// license header above them rather than removing them, and verify accepts blank lines between the header and the
+	// content even if RunParam.Strict is true. File types that specify BlankLinesAfterHeader still require exactly
+	// that number of blank lines.
+	PreserveLeadingBlankLines bool
+
+	// RequirePackageClause specifies that verify also fails for Go files (files of GoFileType) that have the license
+	// header but in which the first line after the header that is not blank and is not part of a comment is not a
+	// package clause, for example because the header was inserted in the wrong place. Has no effect on apply and
+	// remove.
+	RequirePackageClause bool
+
+	// RejectPlaceholders specifies that license headers must not contain template placeholders such as "{{YEAR}}"
+	// that were not expanded, for example because a placeholder was mistyped. Verify fails for files whose header
+	// contains a placeholder and apply returns an error rather than writing such a header.
+	RejectPlaceholders bool
+
+	// EnsureFinalNewline specifies that processed files must end with exactly one newline. Apply and remove replace
+	// the newlines at the end of files with a single newline and verify fails for files that do not end with exactly
+	// one newline. Empty files are not modified.
+	EnsureFinalNewline bool
+}
+
+// FileMatcher returns a matcher that matches all of the files that have license headers: Go files and files that
+// match any of the file types.
+func (p ProjectParam) FileMatcher() matcher.Matcher {
+	matchers := []matcher.Matcher{goFileMatcher}
+	for _, fileType := range p.FileTypes {
+		matchers = append(matchers, fileType.Matcher)
+	}
+	return matcher.Any(matchers...)
+}
+
+// empty returns true if the parameters do not specify any license headers, in which case there is nothing to apply or
+// verify.
+func (p ProjectParam) empty() bool {
+	return p.Licenser.Empty() && len(p.CustomHeaders) == 0 && (p.TestLicenser == nil || p.TestLicenser.Empty()) &&
+		(p.PackageDocLicenser == nil || p.PackageDocLicenser.Empty())
+}
+
+type FileTypeParam struct {
+	// Name is the identifier used to identify this file type. Must be unique.
+	Name string
+
+	// Matcher matches the files of this file type.
+	Matcher matcher.Matcher
+
+	// Extensions are the file extensions (including the leading ".") of the files of this file type. Used to
+	// determine how specifically this file type matches a file when multiple file types match it. Files with these
+	// extensions must also be matched by Matcher.
+	Extensions []string
+
+	// Filenames are the exact names of the files of this file type. Used to determine how specifically this file type
+	// matches a file when multiple file types match it. Files with these names must also be matched by Matcher.
+	Filenames []string
+
+	// Licenser is the Licenser for the default header of files of this type. If nil, ProjectParam.Licenser is used.
+	Licenser golicense.Licenser
+
+	// CustomHeaderLicensers maps the names of custom headers to the Licenser for the custom header for files of this
+	// type. The Licenser of the custom header is used for custom headers that are not in the map.
+	CustomHeaderLicensers map[string]golicense.Licenser
+
+	// FirstLine matches a line that must remain the first line of files of this type. If non-nil and the first line
+	// of a file matches, the license header is placed directly after it rather than at the start of the file.
+	FirstLine *regexp.Regexp
+
+	// InsertAfter matches the lines that must precede the license header of files of this type (for example, a shebang
+	// line or encoding pragmas). If non-empty, the license header is placed directly after the leading lines of a file
+	// that each match any of the regular expressions. Must be empty if FirstLine is non-nil.
+	InsertAfter []*regexp.Regexp
+
+	// ContentPattern matches the start of the content of files of this type whose names do not identify their type (for
+	// example, a shebang line of a shell script with a ".txt" extension). If non-nil, files that would otherwise be
+	// processed as a different file type or as Go files are processed as this file type if the start of their content
+	// (after the license header of this file type, if any) matches. If multiple file types match the content of a file,
+	// the one that appears first in the parameters is used.
+	ContentPattern *regexp.Regexp
+
+	// BlankLinesAfterHeader is the number of blank lines that must separate the license header from the content of
+	// files of this type. If non-nil, the Licensers of files of this type are wrapped as described for
+	// NewSeparatedLicenser. If nil, any number of blank lines may follow the header.
+	BlankLinesAfterHeader *int
+
+	// Encoding is the character encoding of files of this type. If non-nil, the content of the files is converted from
+	// the encoding to UTF-8 before it is compared with the license header, and content to which the header is added or
+	// from which it is removed is converted back to the encoding before it is written. If nil, files are processed as
+	// UTF-8.
+	Encoding Encoding
+}
+
+// filenameSpecificity is the specificity of a match on a file name, which is greater than that of a match on any
+// extension.
+const filenameSpecificity = math.MaxInt32
+
+// specificity returns how specifically this file type matches the provided file, which must be matched by Matcher. A
+// match on a file name has the specificity filenameSpecificity, a match on an extension has a specificity equal to the
+// length of the extension and any other match has a specificity of 0.
+func (p FileTypeParam) specificity(file string) int {
+	for _, filename := range p.Filenames {
+		if filepath.Base(file) == filename {
+			return filenameSpecificity
+		}
+	}
+	specificity := 0
+	for _, ext := range p.Extensions {
+		if strings.HasSuffix(filepath.Base(file), ext) && len(ext) > specificity {
+			specificity = len(ext)
+		}
+	}
+	return specificity
+}
+
+// licenser returns the Licenser for files of this type given the name of the custom header that applies to the file
+// (empty if no custom header applies) and the Licenser that applies to files that are not of a configured file type.
+func (p FileTypeParam) licenser(customHeader string, licenser golicense.Licenser) golicense.Licenser {
+	if customHeader == "" {
+		if p.Licenser != nil {
+			return p.Licenser
+		}
+		return licenser
+	}
+	if customHeaderLicenser, ok := p.CustomHeaderLicensers[customHeader]; ok {
+		return customHeaderLicenser
+	}
+	return licenser
+}
+
+type RunParam struct {
+	// Verify specifies that files should be verified rather than modified.
+	Verify bool
+
+	// Remove specifies that license headers should be removed from files. No-op if Verify is true.
+	Remove bool
+
+	// AddOnly specifies that apply only adds license headers to files that do not have one: files that have the
+	// license header or start with a leading comment that resembles a license header (one that mentions a copyright, a
+	// license or an SPDX identifier) are not modified at all. Has no effect on verify and remove.
+	AddOnly bool
+
+	// ErrOnChange specifies that apply and remove return ErrFilesChanged if they modified any files (for example, so
+	// that a CI job that applies headers can determine whether there are changes to commit). Has no effect on verify.
+	ErrOnChange bool
+
+	// Backup specifies that apply and remove write a copy of each file that they modify, with the content that it had
+	// before it was modified and the same permissions, to the path of the file with BackupSuffix appended before the
+	// file is modified. Existing copies are overwritten.
+	Backup bool
+
+	// FixAfter specifies that verify is followed by apply if any files fail verification: the failures are reported
+	// as for verify, the license headers are then applied and the files that were modified are listed after the
+	// failures. The error is that of apply (so files failing verification are not an error if they are fixed). If
+	// Verify is false, apply lists the files that it modified. Not supported for archives or content.
+	FixAfter bool
+
+	// DryRun specifies that apply and remove determine the changes that they would make without writing any files or
+	// running post-modify commands. Instead, the planned action for each file (add, update, remove or none) is written
+	// in the format specified by Output: OutputJSON writes a JSON array with an object for each file, OutputNDJSON
+	// writes the object of each file on its own line and otherwise the action and path of each file that would be
+	// modified are written on their own line. Has no effect on verify.
+	DryRun bool
+
+	// PatchFile is the path of a file to which a dry run writes a unified diff of the changes that it would make, in
+	// addition to writing the planned action for each file, so that the changes can be reviewed or applied later (for
+	// example, using "git apply"). The paths in the diff are the paths of the files as they are reported (see
+	// PathBase) preceded by "a/" and "b/". If empty, no patch file is written. Only used for dry runs.
+	PatchFile string
+
+	// DryRunHeaders specifies that the JSON objects written for a dry run include the license header that each file
+	// would have after the change.
+	DryRunHeaders bool
+
+	// Strict specifies that the license header must be followed directly by the content of the file: blank lines
+	// between the header and the content cause verify to fail and are removed by apply.
+	Strict bool
+
+	// MaxChanges is the maximum number of files that an apply or remove operation may modify. If the operation would
+	// modify more files than this, it fails before any file is written. A value <= 0 means that there is no limit.
+	MaxChanges int
+
+	// CountOnly specifies that verify prints only the number of files that fail verification (including 0 if no
+	// files fail) rather than listing the files.
+	CountOnly bool
+
+	// GroupByDir specifies that the files listed by verify are grouped by directory.
+	GroupByDir bool
+
+	// Output specifies the format of the output of verify and of dry runs. The empty value is treated as OutputText.
+	Output OutputFormat
+
+	// TextLayout specifies how the files that fail verification are laid out in the OutputText format. The empty
+	// value is treated as TextLayoutList. GroupByDir and Color only apply to TextLayoutList.
+	TextLayout TextLayout
+
+	// Color specifies whether the output of verify is colored. The empty value is treated as ColorAuto.
+	Color ColorMode
+
+	// Archive is the path to a tar, tar.gz or zip archive. If non-empty, the entries of the archive are verified
+	// instead of the provided files. Only valid if Verify is true.
+	Archive string
+
+	// Types are the names of the file types of the files that are processed (GoFileType for Go files or the name of a
+	// configured file type). If empty, files of all types are processed.
+	Types []string
+
+	// FailuresFile is the path to a file to which the paths of the files that fail verification are written, one per
+	// line. If non-empty, the file is written (atomically) whenever files are verified, even if no files fail.
+	FailuresFile string
+
+	// NoticeFile is the path to a file to which the distinct copyright lines of the license headers of the processed
+	// files (without comment syntax) are written in sorted order, one per line. If non-empty, the file is written
+	// (atomically) after the files are processed: for apply and remove, the lines are those of the headers that the
+	// files have after they are modified, and for verify, those of the files that have the correct header. Has no
+	// effect on archives or content.
+	NoticeFile string
+
+	// NewFilesSince is a git ref. If non-empty and Verify is true, only files that were added to the git repository
+	// after the ref are verified. Has no effect on apply and remove.
+	NewFilesSince string
+
+	// ModifiedSince is the time after which files must have been modified (as determined by their modification time)
+	// to be processed. Files that were not are not verified, modified or reported, which allows incremental runs
+	// without git history (see ParseModifiedSince). If zero, files are processed regardless of when they were
+	// modified. Has no effect on archives or content.
+	ModifiedSince time.Time
+
+	// MaxDepth is the maximum depth relative to ProjectDir (or to the project directory provided to RunLicenseDir) of
+	// the files that are processed: the files in the project directory have a depth of 1, the files in its
+	// subdirectories have a depth of 2 and so on. Files that are deeper are not verified, modified or reported, and
+	// RunLicenseDir does not walk the directories that contain them. If 0, files are processed regardless of their
+	// depth. Has no effect on archives or content.
+	MaxDepth int
+
+	// CheckCommitYear specifies that verify also fails for files whose license header has a year that does not end
+	// with the year in which the file was last modified according to git: the year of the most recent commit that
+	// modified the file or the current year if the file has uncommitted modifications. Files that are not tracked by
+	// git are not checked. Has no effect on apply and remove, archives or content.
+	CheckCommitYear bool
+
+	// Manifest specifies the paths of the files listed in a manifest (see ReadManifest). If non-nil, verify checks the
+	// listed files rather than the files of the project and fails if any of them does not have the license header.
+	// Every listed file must be a Go file or a file of a configured file type that is not excluded. Can only be
+	// specified for verify of a single project.
+	Manifest []string
+
+	// ManifestClosed specifies that verify also fails for files of the project that have the license header but are
+	// not listed in Manifest, so that the manifest must list exactly the files that have the header. Has no effect if
+	// Manifest is nil.
+	ManifestClosed bool
+
+	// GitParallelism is the maximum number of git processes that are run at once when NewFilesSince or
+	// CheckCommitYear requires git. It is independent of the number of files that are processed at once. A value <= 0
+	// means runtime.GOMAXPROCS(0).
+	GitParallelism int
+
+	// Warnings is the writer to which warnings are written, such as for files that are skipped because they are binary
+	// or, for verify, larger than ProjectParam.SkipFilesOver. If nil, warnings are not written.
+	Warnings io.Writer
+
+	// ContinueOnFileErrors specifies that an error that the operating system returns for the path of an individual
+	// file (for example, because the path is too long or is a reserved name on Windows) does not stop the operation.
+	// Instead, the file is skipped and the operation continues with the other files, and the operation fails after
+	// it is complete with an error that lists the files and their errors. On Windows, files are accessed using their
+	// extended-length paths, so long paths and reserved names are supported whether or not this is specified.
+	ContinueOnFileErrors bool
+
+	// FailFast specifies that verify stops at the first file that fails verification: the files that have not been
+	// processed when the failure is found are not processed and only that file is reported. Processing always stops at
+	// the first error. Has no effect on apply and remove.
+	FailFast bool
+
+	// Timings is the writer to which the time taken to process each file is written after the files are processed,
+	// one file per line and sorted by duration with the slowest file first (for example, to find large generated
+	// files to skip using ProjectParam.SkipFilesOver). If nil, timings are not recorded.
+	Timings io.Writer
+
+	// SkipInvalidGo specifies that apply skips Go files (files of GoFileType) that do not parse as Go source (for
+	// example, broken scratch files) with a warning rather than adding the license header to them. Has no effect on
+	// verify and remove.
+	SkipInvalidGo bool
+
+	// FailOnSkipped specifies that files that are skipped because they are binary, because they are larger than
+	// ProjectParam.SkipFilesOver (for verify) or because they are not valid Go (see SkipInvalidGo) cause the operation
+	// to fail rather than being skipped with a warning. The error lists
+	// all of the skipped files of a project, and no files are modified if any file is skipped.
+	FailOnSkipped bool
+
+	// ProjectDir is the project directory. Used to render paths if PathBase is PathBaseProject and to run git if
+	// NewFilesSince is non-empty or CheckCommitYear is true.
+	ProjectDir string
+
+	// PathBase specifies the directory that reported paths are relative to. The empty value is treated as
+	// PathBaseCWD.
+	PathBase PathBase
+}
+
+// skipInvalidGo returns true if the parameters are for an apply operation that skips Go files that do not parse.
+func (p RunParam) skipInvalidGo() bool {
+	return p.SkipInvalidGo && !p.Verify && !p.Remove
+}
+
+// dryRun returns true if the parameters are for an apply or remove operation that does not write any files.
+func (p RunParam) dryRun() bool {
+	return p.DryRun && !p.Verify
+}
+
+// addOnly returns true if the parameters are for an apply operation that only adds license headers to files that do
+// not have one.
+func (p RunParam) addOnly() bool {
+	return p.AddOnly && !p.Verify && !p.Remove
+}
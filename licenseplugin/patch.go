@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// patchContextLines is the number of unchanged lines that surround the changed lines of each hunk of a patch.
+const patchContextLines = 3
+
+// writePatchFile writes a unified diff of the provided changes, which were determined by a dry run and are sorted by
+// path, to the file at the provided path in the manner described by RunParam.PatchFile. The content of each file
+// before the change is read from the file, which the dry run did not modify.
+func writePatchFile(path string, changes []Change, runParam RunParam) error {
+	var lines []string
+	for _, change := range changes {
+		before, err := os.ReadFile(longPath(change.Path))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", change.Path)
+		}
+		lines = append(lines, patchLines(filepath.ToSlash(displayPath(change.Path, runParam)), string(before), change.Content)...)
+	}
+	return writeLinesFile(path, "patch file", lines)
+}
+
+// patchLines returns the lines of the unified diff between the provided content of the file at the provided path before
+// and after a change. The returned lines do not end with newlines. Returns no lines if the content did not change.
+func patchLines(path, before, after string) []string {
+	a, b := splitPatchLines(before), splitPatchLines(after)
+	groups := difflib.NewMatcher(a, b).GetGroupedOpCodes(patchContextLines)
+	if len(groups) == 0 {
+		return nil
+	}
+	lines := []string{"--- a/" + path, "+++ b/" + path}
+	for _, group := range groups {
+		first, last := group[0], group[len(group)-1]
+		lines = append(lines, fmt.Sprintf("@@ -%s +%s @@", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2)))
+		for _, op := range group {
+			if op.Tag == 'e' {
+				lines = appendPatchLines(lines, " ", a[op.I1:op.I2])
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				lines = appendPatchLines(lines, "-", a[op.I1:op.I2])
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				lines = appendPatchLines(lines, "+", b[op.J1:op.J2])
+			}
+		}
+	}
+	return lines
+}
+
+// splitPatchLines splits the provided content into lines that each end with a newline except for the last line of
+// content that does not end with a newline.
+func splitPatchLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// appendPatchLines appends the provided lines, each preceded by the provided prefix and without its newline, to the
+// provided lines of a patch. A line that does not end with a newline is followed by the marker that specifies so.
+func appendPatchLines(lines []string, prefix string, content []string) []string {
+	for _, line := range content {
+		if strings.HasSuffix(line, "\n") {
+			lines = append(lines, prefix+strings.TrimSuffix(line, "\n"))
+			continue
+		}
+		lines = append(lines, prefix+line, `\ No newline at end of file`)
+	}
+	return lines
+}
+
+// hunkRange returns the range of lines of a hunk of a unified diff that starts at the provided 0-based index of a line
+// and ends before the provided index.
+func hunkRange(start, stop int) string {
+	length := stop - start
+	switch {
+	case length == 1:
+		return fmt.Sprintf("%d", start+1)
+	case length == 0:
+		// an empty range starts at the line before it
+		return fmt.Sprintf("%d,0", start)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
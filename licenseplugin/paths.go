@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// PathBase specifies the directory that reported paths are relative to.
+type PathBase string
+
+const (
+	// PathBaseCWD reports paths relative to the current working directory.
+	PathBaseCWD PathBase = "cwd"
+	// PathBaseProject reports paths relative to the project directory.
+	PathBaseProject PathBase = "project"
+)
+
+// ParsePathBase returns the PathBase for the provided string. Returns an error if the string is not a valid base.
+func ParsePathBase(base string) (PathBase, error) {
+	switch pathBase := PathBase(base); pathBase {
+	case PathBaseCWD, PathBaseProject:
+		return pathBase, nil
+	default:
+		return "", errors.Errorf("invalid path base %q: must be one of %q or %q", base, PathBaseCWD, PathBaseProject)
+	}
+}
+
+// displayPath returns the provided path, which is relative to the working directory, as it should be reported based
+// on the provided parameters. If the path cannot be made relative to the project directory, it is returned unchanged.
+func displayPath(path string, runParam RunParam) string {
+	if runParam.PathBase != PathBaseProject || runParam.ProjectDir == "" {
+		return path
+	}
+	absProjectDir, err := filepath.Abs(runParam.ProjectDir)
+	if err != nil {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	relPath, err := filepath.Rel(absProjectDir, absPath)
+	if err != nil {
+		return path
+	}
+	return relPath
+}
+
+// relativeToWorkingDir returns the provided path relative to the working directory. Relative paths are returned cleaned
+// and absolute paths are made relative to the working directory, since the matchers of the project parameters do not
+// match absolute paths.
+func relativeToWorkingDir(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine working directory")
+	}
+	relPath, err := filepath.Rel(wd, path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to make %s relative to the working directory", path)
+	}
+	return relPath, nil
+}
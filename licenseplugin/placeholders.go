@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/pkg/errors"
+)
+
+// placeholderRegexp matches a template placeholder such as "{{YEAR}}" or "{{.Holder}}".
+var placeholderRegexp = regexp.MustCompile(`\{\{[^{}\n]*\}\}`)
+
+// placeholderChanges returns a change for each of the provided files that has the license header but whose header
+// contains an unexpanded placeholder (for example, because a placeholder was mistyped in the configuration). The
+// returned changes do not modify the files, have UnexpandedPlaceholder set and are sorted by path. Returns no changes
+// if projectParam.RejectPlaceholders is false.
+func placeholderChanges(files []string, projectParam ProjectParam) ([]Change, error) {
+	if !projectParam.RejectPlaceholders {
+		return nil, nil
+	}
+	changes, err := processFiles(files, projectParam, placeholderVisitor)
+	if err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		changes[i].UnexpandedPlaceholder = true
+	}
+	return changes, nil
+}
+
+// placeholderVisitor is a visitor that considers content that has the license header to be changed if the header
+// contains a placeholder. The content itself is never modified.
+func placeholderVisitor(content string, licenser golicense.Licenser) (string, bool) {
+	if skipContent(content) || licenser.Empty() || !licenser.Matches(content) {
+		return content, false
+	}
+	start, end := headerBounds(content, licenser)
+	return content, placeholderRegexp.MatchString(content[start:end])
+}
+
+// checkPlaceholders returns an error if the license header that any of the provided changes writes contains a
+// placeholder and projectParam.RejectPlaceholders is true.
+func checkPlaceholders(changes []Change, projectParam ProjectParam) error {
+	if !projectParam.RejectPlaceholders {
+		return nil
+	}
+	for _, change := range changes {
+		if placeholder := placeholderRegexp.FindString(change.Header); placeholder != "" {
+			return errors.Errorf("license header of %s would contain unexpanded placeholder %s: no files were modified", change.Path, placeholder)
+		}
+	}
+	return nil
+}
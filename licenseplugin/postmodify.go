@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FilePlaceholder is the placeholder in the arguments of a post-modify command that is replaced with the path of the
+// modified file.
+const FilePlaceholder = "{{FILE}}"
+
+// postModifyArgs returns the arguments of the provided post-modify command for the file at the provided path. Each
+// FilePlaceholder in the arguments is replaced with the path. If no argument contains the placeholder, the path is
+// appended to the arguments.
+func postModifyArgs(command []string, path string) []string {
+	args := make([]string, len(command))
+	replaced := false
+	for i, arg := range command {
+		if strings.Contains(arg, FilePlaceholder) {
+			replaced = true
+		}
+		args[i] = strings.Replace(arg, FilePlaceholder, path, -1)
+	}
+	if !replaced {
+		args = append(args, path)
+	}
+	return args
+}
+
+// runPostModifyCommands runs the post-modify command of each of the provided changes, which have been written. The
+// provided map is from the path of a change to the post-modify command of the project of the change (changes whose
+// paths are not in the map do not have a post-modify command). All of the commands are run even if some of them fail.
+// Returns an error that describes all of the commands that failed along with their output.
+func runPostModifyCommands(changes []Change, commands map[string][]string) error {
+	var failures []string
+	for _, change := range changes {
+		command := commands[change.Path]
+		if len(command) == 0 {
+			continue
+		}
+		args := postModifyArgs(command, change.Path)
+		if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			failure := fmt.Sprintf("%s: %v", change.Path, err)
+			if trimmed := strings.TrimSpace(string(output)); trimmed != "" {
+				failure += "\n\t\t" + strings.Replace(trimmed, "\n", "\n\t\t", -1)
+			}
+			failures = append(failures, failure)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(append([]string{fmt.Sprintf("post-modify-command failed for %d file(s):", len(failures))}, failures...), "\n\t"))
+}
@@ -0,0 +1,429 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
+)
+
+// Project is a set of files that are processed using the same project parameters.
+type Project struct {
+	// Files are the paths to the files of the project relative to the working directory.
+	Files []string
+
+	// Param are the parameters used to process the files of the project. They must match the paths of the files
+	// relative to the working directory (see ProjectParam.InDir).
+	Param ProjectParam
+}
+
+// RunLicenseProjects runs the license operation on the files of all of the provided projects, each of which is
+// processed using its own parameters. The results are aggregated across projects: verify reports the files of all
+// projects that do not have the correct header in a single report and runParam.MaxChanges limits the total number of
+// files that are modified. Archives cannot be verified using this function.
+func RunLicenseProjects(projects []Project, runParam RunParam, stdout io.Writer) error {
+	if runParam.Archive != "" {
+		return errors.Errorf("archives cannot be verified for multiple projects")
+	}
+	if runParam.Manifest != nil {
+		if !runParam.Verify {
+			return errors.Errorf("manifests can only be verified")
+		}
+		if len(projects) != 1 {
+			return errors.Errorf("manifests cannot be verified for multiple projects")
+		}
+	}
+	if err := validateTypes(projects, runParam.Types); err != nil {
+		return err
+	}
+	if runParam.Verify && runParam.FixAfter {
+		return verifyThenFix(runParam, func(runParam RunParam) error {
+			return RunLicenseProjects(projects, runParam, stdout)
+		})
+	}
+
+	git := newGitRunner(runParam.GitParallelism)
+	timings := newFileTimings(runParam)
+	defer timings.write(runParam)
+	errs := newFileErrors(runParam)
+	ff := newFailFast(runParam)
+	if runParam.Verify && runParam.Output == OutputNDJSON {
+		return errs.complete(withNotice(streamVerifyProjects(git, projects, runParam, timings, errs, ff, stdout), projects, runParam), runParam)
+	}
+
+	var changes []Change
+	var planned []string
+	commands := make(map[string][]string)
+	for _, project := range projects {
+		if ff.stopped() {
+			break
+		}
+		files, unlisted, err := splitManifest(project.Files, project.Param, runParam)
+		if err != nil {
+			return err
+		}
+		if files, err = skipFiles(selectFiles(files, project.Param, runParam), project.Param, runParam); err != nil {
+			return err
+		}
+		if unlisted, err = skipFiles(selectFiles(unlisted, project.Param, runParam), project.Param, runParam); err != nil {
+			return err
+		}
+		if runParam.Verify && runParam.NewFilesSince != "" {
+			if files, err = filesAddedSince(git, files, runParam.ProjectDir, runParam.NewFilesSince); err != nil {
+				return err
+			}
+		}
+		visitor := applyVisitor(runParam, project.Param)
+		if runParam.Remove && !runParam.Verify {
+			visitor = removeVisitor(project.Param)
+		}
+		projectChanges, err := processTimedFiles(files, project.Param, visitor, timings, errs, ff)
+		if err != nil {
+			return err
+		}
+		files = errs.without(files)
+		if runParam.addOnly() {
+			projectChanges = addOnlyChanges(projectChanges)
+		}
+		if !runParam.Verify && !runParam.Remove {
+			if err := checkPlaceholders(projectChanges, project.Param); err != nil {
+				return err
+			}
+		}
+		if runParam.Verify && runParam.CheckCommitYear && !ff.stopped() {
+			yearChanges, err := commitYearChanges(git, unchangedFiles(files, projectChanges), project.Param, runParam.ProjectDir)
+			if err != nil {
+				return err
+			}
+			projectChanges = append(projectChanges, ff.first(yearChanges)...)
+		}
+		if runParam.Verify && !ff.stopped() {
+			packageChanges, err := packageClauseChanges(unchangedFiles(files, projectChanges), project.Param)
+			if err != nil {
+				return err
+			}
+			projectChanges = append(projectChanges, ff.first(packageChanges)...)
+		}
+		if runParam.Verify && !ff.stopped() {
+			placeholderChanges, err := placeholderChanges(unchangedFiles(files, projectChanges), project.Param)
+			if err != nil {
+				return err
+			}
+			projectChanges = append(projectChanges, ff.first(placeholderChanges)...)
+		}
+		if runParam.Verify && !ff.stopped() {
+			unlistedChanges, err := unlistedHeaderChanges(unlisted, project.Param, runParam)
+			if err != nil {
+				return err
+			}
+			projectChanges = append(projectChanges, ff.first(unlistedChanges)...)
+		}
+		changes = append(changes, projectChanges...)
+		if runParam.dryRun() {
+			planned = append(planned, licensedFiles(files, project.Param)...)
+		}
+		for path, command := range postModifyCommands(projectChanges, project.Param) {
+			commands[path] = command
+		}
+	}
+	return errs.complete(withNotice(completeRun(planned, changes, commands, runParam, errs, stdout), projects, runParam), runParam)
+}
+
+// completeRun completes the license operation that determined the provided changes: for apply and remove, the changes
+// are written and the provided post-modify commands are run. For verify, the changes are reported as failures and an
+// error is returned if there are any. For dry runs, the planned action for each of the provided planned files (and the
+// patch file, if any) is written instead of writing the changes. Files for which an error that occurs while they are
+// written is recorded in the provided errors are skipped (see writeChanges).
+func completeRun(planned []string, changes []Change, commands map[string][]string, runParam RunParam, errs *fileErrors, stdout io.Writer) error {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	if runParam.dryRun() {
+		if err := writePlan(planned, changes, runParam, stdout); err != nil {
+			return err
+		}
+		if runParam.PatchFile != "" {
+			if err := writePatchFile(runParam.PatchFile, changes, runParam); err != nil {
+				return err
+			}
+		}
+		if runParam.ErrOnChange && len(changes) > 0 {
+			return ErrFilesChanged
+		}
+		return nil
+	}
+	if !runParam.Verify {
+		changes, err := writeChanges(changes, runParam, errs)
+		if err != nil {
+			return err
+		}
+		if err := runPostModifyCommands(changes, commands); err != nil {
+			return err
+		}
+		printFixed(changes, runParam, stdout)
+		if runParam.ErrOnChange && len(changes) > 0 {
+			return ErrFilesChanged
+		}
+		return nil
+	}
+	if ok, err := reportVerifyFailures(displayPaths(changes, runParam), changeFailures(changes, runParam), runParam, stdout); err != nil {
+		return err
+	} else if !ok {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// streamVerifyProjects verifies the files of the provided projects using the provided git runner and writes the files
+// that fail verification in the OutputNDJSON format as soon as they are verified. The files are written in the order of
+// the projects and of the files of each project, except that the files of a project whose header years are checked
+// against their commit years and the files whose header is not followed by a package clause and the files that have the
+// header but are not listed in the manifest are written after the other files of the project. The failures file (if
+// any) lists the files in sorted order. If the provided failFast stops at the first failure, only the first file that
+// fails verification is written.
+func streamVerifyProjects(git *gitRunner, projects []Project, runParam RunParam, timings *fileTimings, errs *fileErrors, ff *failFast, stdout io.Writer) error {
+	var paths []string
+	for _, project := range projects {
+		if ff.stopped() {
+			break
+		}
+		files, unlisted, err := splitManifest(project.Files, project.Param, runParam)
+		if err != nil {
+			return err
+		}
+		if files, err = skipFiles(selectFiles(files, project.Param, runParam), project.Param, runParam); err != nil {
+			return err
+		}
+		if unlisted, err = skipFiles(selectFiles(unlisted, project.Param, runParam), project.Param, runParam); err != nil {
+			return err
+		}
+		if runParam.NewFilesSince != "" {
+			if files, err = filesAddedSince(git, files, runParam.ProjectDir, runParam.NewFilesSince); err != nil {
+				return err
+			}
+		}
+		var projectChanges []Change
+		emit := func(change Change) error {
+			projectChanges = append(projectChanges, change)
+			path := displayPath(change.Path, runParam)
+			paths = append(paths, path)
+			ff.stop()
+			return writeNDJSON(path, changeFailure(change), stdout)
+		}
+		if err := streamFiles(ff.context(), files, project.Param, applyVisitor(runParam, project.Param), timings, errs, emit); err != nil {
+			return err
+		}
+		files = errs.without(files)
+		if runParam.CheckCommitYear && !ff.stopped() {
+			yearChanges, err := commitYearChanges(git, unchangedFiles(files, projectChanges), project.Param, runParam.ProjectDir)
+			if err != nil {
+				return err
+			}
+			for _, change := range ff.first(yearChanges) {
+				if err := emit(change); err != nil {
+					return err
+				}
+			}
+		}
+		if !ff.stopped() {
+			packageChanges, err := packageClauseChanges(unchangedFiles(files, projectChanges), project.Param)
+			if err != nil {
+				return err
+			}
+			for _, change := range ff.first(packageChanges) {
+				if err := emit(change); err != nil {
+					return err
+				}
+			}
+		}
+		if !ff.stopped() {
+			placeholderChanges, err := placeholderChanges(unchangedFiles(files, projectChanges), project.Param)
+			if err != nil {
+				return err
+			}
+			for _, change := range ff.first(placeholderChanges) {
+				if err := emit(change); err != nil {
+					return err
+				}
+			}
+		}
+		if !ff.stopped() {
+			unlistedChanges, err := unlistedHeaderChanges(unlisted, project.Param, runParam)
+			if err != nil {
+				return err
+			}
+			for _, change := range ff.first(unlistedChanges) {
+				if err := emit(change); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return completeStreamedVerify(paths, runParam)
+}
+
+// completeStreamedVerify completes a verify operation whose failures, the provided paths, have already been written:
+// the failures file (if any) is written with the paths in sorted order and an error is returned if there are any
+// failures.
+func completeStreamedVerify(paths []string, runParam RunParam) error {
+	if runParam.FailuresFile != "" {
+		sort.Strings(paths)
+		if err := writeFailuresFile(runParam.FailuresFile, paths); err != nil {
+			return err
+		}
+	}
+	if len(paths) > 0 {
+		return ErrVerifyFailed
+	}
+	return nil
+}
+
+// validateTypes returns an error if any of the provided file type names is not the name of a file type of at least one
+// of the provided projects.
+func validateTypes(projects []Project, types []string) error {
+	if len(types) == 0 {
+		return nil
+	}
+	known := map[string]struct{}{
+		GoFileType: {},
+	}
+	for _, project := range projects {
+		for _, fileType := range project.Param.FileTypes {
+			known[fileType.Name] = struct{}{}
+		}
+	}
+	for _, fileType := range types {
+		if _, ok := known[fileType]; !ok {
+			var names []string
+			for name := range known {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return errors.Errorf("unknown file type %q: must be one of %v", fileType, names)
+		}
+	}
+	return nil
+}
+
+// selectFiles returns the provided files that are selected by the provided parameters: the files that are of one of
+// the file types of runParam.Types, were modified after runParam.ModifiedSince, are at most runParam.MaxDepth deep and,
+// for verify, are matched by projectParam.Required.
+func selectFiles(files []string, projectParam ProjectParam, runParam RunParam) []string {
+	files = filterRequired(files, projectParam, runParam)
+	files = filterTypes(files, projectParam, runParam.Types)
+	files = filterModified(files, runParam.ModifiedSince)
+	return filterDepth(files, runParam.ProjectDir, runParam.MaxDepth)
+}
+
+// filterTypes returns the provided files that are of one of the provided file types. Returns all of the files if no
+// file types are provided.
+func filterTypes(files []string, projectParam ProjectParam, types []string) []string {
+	if len(types) == 0 {
+		return files
+	}
+	var out []string
+	for _, file := range files {
+		if hasType(file, projectParam, types) {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// hasType returns true if the provided file is of one of the provided file types or if no file types are provided.
+func hasType(file string, projectParam ProjectParam, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	fileType, ok := fileTypeName(file, projectParam)
+	if !ok {
+		return false
+	}
+	for _, want := range types {
+		if fileType == want {
+			return true
+		}
+	}
+	return false
+}
+
+// InDir returns the parameters of the project in the provided directory (relative to the working directory or
+// absolute) that match the paths of files relative to the working directory rather than relative to the project
+// directory. The files of a project are provided relative to the working directory, so the parameters of a project
+// whose directory is not the working directory (such as a sub-project) must be returned by this function for its
+// excludes, required files, custom header paths and file types to match its files.
+func (p ProjectParam) InDir(dir string) (ProjectParam, error) {
+	if filepath.IsAbs(dir) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return ProjectParam{}, errors.Wrapf(err, "failed to determine working directory")
+		}
+		if dir, err = filepath.Rel(wd, dir); err != nil {
+			return ProjectParam{}, errors.Wrapf(err, "failed to determine relative path")
+		}
+	}
+	dir = filepath.Clean(dir)
+	if dir == "." {
+		return p, nil
+	}
+
+	if p.Exclude != nil {
+		p.Exclude = dirMatcher{dir: dir, Matcher: p.Exclude}
+	}
+	if p.Required != nil {
+		p.Required = dirMatcher{dir: dir, Matcher: p.Required}
+	}
+	customHeaders := make([]golicense.CustomHeaderParam, len(p.CustomHeaders))
+	for i, customHeader := range p.CustomHeaders {
+		includePaths := make([]string, len(customHeader.IncludePaths))
+		for j, includePath := range customHeader.IncludePaths {
+			includePaths[j] = filepath.Join(dir, includePath)
+		}
+		customHeader.IncludePaths = includePaths
+		customHeaders[i] = customHeader
+	}
+	p.CustomHeaders = customHeaders
+	fileTypes := make([]FileTypeParam, len(p.FileTypes))
+	for i, fileType := range p.FileTypes {
+		if fileType.Matcher != nil {
+			fileType.Matcher = dirMatcher{dir: dir, Matcher: fileType.Matcher}
+		}
+		fileTypes[i] = fileType
+	}
+	p.FileTypes = fileTypes
+	if forModule := p.ForModule; forModule != nil {
+		p.ForModule = func(module string) (ProjectParam, error) {
+			moduleParam, err := forModule(module)
+			if err != nil {
+				return ProjectParam{}, err
+			}
+			return moduleParam.InDir(dir)
+		}
+	}
+	return p, nil
+}
+
+// dirMatcher is a matcher that matches paths in a directory whose path relative to the directory is matched by the
+// wrapped matcher.
+type dirMatcher struct {
+	dir string
+	matcher.Matcher
+}
+
+func (m dirMatcher) Match(path string) bool {
+	relPath, err := filepath.Rel(m.dir, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return m.Matcher.Match(relPath)
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+// renderBody is the content to which the license header is added to render it.
+const renderBody = "content\n"
+
+// RenderHeader returns the license header that applying licenses adds to the file at the provided path (relative to
+// the working directory). The header is determined in the same manner as for RunLicense: custom headers, the
+// parameters of modules and the package doc header are resolved and the placeholders of the header are expanded. The
+// file is not read or written and does not need to exist. Returns false if no header applies to the file because it
+// is not a Go file or a file of a configured file type, is excluded or has an empty header.
+func RenderHeader(file string, projectParam ProjectParam) (string, bool, error) {
+	licenser, ok, err := newModuleResolver(projectParam).fileLicenser(file)
+	if err != nil || !ok || licenser.Empty() {
+		return "", false, err
+	}
+	content := licenser.Add(renderBody)
+	start, end := headerBounds(content, licenser)
+	return content[start:end], true, nil
+}
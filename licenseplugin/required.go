@@ -0,0 +1,25 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+// requiredFile returns true if the provided file is verified given the provided parameters (see
+// ProjectParam.Required): all files are verified if ProjectParam.Required is nil or the operation is not verify.
+func requiredFile(file string, projectParam ProjectParam, runParam RunParam) bool {
+	return !runParam.Verify || projectParam.Required == nil || projectParam.Required.Match(file)
+}
+
+// filterRequired returns the provided files that are verified given the provided parameters (see requiredFile).
+func filterRequired(files []string, projectParam ProjectParam, runParam RunParam) []string {
+	if !runParam.Verify || projectParam.Required == nil {
+		return files
+	}
+	var out []string
+	for _, file := range files {
+		if projectParam.Required.Match(file) {
+			out = append(out, file)
+		}
+	}
+	return out
+}
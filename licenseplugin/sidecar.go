@@ -0,0 +1,38 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"os"
+	"strings"
+
+	"github.com/palantir/go-license/golicense"
+	"github.com/pkg/errors"
+)
+
+// SidecarExtension is the extension of a sidecar file. If a file has a sidecar file (a file in the same directory
+// whose name is the name of the file followed by SidecarExtension), the content of the sidecar file is the license
+// header of the file regardless of configuration.
+const SidecarExtension = ".license"
+
+// sidecarLicenser returns the Licenser for the license header specified by the sidecar file of the provided file. The
+// content of the sidecar file is used verbatim as the header except that a single trailing newline is removed, so the
+// header is followed directly by the content of the file. The lines that the file type of the file (if any) requires to
+// precede the header are still honored. Returns false if the file does not have a sidecar file.
+func sidecarLicenser(file string, projectParam ProjectParam) (golicense.Licenser, bool, error) {
+	sidecarFile := file + SidecarExtension
+	headerBytes, err := os.ReadFile(sidecarFile)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "failed to read sidecar file %s", sidecarFile)
+	}
+	licenser := NewLicenser(strings.TrimSuffix(string(headerBytes), "\n"))
+	if fileType, ok := fileTypeFor(file, projectParam); ok {
+		licenser = newLeadingLinesLicenser(licenser, fileType)
+	}
+	return licenser, true, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// skipFiles returns the provided files without those that are skipped (see skipReason). Returns an error that lists
+// the skipped files if any files are skipped and runParam.FailOnSkipped is true.
+func skipFiles(files []string, projectParam ProjectParam, runParam RunParam) ([]string, error) {
+	var out, skipped []string
+	for _, file := range files {
+		if reason := skipReason(file, projectParam, runParam); reason != "" {
+			skipped = append(skipped, skippedLine(file, reason, runParam))
+			continue
+		}
+		out = append(out, file)
+	}
+	if err := skippedError(skipped, runParam); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// skipReason returns the reason that the provided file is skipped rather than processed: for verify, files that are
+// larger than projectParam.SkipFilesOver are skipped, if runParam.Warnings is non-nil or runParam.FailOnSkipped is
+// true, binary files (which are never modified or verified) are reported as skipped, and for apply, Go files that do
+// not parse are skipped if runParam.SkipInvalidGo is true. Unless runParam.FailOnSkipped is true, a warning is written
+// to runParam.Warnings (if non-nil) for each skipped file. Returns an empty string if the file is not skipped. Files
+// that cannot be read are not skipped so that the error is reported when they are processed.
+func skipReason(file string, projectParam ProjectParam, runParam RunParam) string {
+	var reason string
+	if runParam.Verify && projectParam.SkipFilesOver > 0 {
+		if fi, err := os.Stat(file); err == nil && fi.Size() > projectParam.SkipFilesOver {
+			reason = fmt.Sprintf("file is larger than %d bytes", projectParam.SkipFilesOver)
+		}
+	}
+	if reason == "" && (runParam.Warnings != nil || runParam.FailOnSkipped) {
+		if _, ok := fileLicenser(file, projectParam); ok && binaryFile(file) {
+			reason = "file is binary"
+		}
+	}
+	if reason == "" && runParam.skipInvalidGo() {
+		if fileType, ok := fileTypeName(file, projectParam); ok && fileType == GoFileType && !validGoFile(file) {
+			reason = "file is not valid Go"
+		}
+	}
+	if reason != "" && !runParam.FailOnSkipped && runParam.Warnings != nil {
+		_, _ = fmt.Fprintf(runParam.Warnings, "skipped %s\n", skippedLine(file, reason, runParam))
+	}
+	return reason
+}
+
+// skippedLine returns the line that describes the provided file that is skipped for the provided reason.
+func skippedLine(file, reason string, runParam RunParam) string {
+	return displayPath(file, runParam) + ": " + reason
+}
+
+// skippedError returns an error that lists the provided lines that describe skipped files if runParam.FailOnSkipped is
+// true and there are any such lines. Returns nil otherwise.
+func skippedError(skipped []string, runParam RunParam) error {
+	if !runParam.FailOnSkipped || len(skipped) == 0 {
+		return nil
+	}
+	plural := "files were"
+	if len(skipped) == 1 {
+		plural = "file was"
+	}
+	return errors.Errorf("%d %s skipped:\n\t%s", len(skipped), plural, strings.Join(skipped, "\n\t"))
+}
+
+// binaryFile returns true if the content of the provided file is binary (see isBinary). Returns false if the file
+// cannot be read.
+func binaryFile(file string) bool {
+	f, err := os.Open(file)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	buf := make([]byte, binaryDetectionWindow)
+	n, _ := io.ReadFull(f, buf)
+	return isBinary(string(buf[:n]))
+}
+
+// validGoFile returns true if the provided file parses as Go source. Returns true if the file cannot be read so that
+// the error is reported when it is processed.
+func validGoFile(file string) bool {
+	if _, err := os.Stat(file); err != nil {
+		return true
+	}
+	_, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	return err == nil
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package spdx contains the license header text for commonly used licenses keyed by their SPDX identifier.
+package spdx
+
+import (
+	"embed"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HolderPlaceholder is the placeholder for the copyright holder in the header text of a License.
+const HolderPlaceholder = "{{HOLDER}}"
+
+type License struct {
+	// ID is the SPDX identifier of the license.
+	ID string
+
+	// Name is the full name of the license.
+	Name string
+
+	// Header is the text of the license header without any comment markers. Occurrences of {{YEAR}} represent the
+	// copyright year and occurrences of HolderPlaceholder represent the copyright holder. The headers of public
+	// domain dedications such as the Unlicense do not have a copyright holder.
+	Header string
+
+	// Text is the full text of the license without its title and copyright notice. Empty if the full text of the
+	// license is not available.
+	Text string
+}
+
+// HeaderText returns the header text of the license with the copyright holder set to the provided holder.
+func (l License) HeaderText(holder string) string {
+	return strings.Replace(l.Header, HolderPlaceholder, holder, -1)
+}
+
+var licenses = map[string]License{}
+
+//go:embed text/*.txt
+var texts embed.FS
+
+func init() {
+	for _, l := range []License{
+		{
+			ID:   "Apache-2.0",
+			Name: "Apache License 2.0",
+			Header: `Copyright (c) {{YEAR}} {{HOLDER}}
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+`,
+		},
+		{
+			ID:   "BSD-2-Clause",
+			Name: `BSD 2-Clause "Simplified" License`,
+			Header: `Copyright (c) {{YEAR}} {{HOLDER}}
+Use of this source code is governed by a BSD-style
+license that can be found in the LICENSE file.
+`,
+		},
+		{
+			ID:   "BSD-3-Clause",
+			Name: `BSD 3-Clause "New" or "Revised" License`,
+			Header: `Copyright (c) {{YEAR}} {{HOLDER}}
+Use of this source code is governed by a BSD-style
+license that can be found in the LICENSE file.
+`,
+		},
+		{
+			ID:   "CC0-1.0",
+			Name: "Creative Commons Zero v1.0 Universal",
+			Header: `Written in {{YEAR}} by {{HOLDER}}
+
+To the extent possible under law, the author(s) have dedicated all copyright
+and related and neighboring rights to this software to the public domain
+worldwide. This software is distributed without any warranty.
+
+You should have received a copy of the CC0 Public Domain Dedication along
+with this software. If not, see <http://creativecommons.org/publicdomain/zero/1.0/>.
+`,
+		},
+		{
+			ID:   "GPL-3.0-or-later",
+			Name: "GNU General Public License v3.0 or later",
+			Header: `Copyright (c) {{YEAR}} {{HOLDER}}
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+`,
+		},
+		{
+			ID:   "ISC",
+			Name: "ISC License",
+			Header: `Copyright (c) {{YEAR}} {{HOLDER}}
+Use of this source code is governed by an ISC-style
+license that can be found in the LICENSE file.
+`,
+		},
+		{
+			ID:   "Unlicense",
+			Name: "The Unlicense",
+			Header: `This is free and unencumbered software released into the public domain.
+For more information, please refer to <https://unlicense.org>
+`,
+		},
+		{
+			ID:   "MIT",
+			Name: "MIT License",
+			Header: `Copyright (c) {{YEAR}} {{HOLDER}}
+Use of this source code is governed by an MIT-style
+license that can be found in the LICENSE file.
+`,
+		},
+		{
+			ID:   "MPL-2.0",
+			Name: "Mozilla Public License 2.0",
+			Header: `Copyright (c) {{YEAR}} {{HOLDER}}
+
+This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at https://mozilla.org/MPL/2.0/.
+`,
+		},
+	} {
+		if text, err := texts.ReadFile("text/" + l.ID + ".txt"); err == nil {
+			l.Text = string(text)
+		}
+		licenses[l.ID] = l
+	}
+}
+
+// Lookup returns the License with the provided SPDX identifier. Returns an error if the identifier is not known.
+func Lookup(id string) (License, error) {
+	l, ok := licenses[id]
+	if !ok {
+		return License{}, errors.Errorf("unknown SPDX license identifier %q: must be one of %v", id, IDs())
+	}
+	return l, nil
+}
+
+// IDs returns the sorted SPDX identifiers of all of the known licenses.
+func IDs() []string {
+	ids := make([]string, 0, len(licenses))
+	for id := range licenses {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// LicenseTextPlaceholder is the placeholder in a header that is replaced with the full text of a license.
+	LicenseTextPlaceholder = "{{LICENSE}}"
+
+	// HolderPlaceholder is the placeholder in a header that is replaced with a copyright holder. A line that contains
+	// the placeholder is repeated once for each copyright holder.
+	HolderPlaceholder = "{{HOLDER}}"
+
+	// ModulePlaceholder is the placeholder in a header that is replaced with the path of the Go module that contains
+	// the file to which the header is added (as defined by the go.mod file in the directory of the file or its closest
+	// ancestor directory that has one).
+	ModulePlaceholder = "{{MODULE}}"
+
+	// CommitPlaceholder is the placeholder in a header that is replaced with the abbreviated hash of the current git
+	// commit when the header is added. When content is verified, any commit hash matches the placeholder, so headers do
+	// not need to be rewritten after every commit.
+	CommitPlaceholder = "{{COMMIT}}"
+)
+
+// HeaderTemplateData is the data with which headers are executed as templates (see ExecuteHeaderTemplate).
+// Headers are executed once for all of the files they apply to, so the data has no per-file fields such as a filename.
+type HeaderTemplateData struct {
+	// Year renders as "{{YEAR}}", which is replaced with the year when a header is added.
+	Year string
+	// Holder renders as HolderPlaceholder.
+	Holder string
+	// Holders are the copyright holders of the project.
+	Holders []string
+	// Module renders as ModulePlaceholder.
+	Module string
+	// Commit renders as CommitPlaceholder.
+	Commit string
+	// License renders as LicenseTextPlaceholder.
+	License string
+	// Env maps the names of environment variables to their values.
+	Env map[string]string
+}
+
+// NewHeaderTemplateData returns the HeaderTemplateData for a project with the provided copyright holders. Its Env
+// contains the current environment.
+func NewHeaderTemplateData(holders []string) HeaderTemplateData {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if idx := strings.Index(kv, "="); idx > 0 {
+			env[kv[:idx]] = kv[idx+1:]
+		}
+	}
+	return HeaderTemplateData{
+		Year:    "{{YEAR}}",
+		Holder:  HolderPlaceholder,
+		Holders: holders,
+		Module:  ModulePlaceholder,
+		Commit:  CommitPlaceholder,
+		License: LicenseTextPlaceholder,
+		Env:     env,
+	}
+}
+
+// headerTemplateFuncs are the functions of header templates, which allow the placeholders to be used in templates as
+// they are used in other headers.
+var headerTemplateFuncs = template.FuncMap{
+	"YEAR":    func() string { return "{{YEAR}}" },
+	"HOLDER":  func() string { return HolderPlaceholder },
+	"MODULE":  func() string { return ModulePlaceholder },
+	"COMMIT":  func() string { return CommitPlaceholder },
+	"LICENSE": func() string { return LicenseTextPlaceholder },
+}
+
+// ExecuteHeaderTemplate executes the provided header as a Go text/template with the provided data and returns the
+// result. The placeholders are functions of the template that return themselves, so a header that contains no actions
+// other than placeholders is returned unmodified. Returns an error if the header is not a valid template or if its
+// execution fails (for example, because it references an environment variable that is not set).
+func ExecuteHeaderTemplate(header string, data HeaderTemplateData) (string, error) {
+	tmpl, err := template.New("header").Funcs(headerTemplateFuncs).Option("missingkey=error").Parse(header)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse header template")
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", errors.Wrapf(err, "failed to execute header template")
+	}
+	return sb.String(), nil
+}
+
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces all of the ${VAR} references in the provided header with the value of the corresponding
+// environment variable. Occurrences of "$" that are not part of a ${VAR} reference are left unmodified. Returns an
+// error if any referenced environment variable is not set.
+func ExpandEnv(header string) (string, error) {
+	var unset []string
+	expanded := envVarRegexp.ReplaceAllStringFunc(header, func(ref string) string {
+		name := envVarRegexp.FindStringSubmatch(ref)[1]
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			unset = append(unset, name)
+		}
+		return val
+	})
+	if len(unset) > 0 {
+		return "", errors.Errorf("environment variable(s) referenced in header are not set: %v", unset)
+	}
+	return expanded, nil
+}
+
+// ExpandLicenseText replaces every line of the provided header that contains LicenseTextPlaceholder with the lines of
+// the provided license text. The content that precedes and follows the placeholder on its line (typically comment
+// markers such as "// " or " * ") is added to every line of the license text, and trailing whitespace is removed from
+// the resulting lines so that blank lines of the license text do not produce lines with trailing whitespace.
+func ExpandLicenseText(header, text string) string {
+	headerLines := strings.Split(header, "\n")
+	var expandedLines []string
+	for _, headerLine := range headerLines {
+		idx := strings.Index(headerLine, LicenseTextPlaceholder)
+		if idx == -1 {
+			expandedLines = append(expandedLines, headerLine)
+			continue
+		}
+		prefix, suffix := headerLine[:idx], headerLine[idx+len(LicenseTextPlaceholder):]
+		for _, textLine := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+			expandedLines = append(expandedLines, strings.TrimRight(prefix+textLine+suffix, " \t"))
+		}
+	}
+	return strings.Join(expandedLines, "\n")
+}
+
+// ExpandHolders replaces every line of the provided header that contains HolderPlaceholder with one line for each of
+// the provided holders in which the placeholder is replaced with the holder. The lines are in the order of the
+// provided holders.
+func ExpandHolders(header string, holders []string) string {
+	headerLines := strings.Split(header, "\n")
+	var expandedLines []string
+	for _, headerLine := range headerLines {
+		if !strings.Contains(headerLine, HolderPlaceholder) {
+			expandedLines = append(expandedLines, headerLine)
+			continue
+		}
+		for _, holder := range holders {
+			expandedLines = append(expandedLines, strings.Replace(headerLine, HolderPlaceholder, holder, -1))
+		}
+	}
+	return strings.Join(expandedLines, "\n")
+}
+
+// TrimTrailingWhitespace returns the provided header with trailing spaces and tabs removed from each of its lines.
+func TrimTrailingWhitespace(header string) string {
+	lines := strings.Split(header, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// TextLayout specifies how the files that fail verification are laid out in the OutputText format.
+type TextLayout string
+
+const (
+	// TextLayoutList lists the files grouped by the manner in which they failed verification.
+	TextLayoutList TextLayout = "list"
+	// TextLayoutTable writes a table with aligned columns that has a row with the path and the status of each file.
+	TextLayoutTable TextLayout = "table"
+)
+
+// ParseTextLayout returns the TextLayout for the provided string. Returns an error if the string is not a valid layout.
+func ParseTextLayout(layout string) (TextLayout, error) {
+	switch textLayout := TextLayout(layout); textLayout {
+	case TextLayoutList, TextLayoutTable:
+		return textLayout, nil
+	default:
+		return "", errors.Errorf("invalid text layout %q: must be one of %q or %q", layout, TextLayoutList, TextLayoutTable)
+	}
+}
+
+// printVerifyTable prints the provided paths of the files that failed verification as a table whose rows consist of
+// the path and the status of each file, which is the ID of the rule that the file violated (followed by the name of
+// the foreign license, if any, as described by the provided map). The columns are aligned, so the table is not colored.
+func printVerifyTable(paths []string, failures map[string]failure, stdout io.Writer) error {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PATH\tSTATUS")
+	for _, path := range paths {
+		status, _ := failureRule(failures[path])
+		if license := failures[path].foreignLicense; license != "" {
+			status = fmt.Sprintf("%s (%s)", status, license)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", path, status)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Wrapf(err, "failed to write output")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"github.com/palantir/go-license/golicense"
+)
+
+// thirdPartyDetectionWindow is the number of leading bytes of content that are examined to determine whether the
+// content has the third-party marker.
+const thirdPartyDetectionWindow = 4000
+
+// withThirdPartySkip returns a visitor that does not change content that has the third-party marker of the provided
+// parameters and that otherwise behaves in the same manner as the provided visitor.
+func withThirdPartySkip(visitor func(content string, licenser golicense.Licenser) (string, bool), projectParam ProjectParam) func(content string, licenser golicense.Licenser) (string, bool) {
+	if projectParam.ThirdPartyMarker == nil {
+		return visitor
+	}
+	return func(content string, licenser golicense.Licenser) (string, bool) {
+		window := content
+		if len(window) > thirdPartyDetectionWindow {
+			window = window[:thirdPartyDetectionWindow]
+		}
+		if projectParam.ThirdPartyMarker.MatchString(window) {
+			return content, false
+		}
+		return visitor(content, licenser)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fileTimings records the time taken to process each file. It is safe for concurrent use, so the files may be
+// processed in parallel. A nil *fileTimings records nothing.
+type fileTimings struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+// newFileTimings returns the fileTimings for an operation with the provided parameters. Returns nil if the parameters
+// do not specify a writer for timings.
+func newFileTimings(runParam RunParam) *fileTimings {
+	if runParam.Timings == nil {
+		return nil
+	}
+	return &fileTimings{
+		durations: make(map[string]time.Duration),
+	}
+}
+
+// record records that the provided file took the provided duration to process.
+func (t *fileTimings) record(file string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.durations[file] += d
+}
+
+// write writes the recorded durations to runParam.Timings, one file per line in the form "<duration>\t<path>", sorted
+// by duration with the slowest file first (files with equal durations are sorted by path).
+func (t *fileTimings) write(runParam RunParam) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	files := make([]string, 0, len(t.durations))
+	for file := range t.durations {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if t.durations[files[i]] != t.durations[files[j]] {
+			return t.durations[files[i]] > t.durations[files[j]]
+		}
+		return files[i] < files[j]
+	})
+	for _, file := range files {
+		_, _ = fmt.Fprintf(runParam.Timings, "%s\t%s\n", t.durations[file], displayPath(file, runParam))
+	}
+}
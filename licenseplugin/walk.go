@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/pkg/matcher"
+	"github.com/pkg/errors"
+)
+
+// RunLicenseDir runs the license operation on the files in the provided project directory that match
+// projectParam.FileMatcher() and are not excluded by projectParam.Exclude. Unlike RunLicense, which requires the paths
+// of all of the files before any file is processed, the files are processed as soon as they are found while the
+// directory is walked, which bounds the memory used to hold the paths of the files and reduces the time until the
+// first result is available for large projects. The results are otherwise the same as those of RunLicense for the
+// files found by godellauncher.ListProjectPaths. Archives, runParam.NewFilesSince, runParam.CheckCommitYear and
+// runParam.NoticeFile are not supported.
+func RunLicenseDir(projectDir string, projectParam ProjectParam, runParam RunParam, stdout io.Writer) error {
+	switch {
+	case runParam.Archive != "":
+		return errors.Errorf("archives cannot be verified when files are streamed")
+	case runParam.NewFilesSince != "":
+		return errors.Errorf("new files cannot be determined when files are streamed")
+	case runParam.CheckCommitYear:
+		return errors.Errorf("commit years cannot be checked when files are streamed")
+	case runParam.NoticeFile != "":
+		return errors.Errorf("notice file cannot be written when files are streamed")
+	}
+	if err := validateTypes([]Project{{Param: projectParam}}, runParam.Types); err != nil {
+		return err
+	}
+	if runParam.Verify && runParam.FixAfter {
+		return verifyThenFix(runParam, func(runParam RunParam) error {
+			return RunLicenseDir(projectDir, projectParam, runParam, stdout)
+		})
+	}
+
+	ff := newFailFast(runParam)
+	paths := make(chan string)
+	walkErr := make(chan error, 1)
+	var skipped, walked []string
+	// the paths of the walked files are only kept if they are needed once the walk is complete (to plan a dry run or to
+	// check the files that are unchanged), so the memory used to hold paths is otherwise bounded
+	keepWalked := runParam.dryRun() || (runParam.Verify && (projectParam.RequirePackageClause || projectParam.RejectPlaceholders))
+	go func() {
+		walkErr <- walkProjectFiles(ff.context(), projectDir, projectParam.FileMatcher(), projectParam.Exclude, runParam.MaxDepth, func(file string) {
+			if !requiredFile(file, projectParam, runParam) || !hasType(file, projectParam, runParam.Types) || !modifiedSince(file, runParam.ModifiedSince) {
+				return
+			}
+			if reason := skipReason(file, projectParam, runParam); reason != "" {
+				skipped = append(skipped, skippedLine(file, reason, runParam))
+				return
+			}
+			if keepWalked {
+				walked = append(walked, file)
+			}
+			paths <- file
+		})
+		close(paths)
+	}()
+
+	visitor := applyVisitor(runParam, projectParam)
+	if runParam.Remove && !runParam.Verify {
+		visitor = removeVisitor(projectParam)
+	}
+	streamed := runParam.Verify && runParam.Output == OutputNDJSON
+	var changes []Change
+	var failures []string
+	timings := newFileTimings(runParam)
+	defer timings.write(runParam)
+	errs := newFileErrors(runParam)
+	err := streamPaths(ff.context(), paths, projectParam, visitor, timings, errs, func(change Change) error {
+		ff.stop()
+		if !streamed {
+			if !runParam.addOnly() || !change.HadHeader {
+				changes = append(changes, change)
+			}
+			return nil
+		}
+		// only the paths of streamed changes are kept to determine the files that are unchanged
+		changes = append(changes, Change{Path: change.Path})
+		path := displayPath(change.Path, runParam)
+		failures = append(failures, path)
+		return writeNDJSON(path, changeFailure(change), stdout)
+	})
+	// all of the paths are received even if an error occurs, so the walk is complete
+	if walkErr := <-walkErr; err == nil {
+		err = walkErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := skippedError(skipped, runParam); err != nil {
+		return err
+	}
+	walked = errs.without(walked)
+	// checks are run in order on the files that are unchanged and their failures are reported
+	for _, check := range []func([]string, ProjectParam) ([]Change, error){packageClauseChanges, placeholderChanges} {
+		if !runParam.Verify || ff.stopped() {
+			break
+		}
+		checkChanges, err := check(unchangedFiles(walked, changes), projectParam)
+		if err != nil {
+			return err
+		}
+		for _, change := range ff.first(checkChanges) {
+			if !streamed {
+				changes = append(changes, change)
+				continue
+			}
+			changes = append(changes, Change{Path: change.Path})
+			path := displayPath(change.Path, runParam)
+			failures = append(failures, path)
+			if err := writeNDJSON(path, changeFailure(change), stdout); err != nil {
+				return err
+			}
+		}
+	}
+	if !runParam.Verify && !runParam.Remove {
+		if err := checkPlaceholders(changes, projectParam); err != nil {
+			return err
+		}
+	}
+
+	if streamed {
+		return errs.complete(completeStreamedVerify(failures, runParam), runParam)
+	}
+	return errs.complete(completeRun(licensedFiles(walked, projectParam), changes, postModifyCommands(changes, projectParam), runParam, errs, stdout), runParam)
+}
+
+// walkProjectFiles walks the provided project directory and calls the provided function with the path of each file or
+// directory that matches include and does not match exclude as soon as it is found. Paths are matched relative to the
+// project directory and provided relative to the working directory in the same manner as
+// godellauncher.ListProjectPaths, and are provided in lexical order. If maxDepth is greater than 0, the directories
+// whose files are deeper than maxDepth are not walked (see RunParam.MaxDepth). The walk stops once the provided
+// context is done.
+func walkProjectFiles(ctx context.Context, projectDir string, include, exclude matcher.Matcher, maxDepth int, fn func(file string)) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine working directory")
+	}
+	if !filepath.IsAbs(projectDir) {
+		projectDir = filepath.Join(wd, projectDir)
+	}
+	relPathPrefix, err := filepath.Rel(wd, projectDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine relative path")
+	}
+	if fi, err := os.Stat(projectDir); err != nil {
+		return errors.Wrapf(err, "failed to stat %s", projectDir)
+	} else if !fi.IsDir() {
+		return errors.Errorf("%s is not a directory", projectDir)
+	}
+
+	return filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return errors.Wrapf(err, "walk failed at %s", path)
+		}
+		relPath, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve %s to relative path against base %s", path, projectDir)
+		}
+		if d.IsDir() && maxDepth > 0 && relPath != "." && strings.Count(relPath, string(filepath.Separator))+1 >= maxDepth {
+			return filepath.SkipDir
+		}
+		if include != nil && include.Match(relPath) && (exclude == nil || !exclude.Match(relPath)) {
+			fn(filepath.Join(relPathPrefix, relPath))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Palantir Technologies Inc. All rights reserved.
+// Use of this source code is governed by the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package licenseplugin
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-license/golicense"
+)
+
+// yearTokenRegexp matches a 4-digit year or a range of 4-digit years.
+var yearTokenRegexp = regexp.MustCompile(`\b\d{4}(?:-\d{4})?\b`)
+
+// withYearUpdates returns a visitor that updates the years of the headers of the content changed by the provided
+// visitor based on the provided parameters. If projectParam.UpdateYear is true, the years of the header of content
+// that the visitor changes are updated. If projectParam.RequireCurrentYear is true, the years of the header are
+// updated even if the visitor does not change the content, in which case the content is considered to be changed if
+// any year was updated.
+func withYearUpdates(visitor func(content string, licenser golicense.Licenser) (string, bool), projectParam ProjectParam) func(content string, licenser golicense.Licenser) (string, bool) {
+	if !projectParam.UpdateYear && !projectParam.RequireCurrentYear {
+		return visitor
+	}
+	return func(content string, licenser golicense.Licenser) (string, bool) {
+		newContent, changed := visitor(content, licenser)
+		if !changed && !projectParam.RequireCurrentYear {
+			return newContent, false
+		}
+		if skipContent(newContent) || licenser.Empty() || !licenser.Matches(newContent) {
+			return newContent, changed
+		}
+		updated := updateYears(newContent, licenser)
+		return updated, changed || updated != newContent
+	}
+}
+
+// updateYears returns the provided content, which must match the provided licenser, with every year of its header
+// that does not end with the current year replaced with a range from the year (or the start of the range) to the
+// current year. For example, "2019" and "2019-2023" are both replaced with "2019-2024" if the current year is 2024.
+func updateYears(content string, licenser golicense.Licenser) string {
+	start, end := headerBounds(content, licenser)
+	header := content[start:end]
+	currentYear := strconv.Itoa(time.Now().Year())
+
+	var updated strings.Builder
+	prevEnd := 0
+	for _, loc := range yearLocs(header, licenser) {
+		token := header[loc[0]:loc[1]]
+		updated.WriteString(header[prevEnd:loc[0]])
+		if !strings.HasSuffix(token, currentYear) {
+			token = token[:4] + "-" + currentYear
+		}
+		updated.WriteString(token)
+		prevEnd = loc[1]
+	}
+	updated.WriteString(header[prevEnd:])
+	return content[:start] + updated.String() + content[end:]
+}
+
+// headerEndYears returns the last year of each of the years of the header of the provided content, which must match
+// the provided licenser. For example, the last year of "2019-2023" is "2023".
+func headerEndYears(content string, licenser golicense.Licenser) []string {
+	start, end := headerBounds(content, licenser)
+	header := content[start:end]
+	var years []string
+	for _, loc := range yearLocs(header, licenser) {
+		years = append(years, header[loc[1]-4:loc[1]])
+	}
+	return years
+}
+
+// yearLocs returns the locations of the years (or ranges of years) in the provided header, which is the header of
+// content that matches the provided licenser. Years are identified by comparing the header with the header that the
+// licenser adds, so 4-digit numbers that are part of the literal text of the header are not included. Returns nil if
+// the years cannot be identified.
+func yearLocs(header string, licenser golicense.Licenser) [][]int {
+	// header added by the licenser has the current year for every year of the header
+	rendered := licenser.Add("")
+
+	headerLocs := yearTokenRegexp.FindAllStringIndex(header, -1)
+	renderedTokens := yearTokenRegexp.FindAllString(rendered, -1)
+	if len(headerLocs) != len(renderedTokens) {
+		return nil
+	}
+	currentYear := strconv.Itoa(time.Now().Year())
+
+	var locs [][]int
+	for i, loc := range headerLocs {
+		if renderedTokens[i] == currentYear {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}